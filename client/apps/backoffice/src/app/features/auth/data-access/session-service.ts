@@ -1,14 +1,16 @@
 import { computed, inject, Injectable, signal } from '@angular/core';
-import { Router } from '@angular/router';
+import { takeUntilDestroyed } from '@angular/core/rxjs-interop';
+import { NavigationEnd, Router } from '@angular/router';
 import {
     AUTH_REFRESH_BUFFER_MS,
     AuthService,
     Menu,
+    MenuPlacements,
     ProfileService,
     TokenStore,
     User,
 } from '@template-fullstack-client/api';
-import { catchError, forkJoin, of, switchMap, tap } from 'rxjs';
+import { catchError, filter, forkJoin, of, switchMap, tap } from 'rxjs';
 import { Logger } from '../../../core/logger/logger';
 
 @Injectable({
@@ -23,14 +25,29 @@ export class SessionService {
     private readonly refreshBufferMs = inject(AUTH_REFRESH_BUFFER_MS);
 
     private readonly userSignal = signal<User | null>(null);
-    private readonly menuSignal = signal<Menu[] | null>(null);
+    private readonly menuSignal = signal<MenuPlacements | null>(null);
 
     readonly user = this.userSignal.asReadonly();
-    readonly menu = this.menuSignal.asReadonly();
+    readonly menus = this.menuSignal.asReadonly();
+    /** The sidebar menu. */
+    readonly menu = computed(() => this.menus()?.sidebar ?? null);
+    /** The items the user pinned, in their order. */
+    readonly favorites = computed(() => this.menus()?.favorites ?? []);
+    /** The items the user last navigated to, latest first. */
+    readonly recent = computed(() => this.menus()?.recent ?? []);
 
     readonly isAuthenticated = this.authService.isAuthenticated;
     readonly isReady = computed(() => !!this.user() && !!this.menu());
 
+    constructor() {
+        this.router.events
+            .pipe(
+                filter((event): event is NavigationEnd => event instanceof NavigationEnd),
+                takeUntilDestroyed(),
+            )
+            .subscribe((event) => this.recordMenuVisit(event.urlAfterRedirects));
+    }
+
     initialize() {
         if (!this.isAuthenticated()) {
             if (!this.tokenStore.getRefreshToken()) {
@@ -86,6 +103,44 @@ export class SessionService {
         );
     }
 
+    /** Records a visit when url is the path of a menu item, feeding the recent section. */
+    private recordMenuVisit(url: string) {
+        const menus = this.menus();
+        if (!menus || !this.isAuthenticated()) {
+            return;
+        }
+
+        const path = url.split(/[?#]/)[0];
+        const placements = [menus.sidebar, menus.topbar, menus.user_menu, menus.quick_actions];
+        const item = placements.map((items) => this.findMenuItem(items, path)).find((found) => !!found);
+        if (!item) {
+            return;
+        }
+
+        this.profileService
+            .recordMenuVisit(item.id)
+            .pipe(
+                catchError((err) => {
+                    this.logger.warn('Failed to record menu visit', err);
+                    return of(null);
+                }),
+            )
+            .subscribe();
+    }
+
+    private findMenuItem(items: Menu[], path: string): Menu | undefined {
+        for (const item of items) {
+            if (item.path === path) {
+                return item;
+            }
+            const child = this.findMenuItem(item.children ?? [], path);
+            if (child) {
+                return child;
+            }
+        }
+        return undefined;
+    }
+
     private shouldRefreshNow(accessExpiresAt?: string): boolean {
         if (!accessExpiresAt) {
             return true;
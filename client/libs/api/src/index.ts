@@ -3,6 +3,7 @@ export * from './lib/features/auth/data-access/auth-tokens';
 export * from './lib/features/auth/data-access/permissions-service';
 export * from './lib/features/auth/data-access/token-store';
 export * from './lib/features/auth/data-access/profile-service';
+export * from './lib/features/auth/data-access/proof-of-work-service';
 export * from './lib/features/auth/types/dtos';
 export * from './lib/features/auth/types/schemas';
 export * from './lib/features/cms/cms-service';
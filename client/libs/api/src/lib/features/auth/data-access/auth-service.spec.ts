@@ -5,6 +5,7 @@ import { ApiClient } from '../../../core/api/api-client';
 import { AuthTokensResponse } from '../types/dtos';
 import { AuthService } from './auth-service';
 import { AUTH_API_BASE_URL, AUTH_REFRESH_BUFFER_MS } from './auth-tokens';
+import { ProofOfWorkService } from './proof-of-work-service';
 import { TokenStore } from './token-store';
 
 describe('AuthService', () => {
@@ -12,6 +13,7 @@ describe('AuthService', () => {
   let apiClient: ApiClient;
   let tokenStore: TokenStore;
   const baseUrl = 'https://api.example.com';
+  const powHeaders = { 'X-PoW-Challenge': 'challenge', 'X-PoW-Nonce': '42' };
 
   beforeEach(() => {
     const apiClientMock = {
@@ -31,6 +33,10 @@ describe('AuthService', () => {
         AuthService,
         { provide: ApiClient, useValue: apiClientMock },
         { provide: TokenStore, useValue: tokenStoreMock },
+        {
+          provide: ProofOfWorkService,
+          useValue: { headers: vi.fn(() => of(powHeaders)) },
+        },
         { provide: AUTH_API_BASE_URL, useValue: baseUrl },
         { provide: AUTH_REFRESH_BUFFER_MS, useValue: 60000 },
       ],
@@ -115,6 +121,7 @@ describe('AuthService', () => {
         `${baseUrl}/auth/login`,
         loginRequest,
         {
+          headers: powHeaders,
           withCredentials: true,
         },
       );
@@ -160,7 +167,7 @@ describe('AuthService', () => {
       expect(apiClientMock.post).toHaveBeenCalledWith(
         `${baseUrl}/auth/register`,
         registerRequest,
-        { withCredentials: true },
+        { headers: powHeaders, withCredentials: true },
       );
     });
   });
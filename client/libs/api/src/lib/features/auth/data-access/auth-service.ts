@@ -7,7 +7,7 @@ import {
     PLATFORM_ID,
     signal,
 } from '@angular/core';
-import { catchError, map, of } from 'rxjs';
+import { catchError, map, of, switchMap } from 'rxjs';
 
 import { ApiClient } from '../../../core/api/api-client';
 import {
@@ -18,6 +18,7 @@ import {
 } from '../types/dtos';
 import { AuthSession } from '../types/schemas';
 import { AUTH_API_BASE_URL, AUTH_REFRESH_BUFFER_MS } from './auth-tokens';
+import { ProofOfWorkService } from './proof-of-work-service';
 import { TokenStore } from './token-store';
 
 @Injectable({
@@ -26,6 +27,7 @@ import { TokenStore } from './token-store';
 export class AuthService {
     private readonly api = inject(ApiClient);
     private readonly tokenStore = inject(TokenStore);
+    private readonly proofOfWork = inject(ProofOfWorkService);
     private readonly baseUrl = inject(AUTH_API_BASE_URL);
     private readonly refreshBufferMs = inject(AUTH_REFRESH_BUFFER_MS);
     private readonly destroyRef = inject(DestroyRef);
@@ -52,23 +54,35 @@ export class AuthService {
     }
 
     login(request: AuthLoginRequest) {
-        return this.api
-            .post<AuthTokensResponse>(`${this.baseUrl}/auth/login`, request, {
-                withCredentials: false, // TODO: Change this to true when near prod
-            })
-            .pipe(map((response) => this.applyTokens(response)));
+        return this.proofOfWork.headers().pipe(
+            switchMap((headers) =>
+                this.api.post<AuthTokensResponse>(
+                    `${this.baseUrl}/auth/login`,
+                    request,
+                    {
+                        headers,
+                        withCredentials: false, // TODO: Change this to true when near prod
+                    },
+                ),
+            ),
+            map((response) => this.applyTokens(response)),
+        );
     }
 
     register(request: AuthRegisterRequest) {
-        return this.api
-            .post<AuthTokensResponse>(
-                `${this.baseUrl}/auth/register`,
-                request,
-                {
-                    withCredentials: false, // TODO: Change this to true when near prod
-                },
-            )
-            .pipe(map((response) => this.applyTokens(response)));
+        return this.proofOfWork.headers().pipe(
+            switchMap((headers) =>
+                this.api.post<AuthTokensResponse>(
+                    `${this.baseUrl}/auth/register`,
+                    request,
+                    {
+                        headers,
+                        withCredentials: false, // TODO: Change this to true when near prod
+                    },
+                ),
+            ),
+            map((response) => this.applyTokens(response)),
+        );
     }
 
     refresh() {
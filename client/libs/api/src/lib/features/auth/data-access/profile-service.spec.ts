@@ -3,7 +3,7 @@ import { lastValueFrom, of, throwError } from 'rxjs';
 import { beforeEach, describe, expect, it, vi } from 'vitest';
 import { ApiClient } from '../../../core/api/api-client';
 
-import { Menu, User } from '../types/schemas';
+import { MenuPlacements, User } from '../types/schemas';
 import { AUTH_API_BASE_URL } from './auth-tokens';
 import { ProfileService } from './profile-service';
 
@@ -15,6 +15,8 @@ describe('ProfileService', () => {
   beforeEach(() => {
     const apiClientMock = {
       get: vi.fn(),
+      put: vi.fn(),
+      post: vi.fn(),
     };
 
     TestBed.configureTestingModule({
@@ -103,10 +105,22 @@ describe('ProfileService', () => {
   });
 
   describe('getMyMenu', () => {
+    const emptyMenu: MenuPlacements = {
+      sidebar: [],
+      topbar: [],
+      user_menu: [],
+      quick_actions: [],
+      favorites: [],
+      recent: [],
+    };
+
     it('should fetch user menu', async () => {
-      const mockMenu: Menu = {
-        dashboard: { label: 'Dashboard', path: '/dashboard' },
-        users: { label: 'Users', path: '/users' },
+      const mockMenu: MenuPlacements = {
+        ...emptyMenu,
+        sidebar: [
+          { id: 'dashboard', label: 'Dashboard', path: '/dashboard' },
+          { id: 'users', label: 'Users', path: '/users' },
+        ],
       };
 
       const apiClientMock = apiClient as any;
@@ -121,26 +135,28 @@ describe('ProfileService', () => {
     });
 
     it('should fetch empty menu', async () => {
-      const mockMenu: Menu = {};
-
       const apiClientMock = apiClient as any;
-      apiClientMock.get.mockReturnValue(of(mockMenu));
+      apiClientMock.get.mockReturnValue(of(emptyMenu));
 
       const menu = await lastValueFrom(service.getMyMenu());
 
-      expect(menu).toEqual({});
-      expect(Object.keys(menu)).toHaveLength(0);
+      expect(menu).toEqual(emptyMenu);
+      expect(menu.sidebar).toHaveLength(0);
     });
 
     it('should fetch complex nested menu structure', async () => {
-      const mockMenu: Menu = {
-        settings: {
-          label: 'Settings',
-          children: [
-            { label: 'Profile', path: '/settings/profile' },
-            { label: 'Security', path: '/settings/security' },
-          ],
-        },
+      const mockMenu: MenuPlacements = {
+        ...emptyMenu,
+        user_menu: [
+          {
+            id: 'settings',
+            label: 'Settings',
+            children: [
+              { id: 'profile', label: 'Profile', path: '/settings/profile' },
+              { id: 'security', label: 'Security', path: '/settings/security' },
+            ],
+          },
+        ],
       };
 
       const apiClientMock = apiClient as any;
@@ -148,7 +164,7 @@ describe('ProfileService', () => {
 
       const menu = await lastValueFrom(service.getMyMenu());
 
-      expect(menu['settings']).toBeDefined();
+      expect(menu.user_menu[0].children).toHaveLength(2);
     });
 
     it('should handle error when fetching menu', async () => {
@@ -162,4 +178,35 @@ describe('ProfileService', () => {
       );
     });
   });
+
+  describe('setMenuPins', () => {
+    it('should replace pinned menu items in order', async () => {
+      const apiClientMock = apiClient as any;
+      apiClientMock.put.mockReturnValue(of({ menu_ids: ['pages', 'roles'] }));
+
+      const result = await lastValueFrom(
+        service.setMenuPins(['pages', 'roles']),
+      );
+
+      expect(result.menu_ids).toEqual(['pages', 'roles']);
+      expect(apiClientMock.put).toHaveBeenCalledWith(
+        `${baseUrl}/backoffice/me/menu/pins`,
+        { menu_ids: ['pages', 'roles'] },
+      );
+    });
+  });
+
+  describe('recordMenuVisit', () => {
+    it('should record a visit to a menu item', async () => {
+      const apiClientMock = apiClient as any;
+      apiClientMock.post.mockReturnValue(of({ status: 'recorded' }));
+
+      await lastValueFrom(service.recordMenuVisit('pages'));
+
+      expect(apiClientMock.post).toHaveBeenCalledWith(
+        `${baseUrl}/backoffice/me/menu/recent`,
+        { menu_id: 'pages' },
+      );
+    });
+  });
 });
@@ -2,7 +2,7 @@ import { inject, Injectable } from '@angular/core';
 
 import { ApiClient } from '../../../core/api/api-client';
 
-import { Menu, User } from '../types/schemas';
+import { MenuPlacements, User } from '../types/schemas';
 import { AUTH_API_BASE_URL } from './auth-tokens';
 
 @Injectable({
@@ -17,7 +17,21 @@ export class ProfileService {
     }
 
     getMyMenu() {
-        return this.api.get<Menu[]>(`${this.baseUrl}/backoffice/me/menu`);
+        return this.api.get<MenuPlacements>(`${this.baseUrl}/backoffice/me/menu`);
+    }
+
+    /** Replaces the pinned menu items, in order. */
+    setMenuPins(menuIds: string[]) {
+        return this.api.put<{ menu_ids: string[] }>(`${this.baseUrl}/backoffice/me/menu/pins`, {
+            menu_ids: menuIds,
+        });
+    }
+
+    /** Records a visit to a menu item for the recent section. */
+    recordMenuVisit(menuId: string) {
+        return this.api.post<unknown>(`${this.baseUrl}/backoffice/me/menu/recent`, {
+            menu_id: menuId,
+        });
     }
 
     // TODO: Implement settings endpoints when backend is ready
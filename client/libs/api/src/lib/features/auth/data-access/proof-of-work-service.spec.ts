@@ -0,0 +1,66 @@
+import { TestBed } from '@angular/core/testing';
+import { lastValueFrom, of } from 'rxjs';
+import { describe, expect, it, vi } from 'vitest';
+import { ApiClient } from '../../../core/api/api-client';
+import { AUTH_API_BASE_URL } from './auth-tokens';
+import {
+  POW_CHALLENGE_HEADER,
+  POW_NONCE_HEADER,
+  ProofOfWorkService,
+  solve,
+} from './proof-of-work-service';
+
+async function zeroBits(input: string): Promise<number> {
+  const digest = new Uint8Array(
+    await crypto.subtle.digest('SHA-256', new TextEncoder().encode(input)),
+  );
+  let bits = 0;
+  for (const b of digest) {
+    if (b !== 0) {
+      return bits + Math.clz32(b) - 24;
+    }
+    bits += 8;
+  }
+  return bits;
+}
+
+describe('ProofOfWorkService', () => {
+  it('should solve a challenge at the requested difficulty', async () => {
+    const nonce = await solve('challenge', 8);
+
+    expect(await zeroBits(`challenge:${nonce}`)).toBeGreaterThanOrEqual(8);
+  });
+
+  it('should fetch a challenge and return the solution headers', async () => {
+    const apiClientMock = {
+      get: vi.fn().mockReturnValue(
+        of({
+          challenge: 'abc.def',
+          difficulty: 4,
+          algorithm: 'sha256-leading-zero-bits',
+          expires_at: new Date().toISOString(),
+        }),
+      ),
+    };
+
+    TestBed.configureTestingModule({
+      providers: [
+        ProofOfWorkService,
+        { provide: ApiClient, useValue: apiClientMock },
+        { provide: AUTH_API_BASE_URL, useValue: 'https://api.example.com' },
+      ],
+    });
+
+    const headers = await lastValueFrom(
+      TestBed.inject(ProofOfWorkService).headers(),
+    );
+
+    expect(apiClientMock.get).toHaveBeenCalledWith(
+      'https://api.example.com/auth/pow/challenge',
+    );
+    expect(headers[POW_CHALLENGE_HEADER]).toBe('abc.def');
+    expect(
+      await zeroBits(`abc.def:${headers[POW_NONCE_HEADER]}`),
+    ).toBeGreaterThanOrEqual(4);
+  });
+});
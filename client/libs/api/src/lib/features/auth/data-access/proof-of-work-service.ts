@@ -0,0 +1,68 @@
+import { Injectable, inject } from '@angular/core';
+import { from, switchMap } from 'rxjs';
+
+import { ApiClient } from '../../../core/api/api-client';
+import { ProofOfWorkChallengeResponse } from '../types/dtos';
+import { AUTH_API_BASE_URL } from './auth-tokens';
+
+export const POW_CHALLENGE_HEADER = 'X-PoW-Challenge';
+export const POW_NONCE_HEADER = 'X-PoW-Nonce';
+
+/**
+ * Solves the server's proof-of-work challenges for bot-protected forms
+ * (login, register, magic link).
+ */
+@Injectable({
+  providedIn: 'root',
+})
+export class ProofOfWorkService {
+  private readonly api = inject(ApiClient);
+  private readonly baseUrl = inject(AUTH_API_BASE_URL);
+
+  /**
+   * Fetches a fresh challenge and resolves to the headers carrying its solution.
+   * Each challenge is single-use, so call this once per protected request.
+   */
+  headers() {
+    return this.api
+      .get<ProofOfWorkChallengeResponse>(`${this.baseUrl}/auth/pow/challenge`)
+      .pipe(
+        switchMap((c) =>
+          from(
+            solve(c.challenge, c.difficulty).then(
+              (nonce): Record<string, string> => ({
+                [POW_CHALLENGE_HEADER]: c.challenge,
+                [POW_NONCE_HEADER]: nonce,
+              }),
+            ),
+          ),
+        ),
+      );
+  }
+}
+
+/** Finds a nonce such that SHA-256("<challenge>:<nonce>") starts with `difficulty` zero bits. */
+export async function solve(challenge: string, difficulty: number): Promise<string> {
+  const encoder = new TextEncoder();
+  for (let n = 0; ; n++) {
+    const nonce = n.toString();
+    const digest = await crypto.subtle.digest(
+      'SHA-256',
+      encoder.encode(`${challenge}:${nonce}`),
+    );
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return nonce;
+    }
+  }
+}
+
+function leadingZeroBits(bytes: Uint8Array): number {
+  let total = 0;
+  for (const b of bytes) {
+    if (b !== 0) {
+      return total + Math.clz32(b) - 24;
+    }
+    total += 8;
+  }
+  return total;
+}
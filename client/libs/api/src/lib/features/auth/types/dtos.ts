@@ -31,3 +31,10 @@ export interface AddPermissionRequest {
 export interface AssignRoleRequest {
   role_id: number;
 }
+
+export interface ProofOfWorkChallengeResponse {
+  challenge: string;
+  difficulty: number;
+  algorithm: string;
+  expires_at: string;
+}
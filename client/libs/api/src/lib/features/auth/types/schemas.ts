@@ -29,5 +29,14 @@ export type Menu = {
     // parentId?: string;
     // permissions?: string[];
     // visible: boolean;
+    badge?: { count: number };
     children?: Menu[];
 };
+
+export type MenuPlacement = 'sidebar' | 'topbar' | 'user_menu' | 'quick_actions';
+
+/** Flat lists of shortcuts to placement items: the user's pins and recent visits. */
+export type MenuSection = 'favorites' | 'recent';
+
+/** The user's menu, one tree per placement plus the sections. Every key is present. */
+export type MenuPlacements = Record<MenuPlacement | MenuSection, Menu[]>;
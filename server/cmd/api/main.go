@@ -15,11 +15,15 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/cors"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth"
-	authHttp "github.com/rubenalves-dev/template-fullstack/server/internal/auth/delivery/http"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/cms"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/platform"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/features"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/pow"
 )
 
 func main() {
@@ -50,15 +54,15 @@ func main() {
 
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
+	router.Use(httputil.RealIP(cfg.TrustedProxies))
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 
 	corsPtr := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Adjust as needed
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "DPoP", "If-None-Match", pow.ChallengeHeader, pow.NonceHeader},
+		ExposedHeaders:   []string{"ETag", "Link", "WWW-Authenticate"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	})
@@ -70,19 +74,39 @@ func main() {
 		jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "OK"})
 	})
 
+	enc, err := platform.NewEncryptor(cfg)
+	if err != nil {
+		logger.Error("failed to load encryption keys", "error", err)
+		os.Exit(1)
+	}
+
+	mailer := platform.NewMailer(cfg)
+	media := platform.NewMediaStorage(cfg)
+	router.Handle("/media/*", http.StripPrefix("/media", platform.MediaHandler(cfg)))
+
+	// Every module evaluates feature flags against this instance's replica.
+	flagClient := features.NewClient(nc)
+
 	// Auth Module
-	authModule := auth.NewModule(dbPool, nc, cfg.JWTSecret)
+	authModule := auth.NewModule(dbPool, nc, cfg, mailer, media, enc, flagClient)
 	authModule.RegisterRoutes(router)
 
 	// Microservices
 	cmsModule := cms.NewModule(dbPool, nc)
+	auditModule := audit.NewModule(dbPool, nc)
+	flagsModule := featureflags.NewModule(dbPool, nc, flagClient)
 
 	// Protected routes modules
 	router.Group(func(r chi.Router) {
-		r.Use(authHttp.AuthMiddleware(cfg.JWTSecret))
-
-		authModule.RegisterProtectedRoutes(r)
-		cmsModule.RegisterRoutes(r)
+		r.Use(authModule.AuthMiddleware())
+
+		authModule.RegisterProtectedRoutes(r, flagsModule.RequireFlag)
+		// The pages API checks no permissions, so service clients cannot use it.
+		r.With(authModule.RequireUser()).Group(func(r chi.Router) {
+			cmsModule.RegisterRoutes(r, authModule.RequireRecentAuth())
+		})
+		auditModule.RegisterRoutes(r, authModule.RequirePermission)
+		flagsModule.RegisterRoutes(r, authModule.RequirePermission)
 	})
 
 	server := &http.Server{
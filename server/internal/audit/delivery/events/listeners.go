@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// auditedSubjects lists the subject trees whose attributed events are persisted.
+var auditedSubjects = []string{"auth.>", "cms.>", "flags.>"}
+
+// auditQueue makes a single instance record each event.
+const auditQueue = "audit"
+
+type eventHandler struct {
+	svc domain.Service
+}
+
+// trailPayload mirrors events.Trail but keeps the snapshots as raw JSON.
+type trailPayload struct {
+	events.Trail
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func RegisterListeners(nc *nats.Conn, svc domain.Service) {
+	h := &eventHandler{svc: svc}
+
+	for _, subject := range auditedSubjects {
+		_, err := nc.QueueSubscribe(subject, auditQueue, h.handleAuditedEvent)
+		if err != nil {
+			log.Printf("Failed to subscribe to %s: %v", subject, err)
+		}
+	}
+}
+
+func (h *eventHandler) handleAuditedEvent(m *nats.Msg) {
+	// Request-reply traffic is not a state change.
+	if m.Reply != "" {
+		return
+	}
+
+	var payload trailPayload
+	if err := json.Unmarshal(m.Data, &payload); err != nil {
+		log.Printf("Failed to unmarshal audited event %s: %v", m.Subject, err)
+		return
+	}
+	// Events that carry no target are not actor-attributed and are skipped.
+	if payload.Target.Type == "" {
+		return
+	}
+
+	event := domain.Event{
+		OccurredAt: payload.OccurredAt,
+		ActorIP:    payload.Actor.IP,
+		RequestID:  payload.Actor.RequestID,
+		Action:     m.Subject,
+		TargetType: payload.Target.Type,
+		TargetID:   payload.Target.ID,
+		Before:     payload.Before,
+		After:      payload.After,
+	}
+	actor := payload.Actor.UserID
+	if actor == "" {
+		// Service clients act on their own behalf.
+		actor = payload.Actor.ClientID
+	}
+	if actorID, err := uuid.Parse(actor); err == nil {
+		event.ActorID = &actorID
+	}
+
+	if err := h.svc.Record(context.Background(), event); err != nil {
+		log.Printf("Failed to record audit event %s: %v", m.Subject, err)
+	}
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// recordingService keeps the events it was asked to record.
+type recordingService struct {
+	domain.Service
+	recorded []domain.Event
+}
+
+func (s *recordingService) Record(_ context.Context, event domain.Event) error {
+	s.recorded = append(s.recorded, event)
+	return nil
+}
+
+func message(t *testing.T, subject string, trail events.Trail) *nats.Msg {
+	t.Helper()
+	data, err := json.Marshal(struct {
+		events.Trail
+		PageID string `json:"page_id"`
+	}{Trail: trail, PageID: "p1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &nats.Msg{Subject: subject, Data: data}
+}
+
+func TestHandleAuditedEventMapsTheTrail(t *testing.T) {
+	svc := &recordingService{}
+	h := &eventHandler{svc: svc}
+	userID := uuid.New()
+	occurredAt := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	h.handleAuditedEvent(message(t, "cms.page.deleted", events.Trail{
+		Actor:      events.Actor{UserID: userID.String(), IP: "203.0.113.7", RequestID: "req-1"},
+		Target:     events.Target{Type: "cms.page", ID: "p1"},
+		Before:     map[string]string{"title": "About"},
+		OccurredAt: occurredAt,
+	}))
+
+	if len(svc.recorded) != 1 {
+		t.Fatalf("expected one event, got %d", len(svc.recorded))
+	}
+	e := svc.recorded[0]
+	if e.ActorID == nil || *e.ActorID != userID || e.ActorIP != "203.0.113.7" || e.RequestID != "req-1" {
+		t.Fatalf("unexpected actor %+v", e)
+	}
+	if e.Action != "cms.page.deleted" || e.TargetType != "cms.page" || e.TargetID != "p1" || !e.OccurredAt.Equal(occurredAt) {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if string(e.Before) != `{"title":"About"}` || e.After != nil {
+		t.Fatalf("expected the snapshots to be kept as JSON, got before %s after %s", e.Before, e.After)
+	}
+}
+
+func TestHandleAuditedEventAttributesServiceClients(t *testing.T) {
+	svc := &recordingService{}
+	h := &eventHandler{svc: svc}
+	clientID := uuid.New()
+
+	h.handleAuditedEvent(message(t, "cms.page.published", events.Trail{
+		Actor:  events.Actor{ClientID: clientID.String()},
+		Target: events.Target{Type: "cms.page", ID: "p1"},
+	}))
+	h.handleAuditedEvent(message(t, "cms.page.published", events.Trail{
+		Actor:  events.Actor{UserID: "not-a-uuid"},
+		Target: events.Target{Type: "cms.page", ID: "p1"},
+	}))
+
+	if len(svc.recorded) != 2 {
+		t.Fatalf("expected two events, got %d", len(svc.recorded))
+	}
+	if got := svc.recorded[0].ActorID; got == nil || *got != clientID {
+		t.Fatalf("expected the client to be the actor, got %v", got)
+	}
+	if got := svc.recorded[1].ActorID; got != nil {
+		t.Fatalf("expected an unparsable actor to be left empty, got %v", got)
+	}
+}
+
+func TestHandleAuditedEventSkipsUnattributedMessages(t *testing.T) {
+	svc := &recordingService{}
+	h := &eventHandler{svc: svc}
+
+	request := message(t, "auth.permissions.check", events.Trail{Target: events.Target{Type: "auth.user", ID: "u1"}})
+	request.Reply = "_INBOX.1"
+	h.handleAuditedEvent(request)
+	h.handleAuditedEvent(message(t, "auth.login.succeeded", events.Trail{}))
+	h.handleAuditedEvent(&nats.Msg{Subject: "cms.page.created", Data: []byte("{")})
+
+	if len(svc.recorded) != 0 {
+		t.Fatalf("expected nothing to be recorded, got %+v", svc.recorded)
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+)
+
+func TestParseFilter(t *testing.T) {
+	actorID := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/backoffice/audit?actor_id="+actorID.String()+
+		"&action=cms.page.deleted&target_type=cms.page&target_id=p1"+
+		"&from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z&limit=20&offset=40", nil)
+
+	filter, err := parseFilter(req)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if filter.ActorID == nil || *filter.ActorID != actorID || filter.Action != "cms.page.deleted" ||
+		filter.TargetType != "cms.page" || filter.TargetID != "p1" || filter.Limit != 20 || filter.Offset != 40 {
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+	if filter.From == nil || !filter.From.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) ||
+		filter.To == nil || !filter.To.Equal(time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected range %v %v", filter.From, filter.To)
+	}
+
+	for _, query := range []string{"actor_id=me", "from=yesterday", "to=2024-05-02", "limit=ten", "offset=-"} {
+		req := httptest.NewRequest(http.MethodGet, "/backoffice/audit?"+query, nil)
+		if _, err := parseFilter(req); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+// pagedService serves events newest first and pages them by cursor like the repository.
+type pagedService struct {
+	domain.Service
+	events  []domain.Event
+	filters []domain.Filter
+}
+
+func (s *pagedService) ListEvents(_ context.Context, filter domain.Filter) ([]domain.Event, error) {
+	s.filters = append(s.filters, filter)
+	start := 0
+	if filter.After != nil {
+		for i, e := range s.events {
+			if e.ID == filter.After.ID {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+filter.Limit, len(s.events))
+	return s.events[start:end], nil
+}
+
+func TestExportEventsPagesByCursor(t *testing.T) {
+	svc := &pagedService{}
+	now := time.Now().UTC()
+	for i := range exportBatchSize + 5 {
+		svc.events = append(svc.events, domain.Event{
+			ID:         uuid.New(),
+			OccurredAt: now.Add(-time.Duration(i) * time.Second),
+			Action:     "cms.page.updated",
+			TargetType: "cms.page",
+			TargetID:   "p1",
+		})
+	}
+	h := &AuditHandler{svc: svc}
+
+	rec := httptest.NewRecorder()
+	h.ExportEvents(rec, httptest.NewRequest(http.MethodGet, "/backoffice/audit/export?offset=7", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("expected a CSV, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(svc.events)+1 {
+		t.Fatalf("expected a header and %d rows, got %d", len(svc.events), len(rows))
+	}
+	if rows[1][0] != svc.events[0].ID.String() || rows[len(rows)-1][0] != svc.events[len(svc.events)-1].ID.String() {
+		t.Fatal("expected every event exactly once, newest first")
+	}
+
+	if len(svc.filters) != 2 || svc.filters[0].Offset != 0 || svc.filters[0].After != nil {
+		t.Fatalf("expected the export to start at the top and ignore offset, got %+v", svc.filters)
+	}
+	last := svc.events[exportBatchSize-1]
+	if after := svc.filters[1].After; after == nil || after.ID != last.ID || !after.OccurredAt.Equal(last.OccurredAt) {
+		t.Fatalf("expected the second batch to resume after the last row written, got %+v", after)
+	}
+}
+
+func TestExportEventsEscapesFormulas(t *testing.T) {
+	svc := &pagedService{events: []domain.Event{{
+		ID:         uuid.New(),
+		OccurredAt: time.Now().UTC(),
+		Action:     "cms.page.updated",
+		TargetType: "cms.page",
+		TargetID:   "=cmd|' /C calc'!A0",
+	}}}
+	h := &AuditHandler{svc: svc}
+
+	rec := httptest.NewRecorder()
+	h.ExportEvents(rec, httptest.NewRequest(http.MethodGet, "/backoffice/audit/export", nil))
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rows[1][7]; got != "'=cmd|' /C calc'!A0" {
+		t.Fatalf("expected the target to be escaped, got %q", got)
+	}
+}
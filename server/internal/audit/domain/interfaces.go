@@ -0,0 +1,13 @@
+package domain
+
+import "context"
+
+type Repository interface {
+	Insert(ctx context.Context, event *Event) error
+	List(ctx context.Context, filter Filter) ([]Event, error)
+}
+
+type Service interface {
+	Record(ctx context.Context, event Event) error
+	ListEvents(ctx context.Context, filter Filter) ([]Event, error)
+}
@@ -0,0 +1,9 @@
+package domain
+
+const (
+	PermissionAuditRead = "audit.event.read"
+)
+
+func GetAvailablePermissions() []string {
+	return []string{PermissionAuditRead}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is an immutable record of an action performed on a target by an actor.
+type Event struct {
+	ID         uuid.UUID       `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorIP    string          `json:"actor_ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Filter narrows an audit query. Zero values are ignored.
+type Filter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+	// After resumes a listing after the given event instead of skipping Offset rows, so rows
+	// recorded meanwhile neither shift nor repeat the pages.
+	After *Cursor
+}
+
+// Cursor is the position of an event in the listing order: newest first, ties broken by ID.
+type Cursor struct {
+	OccurredAt time.Time
+	ID         uuid.UUID
+}
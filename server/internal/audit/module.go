@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	nethttp "net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/delivery/events"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/delivery/http"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/repositories"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/service"
+	globalEvents "github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+type AuditModule struct {
+	Service domain.Service
+}
+
+func NewModule(pool *pgxpool.Pool, nc *nats.Conn) *AuditModule {
+	repo := repositories.NewPgxRepository(pool)
+	svc := service.NewAuditService(repo)
+
+	events.RegisterListeners(nc, svc)
+
+	go func() {
+		payload := globalEvents.SystemPermissionsRegisteredData{
+			Module:      "audit",
+			Permissions: domain.GetAvailablePermissions(),
+		}
+		data, _ := json.Marshal(payload)
+		if err := nc.Publish(globalEvents.SystemPermissionsRegister, data); err != nil {
+			log.Printf("[ERROR] Failed to publish permissions for audit module: %v", err)
+		}
+	}()
+
+	return &AuditModule{Service: svc}
+}
+
+// RegisterRoutes mounts the audit query API. authorize guards each route with the given permission.
+func (m *AuditModule) RegisterRoutes(r chi.Router, authorize func(permission string) func(nethttp.Handler) nethttp.Handler) {
+	http.RegisterHTTPHandlers(r, m.Service, authorize)
+}
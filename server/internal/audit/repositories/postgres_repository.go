@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+)
+
+type pgxRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxRepository(pool *pgxpool.Pool) domain.Repository {
+	return &pgxRepo{pool: pool}
+}
+
+func (r *pgxRepo) Insert(ctx context.Context, event *domain.Event) error {
+	query := `
+		INSERT INTO audit_events
+			(id, occurred_at, actor_id, actor_ip, request_id, action, target_type, target_id, before, after)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.pool.Exec(ctx, query,
+		event.ID, event.OccurredAt, event.ActorID, event.ActorIP, event.RequestID,
+		event.Action, event.TargetType, event.TargetID, nullableJSON(event.Before), nullableJSON(event.After),
+	)
+	if err != nil {
+		return fmt.Errorf("audit repo insert: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) List(ctx context.Context, filter domain.Filter) ([]domain.Event, error) {
+	query, args := listQuery(filter)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("audit repo list: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorID, &e.ActorIP, &e.RequestID, &e.Action, &e.TargetType, &e.TargetID, &e.Before, &e.After, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// listQuery builds the filtered listing, newest first. A cursor pages by keyset on
+// (occurred_at, id), which idx_audit_events_occurred_at_id serves.
+func listQuery(filter domain.Filter) (string, []any) {
+	var conditions []string
+	var args []any
+	addCondition := func(clause string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(clause, len(args)))
+	}
+
+	if filter.ActorID != nil {
+		addCondition("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		addCondition("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		addCondition("target_id = $%d", filter.TargetID)
+	}
+	if filter.From != nil {
+		addCondition("occurred_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("occurred_at < $%d", *filter.To)
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.OccurredAt, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(occurred_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT id, occurred_at, actor_id, actor_ip, request_id, action, target_type, target_id, before, after, created_at
+		FROM audit_events
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY occurred_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return query, args
+}
+
+func nullableJSON(value []byte) any {
+	if len(value) == 0 || string(value) == "null" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+)
+
+func TestListQuery(t *testing.T) {
+	query, args := listQuery(domain.Filter{Limit: 50})
+	if strings.Contains(query, "WHERE") || !strings.Contains(query, "ORDER BY occurred_at DESC, id DESC LIMIT $1 OFFSET $2") {
+		t.Fatalf("unexpected unfiltered query %q", query)
+	}
+	if !reflect.DeepEqual(args, []any{50, 0}) {
+		t.Fatalf("unexpected args %v", args)
+	}
+
+	cursor := domain.Cursor{OccurredAt: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), ID: uuid.New()}
+	query, args = listQuery(domain.Filter{Action: "cms.page.deleted", After: &cursor, Limit: 1000})
+	if !strings.Contains(query, "WHERE action = $1 AND (occurred_at, id) < ($2, $3)") ||
+		!strings.Contains(query, "LIMIT $4 OFFSET $5") {
+		t.Fatalf("unexpected keyset query %q", query)
+	}
+	if !reflect.DeepEqual(args, []any{"cms.page.deleted", cursor.OccurredAt, cursor.ID, 1000, 0}) {
+		t.Fatalf("unexpected args %v", args)
+	}
+}
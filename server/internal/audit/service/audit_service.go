@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+)
+
+type auditService struct {
+	repo domain.Repository
+}
+
+func NewAuditService(repository domain.Repository) domain.Service {
+	return &auditService{repo: repository}
+}
+
+func (s auditService) Record(ctx context.Context, event domain.Event) error {
+	if event.Action == "" || event.TargetType == "" {
+		return httputil.ErrBadRequest
+	}
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	return s.repo.Insert(ctx, &event)
+}
+
+func (s auditService) ListEvents(ctx context.Context, filter domain.Filter) ([]domain.Event, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.List(ctx, filter)
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rubenalves-dev/template-fullstack/server/internal/audit/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+type memoryRepo struct {
+	inserted []domain.Event
+	filter   domain.Filter
+}
+
+func (r *memoryRepo) Insert(_ context.Context, event *domain.Event) error {
+	r.inserted = append(r.inserted, *event)
+	return nil
+}
+
+func (r *memoryRepo) List(_ context.Context, filter domain.Filter) ([]domain.Event, error) {
+	r.filter = filter
+	return nil, nil
+}
+
+func TestRecordFillsDefaults(t *testing.T) {
+	repo := &memoryRepo{}
+	svc := NewAuditService(repo)
+
+	if err := svc.Record(context.Background(), domain.Event{Action: "cms.page.deleted", TargetType: "cms.page"}); err != nil {
+		t.Fatal(err)
+	}
+	if e := repo.inserted[0]; e.ID.String() == "00000000-0000-0000-0000-000000000000" || e.OccurredAt.IsZero() {
+		t.Fatalf("expected an ID and time to be assigned, got %+v", e)
+	}
+
+	if err := svc.Record(context.Background(), domain.Event{Action: "cms.page.deleted"}); !errors.Is(err, httputil.ErrBadRequest) {
+		t.Fatalf("expected an event without a target to be rejected, got %v", err)
+	}
+}
+
+func TestListEventsBoundsThePage(t *testing.T) {
+	repo := &memoryRepo{}
+	svc := NewAuditService(repo)
+
+	cases := []struct{ limit, offset, wantLimit, wantOffset int }{
+		{0, 0, defaultPageSize, 0},
+		{5000, -3, maxPageSize, 0},
+		{20, 40, 20, 40},
+	}
+	for _, tc := range cases {
+		if _, err := svc.ListEvents(context.Background(), domain.Filter{Limit: tc.limit, Offset: tc.offset}); err != nil {
+			t.Fatal(err)
+		}
+		if repo.filter.Limit != tc.wantLimit || repo.filter.Offset != tc.wantOffset {
+			t.Errorf("limit %d offset %d: got %d %d", tc.limit, tc.offset, repo.filter.Limit, repo.filter.Offset)
+		}
+	}
+}
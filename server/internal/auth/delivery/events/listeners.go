@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
@@ -11,12 +12,19 @@ import (
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
 )
 
+// menuInvalidations are the subjects after which cached menu trees may be stale. Menu
+// registrations reach every instance, which purges its cache when applying them.
+var menuInvalidations = []string{"auth.menu.>", "auth.role.>"}
+
 type eventHandler struct {
 	svc domain.Service
 }
 
-func RegisterListeners(nc *nats.Conn, svc domain.Service) {
+// RegisterListeners subscribes the auth module to system events and answers permission requests,
+// caching their results for permissionCacheTTL.
+func RegisterListeners(nc *nats.Conn, svc domain.Service, permissionCacheTTL time.Duration) {
 	h := &eventHandler{svc: svc}
+	registerPermissionResponders(nc, svc, permissionCacheTTL)
 
 	// Example
 	_, err := nc.Subscribe("ecommerce.order.completed", h.handleOrderCompleted)
@@ -33,6 +41,13 @@ func RegisterListeners(nc *nats.Conn, svc domain.Service) {
 	if err != nil {
 		log.Printf("Failed to subscribe to %s: %v", events.SystemMenusRegister, err)
 	}
+
+	// Every instance keeps its own menu cache, so invalidations are not queue-grouped.
+	for _, subject := range menuInvalidations {
+		if _, err := nc.Subscribe(subject, func(*nats.Msg) { svc.InvalidateMenuCache() }); err != nil {
+			log.Printf("Failed to subscribe to %s: %v", subject, err)
+		}
+	}
 }
 
 func (h *eventHandler) handlePermissionsRegister(m *nats.Msg) {
@@ -47,17 +62,37 @@ func (h *eventHandler) handlePermissionsRegister(m *nats.Msg) {
 	}
 }
 
+// handleMenusRegister applies a domain's menu. Registrations sent as requests are answered with
+// the outcome and any problems found, so the publishing module can log them too.
 func (h *eventHandler) handleMenusRegister(m *nats.Msg) {
 	var payload events.SystemMenusRegisteredData
 	if err := json.Unmarshal(m.Data, &payload); err != nil {
 		log.Printf("Failed to unmarshal menus event: %v", err)
+		if m.Reply != "" {
+			respond(m, events.SystemMenusRegisteredReply{Error: "invalid request"})
+		}
 		return
 	}
 
 	defs := flattenMenuDefinitions(payload.Domain, payload.Menu, "")
-	if err := h.svc.RegisterModuleMenus(context.Background(), payload.Domain, defs); err != nil {
-		log.Printf("Failed to register menus for domain %s: %v", payload.Domain, err)
+	problems, err := h.svc.RegisterModuleMenus(context.Background(), payload.Domain, payload.Version, defs)
+	if err != nil {
+		log.Printf("Failed to register menus for domain %s at version %d: %v", payload.Domain, payload.Version, err)
+	}
+	if m.Reply == "" {
+		return
+	}
+
+	reply := events.SystemMenusRegisteredReply{
+		Domain:   payload.Domain,
+		Version:  payload.Version,
+		Applied:  err == nil,
+		Problems: problems,
+	}
+	if err != nil {
+		reply.Error = err.Error()
 	}
+	respond(m, reply)
 }
 
 func flattenMenuDefinitions(domainName string, nodes []menu.MenuDefinition, parentID string) []domain.MenuDefinition {
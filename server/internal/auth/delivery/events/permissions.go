@@ -0,0 +1,178 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/expiry"
+)
+
+// permissionQueue load-balances permission requests across auth instances.
+const permissionQueue = "auth.permissions"
+
+// permissionInvalidations are the subjects after which cached permissions may be stale.
+var permissionInvalidations = []string{"auth.role.>", "auth.group.>", events.AuthUserDeleted, events.SystemPermissionsRegister}
+
+type permissionResponder struct {
+	svc   domain.Service
+	cache *permissionCache
+}
+
+// subscriber is the part of *nats.Conn the responders use.
+type subscriber interface {
+	Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error)
+	QueueSubscribe(subject, queue string, cb nats.MsgHandler) (*nats.Subscription, error)
+}
+
+func registerPermissionResponders(nc subscriber, svc domain.Service, cacheTTL time.Duration) {
+	p := &permissionResponder{svc: svc, cache: newPermissionCache(cacheTTL)}
+
+	if _, err := nc.QueueSubscribe(events.AuthPermissionsCheck, permissionQueue, p.handleCheck); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", events.AuthPermissionsCheck, err)
+	}
+	if _, err := nc.QueueSubscribe(events.AuthPermissionsList, permissionQueue, p.handleList); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", events.AuthPermissionsList, err)
+	}
+	if _, err := nc.QueueSubscribe(events.AuthRolesList, permissionQueue, p.handleRoles); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", events.AuthRolesList, err)
+	}
+
+	// Every instance keeps its own cache, so invalidations are not queue-grouped.
+	for _, subject := range permissionInvalidations {
+		if _, err := nc.Subscribe(subject, func(*nats.Msg) { p.cache.purge() }); err != nil {
+			log.Printf("Failed to subscribe to %s: %v", subject, err)
+		}
+	}
+}
+
+func (p *permissionResponder) handleCheck(m *nats.Msg) { respond(m, p.check(m.Data)) }
+func (p *permissionResponder) handleList(m *nats.Msg)  { respond(m, p.list(m.Data)) }
+func (p *permissionResponder) handleRoles(m *nats.Msg) { respond(m, p.roles(m.Data)) }
+
+func (p *permissionResponder) check(data []byte) events.AuthPermissionCheckReply {
+	var req events.AuthPermissionCheckRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return events.AuthPermissionCheckReply{Error: "invalid request"}
+	}
+
+	perms, err := p.permissions(req.UserID, req.IP)
+	if err != nil {
+		return events.AuthPermissionCheckReply{Error: err.Error()}
+	}
+	return events.AuthPermissionCheckReply{Allowed: slices.Contains(perms, req.Permission)}
+}
+
+func (p *permissionResponder) list(data []byte) events.AuthPermissionListReply {
+	var req events.AuthPermissionListRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return events.AuthPermissionListReply{Error: "invalid request"}
+	}
+
+	perms, err := p.permissions(req.UserID, req.IP)
+	if err != nil {
+		return events.AuthPermissionListReply{Error: err.Error()}
+	}
+	if perms == nil {
+		perms = []string{}
+	}
+	return events.AuthPermissionListReply{Permissions: perms}
+}
+
+func (p *permissionResponder) roles(data []byte) events.AuthRoleListReply {
+	var req events.AuthRoleListRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return events.AuthRoleListReply{Error: "invalid request"}
+	}
+
+	roles, err := p.cached("roles|"+req.UserID.String()+"|"+req.IP, req.IP, func(ctx context.Context) ([]string, error) {
+		return p.svc.GetUserRoleNames(ctx, req.UserID)
+	})
+	if err != nil {
+		return events.AuthRoleListReply{Error: err.Error()}
+	}
+	if roles == nil {
+		roles = []string{}
+	}
+	return events.AuthRoleListReply{Roles: roles}
+}
+
+func (p *permissionResponder) permissions(userID uuid.UUID, ip string) ([]string, error) {
+	return p.cached(userID.String()+"|"+ip, ip, func(ctx context.Context) ([]string, error) {
+		return p.svc.GetUserPermissions(ctx, userID)
+	})
+}
+
+// cached answers from the cache under key, or calls load with the address ip in its context.
+func (p *permissionResponder) cached(key, ip string, load func(ctx context.Context) ([]string, error)) ([]string, error) {
+	if values, ok := p.cache.get(key); ok {
+		return values, nil
+	}
+
+	ctx := context.Background()
+	// An unparsable address is treated as unknown, which only ever removes roles and permissions.
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		ctx = domain.WithClientIP(ctx, addr.Unmap())
+	}
+	values, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.set(key, values)
+	return values, nil
+}
+
+func respond(m *nats.Msg, reply any) {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("Failed to marshal reply for %s: %v", m.Subject, err)
+		return
+	}
+	if err := m.Respond(data); err != nil {
+		log.Printf("Failed to reply on %s: %v", m.Subject, err)
+	}
+}
+
+// permissionCache holds effective permissions and role names per user and address for a short
+// time. It is purged whenever roles or groups change; the TTL bounds staleness for anything else,
+// such as a time-bound grant starting. A non-positive TTL disables it.
+type permissionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries expiry.Map[string, []string]
+}
+
+func newPermissionCache(ttl time.Duration) *permissionCache {
+	return &permissionCache{ttl: ttl}
+}
+
+func (c *permissionCache) get(key string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Get(key, time.Now())
+}
+
+func (c *permissionCache) set(key string, perms []string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.entries.Set(key, perms, now.Add(c.ttl), now)
+}
+
+func (c *permissionCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Clear()
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// permissionService answers from fixed permissions and roles, counting lookups and remembering the
+// address each one was made from.
+type permissionService struct {
+	domain.Service
+	perms   []string
+	roles   []string
+	err     error
+	lookups int
+	ip      netip.Addr
+}
+
+func (s *permissionService) GetUserPermissions(ctx context.Context, _ uuid.UUID) ([]string, error) {
+	s.lookups++
+	s.ip = domain.ClientIPFromContext(ctx)
+	return s.perms, s.err
+}
+
+func (s *permissionService) GetUserRoleNames(ctx context.Context, _ uuid.UUID) ([]string, error) {
+	s.lookups++
+	s.ip = domain.ClientIPFromContext(ctx)
+	return s.roles, s.err
+}
+
+// subscriptions records the handlers registered per subject instead of talking to NATS.
+type subscriptions struct {
+	handlers map[string]nats.MsgHandler
+	queues   map[string]string
+}
+
+func (s *subscriptions) Subscribe(subject string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	s.handlers[subject] = cb
+	return nil, nil
+}
+
+func (s *subscriptions) QueueSubscribe(subject, queue string, cb nats.MsgHandler) (*nats.Subscription, error) {
+	s.handlers[subject] = cb
+	s.queues[subject] = queue
+	return nil, nil
+}
+
+func encode(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPermissionResponderAnswersFromTheService(t *testing.T) {
+	svc := &permissionService{perms: []string{"cms.page.read"}, roles: []string{"Editor"}}
+	p := &permissionResponder{svc: svc, cache: newPermissionCache(time.Minute)}
+	userID := uuid.New()
+
+	check := p.check(encode(t, events.AuthPermissionCheckRequest{UserID: userID, Permission: "cms.page.read", IP: "::ffff:203.0.113.7"}))
+	if !check.Allowed || check.Error != "" {
+		t.Fatalf("expected the permission to be granted, got %+v", check)
+	}
+	if want := netip.MustParseAddr("203.0.113.7"); svc.ip != want {
+		t.Fatalf("expected the lookup to see %s, got %s", want, svc.ip)
+	}
+	if denied := p.check(encode(t, events.AuthPermissionCheckRequest{UserID: userID, Permission: "cms.page.write", IP: "::ffff:203.0.113.7"})); denied.Allowed {
+		t.Fatal("expected a permission the user lacks to be denied")
+	}
+
+	list := p.list(encode(t, events.AuthPermissionListRequest{UserID: userID, IP: "::ffff:203.0.113.7"}))
+	if !slices.Equal(list.Permissions, svc.perms) {
+		t.Fatalf("unexpected permissions %+v", list)
+	}
+	if svc.lookups != 1 {
+		t.Fatalf("expected answers for the same user and address to be cached, got %d lookups", svc.lookups)
+	}
+
+	roles := p.roles(encode(t, events.AuthRoleListRequest{UserID: userID, IP: "not-an-ip"}))
+	if !slices.Equal(roles.Roles, svc.roles) {
+		t.Fatalf("unexpected roles %+v", roles)
+	}
+	if svc.ip.IsValid() {
+		t.Fatalf("expected an unparsable address to be treated as unknown, got %s", svc.ip)
+	}
+}
+
+func TestPermissionResponderRepliesWithErrors(t *testing.T) {
+	svc := &permissionService{err: errors.New("database unavailable")}
+	p := &permissionResponder{svc: svc, cache: newPermissionCache(time.Minute)}
+	req := encode(t, events.AuthPermissionListRequest{UserID: uuid.New()})
+
+	if reply := p.check([]byte("{")); reply.Error != "invalid request" || reply.Allowed {
+		t.Fatalf("expected a malformed check to be refused, got %+v", reply)
+	}
+	if reply := p.list([]byte("{")); reply.Error != "invalid request" {
+		t.Fatalf("expected a malformed list to be refused, got %+v", reply)
+	}
+	if reply := p.roles([]byte("{")); reply.Error != "invalid request" {
+		t.Fatalf("expected a malformed role list to be refused, got %+v", reply)
+	}
+
+	if reply := p.check(req); reply.Error != "database unavailable" || reply.Allowed {
+		t.Fatalf("expected the lookup error to be passed on, got %+v", reply)
+	}
+	if reply := p.list(req); reply.Error != "database unavailable" || reply.Permissions != nil {
+		t.Fatalf("expected the lookup error to be passed on, got %+v", reply)
+	}
+	if reply := p.roles(req); reply.Error != "database unavailable" || reply.Roles != nil {
+		t.Fatalf("expected the lookup error to be passed on, got %+v", reply)
+	}
+
+	// Failures are not cached: the next request asks again.
+	svc.err = nil
+	if reply := p.list(req); reply.Error != "" || reply.Permissions == nil {
+		t.Fatalf("expected an empty list once the service recovers, got %+v", reply)
+	}
+}
+
+func TestPermissionRespondersPurgeOnRoleAndGroupChanges(t *testing.T) {
+	subs := &subscriptions{handlers: map[string]nats.MsgHandler{}, queues: map[string]string{}}
+	svc := &permissionService{perms: []string{"cms.page.read"}}
+	registerPermissionResponders(subs, svc, time.Minute)
+
+	for _, subject := range []string{events.AuthPermissionsCheck, events.AuthPermissionsList, events.AuthRolesList} {
+		if subs.queues[subject] != permissionQueue {
+			t.Errorf("expected %s to be answered by the %q queue group, got %q", subject, permissionQueue, subs.queues[subject])
+		}
+	}
+
+	// Replies cannot be sent without a connection; count the lookups behind them instead.
+	request := &nats.Msg{Subject: events.AuthPermissionsList, Data: encode(t, events.AuthPermissionListRequest{UserID: uuid.New()})}
+	subs.handlers[events.AuthPermissionsList](request)
+	for _, subject := range []string{"auth.role.>", "auth.group.>"} {
+		purge, ok := subs.handlers[subject]
+		if !ok {
+			t.Fatalf("expected a subscription to %s", subject)
+		}
+		if _, queued := subs.queues[subject]; queued {
+			t.Fatalf("expected %s to reach every instance, not a queue group", subject)
+		}
+
+		lookups := svc.lookups
+		subs.handlers[events.AuthPermissionsList](request)
+		if svc.lookups != lookups {
+			t.Fatalf("expected the answer to be cached before %s", subject)
+		}
+		purge(&nats.Msg{Subject: subject})
+		subs.handlers[events.AuthPermissionsList](request)
+		if svc.lookups != lookups+1 {
+			t.Fatalf("expected %s to purge the cache", subject)
+		}
+	}
+}
+
+func TestPermissionCacheExpiresAndPurges(t *testing.T) {
+	c := newPermissionCache(time.Minute)
+	c.set("u|", []string{"cms.page.read"})
+	if perms, ok := c.get("u|"); !ok || len(perms) != 1 {
+		t.Fatalf("expected a cached entry, got %v %v", perms, ok)
+	}
+
+	c.purge()
+	if _, ok := c.get("u|"); ok {
+		t.Fatal("expected purge to drop the entry")
+	}
+
+	c.entries.Set("old|", []string{"x"}, time.Now().Add(-time.Second), time.Now().Add(-time.Minute))
+	if _, ok := c.get("old|"); ok {
+		t.Fatal("expected an expired entry to be ignored")
+	}
+
+	disabled := newPermissionCache(0)
+	disabled.set("u|", []string{"x"})
+	if _, ok := disabled.get("u|"); ok {
+		t.Fatal("expected a zero TTL to disable caching")
+	}
+}
@@ -1,5 +1,12 @@
 package http
 
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -8,6 +15,7 @@ type loginRequest struct {
 type loginResponse struct {
 	AccessToken      string `json:"access_token"`
 	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
 	AccessExpiresAt  string `json:"access_expires_at"`
 	RefreshExpiresAt string `json:"refresh_expires_at"`
 }
@@ -18,12 +26,117 @@ type registerRequest struct {
 	FullName string `json:"full_name"`
 }
 
+type roleAllowedCIDRsRequest struct {
+	CIDRs []string `json:"allowed_cidrs"`
+}
+
 type refreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
 type meResponse struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	FullName string `json:"full_name"`
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FullName  string `json:"full_name"`
+	Locale    string `json:"locale"`
+	Timezone  string `json:"timezone"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// updateProfileRequest is a partial update: omitted fields are left unchanged.
+type updateProfileRequest struct {
+	FullName *string `json:"full_name"`
+	Locale   *string `json:"locale"`
+	Timezone *string `json:"timezone"`
+}
+
+type emailChangeRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// reauthenticateRequest carries exactly one of Password or TOTPCode.
+type reauthenticateRequest struct {
+	Password string `json:"password"`
+	TOTPCode string `json:"totp_code"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type createServiceClientRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type createServiceClientResponse struct {
+	Client       *domain.ServiceClient `json:"client"`
+	ClientSecret string                `json:"client_secret"`
+}
+
+// tokenResponse follows RFC 6749 section 5.1 rather than the response envelope,
+// so off-the-shelf OAuth2 clients can consume it.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// oauthErrorResponse follows RFC 6749 section 5.2.
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type magicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type verifyMagicLinkRequest struct {
+	Token string `json:"token"`
+}
+
+type magicLinkSettingsRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type finishPasskeyRegistrationRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+type finishPasskeyAssertionRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+type elevationRequestRequest struct {
+	RoleID          int    `json:"role_id"`
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+type elevationDecisionRequest struct {
+	Note string `json:"note"`
+}
+
+type secondFactorResponse struct {
+	SecondFactorRequired bool                    `json:"second_factor_required"`
+	Method               string                  `json:"method"`
+	Challenge            domain.PasskeyChallenge `json:"challenge"`
 }
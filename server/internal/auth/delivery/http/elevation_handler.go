@@ -0,0 +1,102 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+func (h *AuthHandler) RequestElevation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req elevationRequestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	elevation, err := h.svc.RequestElevation(r.Context(), userID, req.RoleID, req.Reason, req.DurationMinutes)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusCreated, elevation)
+}
+
+func (h *AuthHandler) GetMyElevationRequests(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := h.svc.GetMyElevationRequests(r.Context(), userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, requests)
+}
+
+func (h *AuthHandler) GetElevationRequests(w http.ResponseWriter, r *http.Request) {
+	requests, err := h.svc.GetElevationRequests(r.Context(), domain.ElevationStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, requests)
+}
+
+func (h *AuthHandler) ApproveElevation(w http.ResponseWriter, r *http.Request) {
+	h.decideElevation(w, r, h.svc.ApproveElevation)
+}
+
+func (h *AuthHandler) DenyElevation(w http.ResponseWriter, r *http.Request) {
+	h.decideElevation(w, r, h.svc.DenyElevation)
+}
+
+func (h *AuthHandler) decideElevation(w http.ResponseWriter, r *http.Request,
+	decide func(ctx context.Context, requestID, approverID uuid.UUID, note string) (*domain.ElevationRequest, error)) {
+	approverID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	requestID, err := uuid.Parse(chi.URLParam(r, "requestID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid Request ID")
+		return
+	}
+
+	// The note is optional, so an empty body is accepted.
+	var req elevationDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	elevation, err := decide(r.Context(), requestID, approverID, req.Note)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, elevation)
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+type groupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type groupMemberRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (h *AuthHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.svc.GetGroups(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, groups)
+}
+
+func (h *AuthHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	var req groupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	group, err := h.svc.CreateGroup(r.Context(), req.Name, req.Description)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusCreated, group)
+}
+
+func (h *AuthHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	group, err := h.svc.GetGroup(r.Context(), groupID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, group)
+}
+
+func (h *AuthHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req groupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	group, err := h.svc.UpdateGroup(r.Context(), groupID, req.Name, req.Description)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, group)
+}
+
+func (h *AuthHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.DeleteGroup(r.Context(), groupID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AuthHandler) GetGroupMembers(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.svc.GetGroupMembers(r.Context(), groupID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, members)
+}
+
+func (h *AuthHandler) AddGroupMember(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req groupMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.AddGroupMember(r.Context(), groupID, req.UserID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "added"})
+}
+
+func (h *AuthHandler) RemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid User ID")
+		return
+	}
+
+	if err := h.svc.RemoveGroupMember(r.Context(), groupID, userID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "removed"})
+}
+
+func (h *AuthHandler) AssignRoleToGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.AssignRoleToGroup(r.Context(), groupID, req.RoleID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "assigned"})
+}
+
+func (h *AuthHandler) RevokeRoleFromGroup(w http.ResponseWriter, r *http.Request) {
+	groupID, ok := groupIDParam(w, r)
+	if !ok {
+		return
+	}
+	roleID, err := strconv.Atoi(chi.URLParam(r, "roleID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_ID", "Invalid Role ID")
+		return
+	}
+
+	if err := h.svc.RevokeRoleFromGroup(r.Context(), groupID, roleID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+func groupIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	groupID, err := uuid.Parse(chi.URLParam(r, "groupID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid Group ID")
+		return uuid.Nil, false
+	}
+	return groupID, true
+}
@@ -2,40 +2,141 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/dpop"
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/pow"
 )
 
 type AuthHandler struct {
 	svc domain.Service
 }
 
-func RegisterHTTPHandlers(r *chi.Mux, svc domain.Service) {
+// RegisterHTTPHandlers mounts the public routes. Routes that issue user tokens accept an optional
+// DPoP proof, checked by verifier, to bind the tokens to the client's key. Forms that bots target
+// require a proof-of-work solution for a challenge issued by guard.
+func RegisterHTTPHandlers(r *chi.Mux, svc domain.Service, verifier *dpop.Verifier, guard *pow.Guard) {
 	h := &AuthHandler{svc: svc}
+	proof := DPoPProof(verifier)
 
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/login", h.Login)
-		r.Post("/refresh", h.Refresh)
-		r.Post("/register", h.Register)
+		r.Use(RequestActor)
+
+		r.Get("/pow/challenge", guard.ChallengeHandler)
+
+		r.With(guard.Require, proof).Post("/login", h.Login)
+		r.With(proof).Post("/refresh", h.Refresh)
+		r.With(guard.Require).Post("/register", h.Register)
+		// Both verify a client secret with bcrypt.
+		r.With(httputil.RateLimit(60, time.Minute)).Post("/token", h.Token)
+		r.With(httputil.RateLimit(60, time.Minute)).Post("/introspect", h.Introspect)
+
+		r.With(httputil.RateLimit(5, 15*time.Minute), guard.Require).Post("/magic-link", h.RequestMagicLink)
+		r.With(proof).Post("/magic-link/verify", h.VerifyMagicLink)
+
+		r.Post("/passkeys/login/begin", h.BeginPasskeyLogin)
+		r.With(proof).Post("/passkeys/login/finish", h.FinishPasskeyLogin)
+		r.With(proof).Post("/passkeys/second-factor/finish", h.FinishPasskeySecondFactor)
+
+		r.Post("/email/confirm", h.ConfirmEmailChange)
+		r.Post("/invitations/accept", h.AcceptInvitation)
 	})
 }
 
-func RegisterProtectedHTTPHandlers(r chi.Router, svc domain.Service) {
+// RegisterProtectedHTTPHandlers mounts the authenticated routes. Sensitive operations additionally
+// require the user to have authenticated within reauthWindow, and routes of features shipped dark
+// are mounted behind requireFlag.
+func RegisterProtectedHTTPHandlers(r chi.Router, svc domain.Service, reauthWindow time.Duration, requireFlag func(key string) func(http.Handler) http.Handler) {
 	h := &AuthHandler{svc: svc}
-
-	r.Get("/me", h.GetMe)
+	recentAuth := RequireRecentAuth(reauthWindow)
+
+	// Service clients only reach the routes that check their scopes with RequirePermission.
+	r.Group(func(r chi.Router) {
+		r.Use(RequireUser)
+
+		// Limited per address and per user; the user limit holds however many addresses guesses come from.
+		r.With(httputil.RateLimit(20, 15*time.Minute), httputil.RateLimitBy(10, 15*time.Minute, principalKey)).Post("/auth/reauthenticate", h.Reauthenticate)
+
+		r.Get("/me", h.GetMe)
+		r.Get("/me/permissions", h.GetMyPermissions)
+		r.Patch("/me", h.UpdateProfile)
+		r.With(recentAuth).Delete("/me", h.DeleteAccount)
+		r.Put("/me/avatar", h.UpdateAvatar)
+		r.Delete("/me/avatar", h.DeleteAvatar)
+		r.With(recentAuth).Post("/me/email", h.RequestEmailChange)
+		r.With(recentAuth).Put("/me/password", h.ChangePassword)
+		r.With(recentAuth).Post("/me/totp", h.BeginTOTPEnrollment)
+		r.Post("/me/totp/confirm", h.ConfirmTOTPEnrollment)
+		r.With(recentAuth).Delete("/me/totp", h.DisableTOTP)
+		// Opting in adds a way to sign in with nothing but the mailbox.
+		r.With(recentAuth).Put("/me/magic-link", h.SetMagicLinkEnabled)
+		r.Get("/me/passkeys", h.GetPasskeys)
+		r.With(recentAuth).Post("/me/passkeys/register/begin", h.BeginPasskeyRegistration)
+		r.Post("/me/passkeys/register/finish", h.FinishPasskeyRegistration)
+		r.With(recentAuth).Delete("/me/passkeys/{passkeyID}", h.DeletePasskey)
+		r.Get("/me/elevation-requests", h.GetMyElevationRequests)
+		r.Post("/me/elevation-requests", h.RequestElevation)
+	})
 
 	r.Route("/backoffice", func(r chi.Router) {
-		r.Get("/me/menu", h.GetMyMenu)
-		r.Get("/roles", h.GetRoles)
-		r.Post("/roles", h.CreateRole)
-		r.Post("/roles/{roleID}/permissions", h.AddPermissionToRole)
-		r.Post("/users/{userID}/roles", h.AssignRoleToUser)
+		r.Group(func(r chi.Router) {
+			r.Use(RequireUser)
+			r.Get("/me/menu", h.GetMyMenu)
+			r.With(requireFlag(domain.FlagMenuPreferences)).Put("/me/menu/pins", h.SetMenuPins)
+			r.With(requireFlag(domain.FlagMenuPreferences)).Post("/me/menu/recent", h.RecordMenuVisit)
+		})
+
+		r.Route("/menus", func(r chi.Router) {
+			r.With(RequirePermission(svc, domain.PermissionMenuRead)).Get("/", h.GetMenuItems)
+			r.With(RequirePermission(svc, domain.PermissionMenuRead)).Get("/diagnostics", h.GetMenuDiagnostics)
+			r.With(RequirePermission(svc, domain.PermissionMenuRead)).Get("/translations", h.GetMenuTranslationReport)
+			// Every signed-in client builds its route guards from the manifest.
+			r.With(RequireUser).Get("/routes", h.GetRouteManifest)
+			r.With(RequirePermission(svc, domain.PermissionMenuWrite)).Post("/reset", h.ResetMenuOverrides)
+			r.With(RequirePermission(svc, domain.PermissionMenuRead)).Get("/{menuID}", h.GetMenuItem)
+			r.With(RequirePermission(svc, domain.PermissionMenuWrite)).Put("/{menuID}/override", h.SetMenuOverride)
+			r.With(RequirePermission(svc, domain.PermissionMenuWrite)).Delete("/{menuID}/override", h.ResetMenuOverride)
+		})
+
+		r.With(RequirePermission(svc, domain.PermissionRoleRead)).Get("/roles", h.GetRoles)
+		r.With(RequirePermission(svc, domain.PermissionRoleWrite)).Post("/roles", h.CreateRole)
+		r.With(RequirePermission(svc, domain.PermissionRoleWrite), recentAuth).Post("/roles/{roleID}/permissions", h.AddPermissionToRole)
+		r.With(RequirePermission(svc, domain.PermissionRoleWrite), recentAuth).Put("/roles/{roleID}/allowed-cidrs", h.SetRoleAllowedCIDRs)
+		r.With(RequirePermission(svc, domain.PermissionUserRead)).Get("/users/export", h.ExportUsers)
+		r.With(RequirePermission(svc, domain.PermissionUserWrite)).Post("/users/import/validate", h.ValidateUserImport)
+		r.With(RequirePermission(svc, domain.PermissionUserWrite), recentAuth).Post("/users/import", h.StartUserImport)
+		r.With(RequirePermission(svc, domain.PermissionUserWrite)).Get("/users/import/{jobID}", h.GetUserImportJob)
+		r.With(RequirePermission(svc, domain.PermissionUserRead)).Get("/users/{userID}/roles", h.GetUserRoles)
+		r.With(RequirePermission(svc, domain.PermissionUserWrite), recentAuth).Post("/users/{userID}/roles", h.AssignRoleToUser)
+		r.With(RequirePermission(svc, domain.PermissionUserWrite), recentAuth).Delete("/users/{userID}/roles/{roleID}", h.RevokeRoleFromUser)
+
+		r.Route("/groups", func(r chi.Router) {
+			r.With(RequirePermission(svc, domain.PermissionGroupRead)).Get("/", h.GetGroups)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite)).Post("/", h.CreateGroup)
+			r.With(RequirePermission(svc, domain.PermissionGroupRead)).Get("/{groupID}", h.GetGroup)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite)).Put("/{groupID}", h.UpdateGroup)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite)).Delete("/{groupID}", h.DeleteGroup)
+			r.With(RequirePermission(svc, domain.PermissionGroupRead)).Get("/{groupID}/members", h.GetGroupMembers)
+			// Membership grants the group's roles, so it needs the same step-up as a direct grant.
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite), recentAuth).Post("/{groupID}/members", h.AddGroupMember)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite), recentAuth).Delete("/{groupID}/members/{userID}", h.RemoveGroupMember)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite), recentAuth).Post("/{groupID}/roles", h.AssignRoleToGroup)
+			r.With(RequirePermission(svc, domain.PermissionGroupWrite), recentAuth).Delete("/{groupID}/roles/{roleID}", h.RevokeRoleFromGroup)
+		})
+
+		r.With(RequirePermission(svc, domain.PermissionElevationApprove)).Get("/elevation-requests", h.GetElevationRequests)
+		r.With(RequirePermission(svc, domain.PermissionElevationApprove), recentAuth).Post("/elevation-requests/{requestID}/approve", h.ApproveElevation)
+		r.With(RequirePermission(svc, domain.PermissionElevationApprove)).Post("/elevation-requests/{requestID}/deny", h.DenyElevation)
+
+		r.With(RequirePermission(svc, domain.PermissionClientRead)).Get("/service-clients", h.GetServiceClients)
+		r.With(RequirePermission(svc, domain.PermissionClientWrite), recentAuth).Post("/service-clients", h.CreateServiceClient)
+		r.With(RequirePermission(svc, domain.PermissionClientWrite), recentAuth).Delete("/service-clients/{clientID}", h.RevokeServiceClient)
 	})
 }
 
@@ -47,6 +148,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	tokens, err := h.svc.Login(r.Context(), req.Email, req.Password)
+	if renderSecondFactor(w, err) {
+		return
+	}
 	if err != nil {
 		status, code := httputil.MapError(err)
 		jsonutil.RenderError(w, status, code, err.Error())
@@ -57,11 +161,27 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	jsonutil.RenderJSON(w, http.StatusOK, loginResponse{
 		AccessToken:      tokens.AccessToken,
 		RefreshToken:     tokens.RefreshToken,
+		TokenType:        tokens.TokenType,
 		AccessExpiresAt:  tokens.AccessExpiresAt.Format(time.RFC3339),
 		RefreshExpiresAt: tokens.RefreshExpiresAt.Format(time.RFC3339),
 	})
 }
 
+// renderSecondFactor answers with the passkey challenge when err reports that the login needs a
+// second factor, which the client completes at /auth/passkeys/second-factor/finish.
+func renderSecondFactor(w http.ResponseWriter, err error) bool {
+	var secondFactor *domain.SecondFactorRequiredError
+	if !errors.As(err, &secondFactor) {
+		return false
+	}
+	jsonutil.RenderJSON(w, http.StatusAccepted, secondFactorResponse{
+		SecondFactorRequired: true,
+		Method:               "passkey",
+		Challenge:            secondFactor.Challenge,
+	})
+	return true
+}
+
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req refreshRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -80,6 +200,7 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	jsonutil.RenderJSON(w, http.StatusOK, loginResponse{
 		AccessToken:      tokens.AccessToken,
 		RefreshToken:     tokens.RefreshToken,
+		TokenType:        tokens.TokenType,
 		AccessExpiresAt:  tokens.AccessExpiresAt.Format(time.RFC3339),
 		RefreshExpiresAt: tokens.RefreshExpiresAt.Format(time.RFC3339),
 	})
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+const (
+	magicLinkDeviceCookie = "magic_link_device"
+	magicLinkDeviceTTL    = 15 * time.Minute
+)
+
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req magicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	// Reusing the browser's device token keeps links it requested earlier valid.
+	var currentDeviceToken string
+	if cookie, err := r.Cookie(magicLinkDeviceCookie); err == nil {
+		currentDeviceToken = cookie.Value
+	}
+
+	deviceToken, err := h.svc.RequestMagicLink(r.Context(), req.Email, remoteIP(r), currentDeviceToken)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	// Binds the link to this browser: verification only succeeds when the same cookie comes back.
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkDeviceCookie,
+		Value:    deviceToken,
+		Expires:  time.Now().Add(magicLinkDeviceTTL),
+		Path:     "/auth/magic-link",
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteLaxMode,
+	})
+	jsonutil.RenderJSON(w, http.StatusAccepted, map[string]string{"message": "If the account allows it, a sign-in link has been sent"})
+}
+
+func (h *AuthHandler) VerifyMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req verifyMagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	cookie, err := r.Cookie(magicLinkDeviceCookie)
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Sign-in link must be opened in the browser that requested it")
+		return
+	}
+
+	tokens, err := h.svc.LoginWithMagicLink(r.Context(), req.Token, cookie.Value)
+	if renderSecondFactor(w, err) {
+		return
+	}
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkDeviceCookie,
+		Value:    "",
+		Path:     "/auth/magic-link",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	h.setTokenCookies(w, tokens)
+	jsonutil.RenderJSON(w, http.StatusOK, loginResponse{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        tokens.TokenType,
+		AccessExpiresAt:  tokens.AccessExpiresAt.Format(time.RFC3339),
+		RefreshExpiresAt: tokens.RefreshExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func (h *AuthHandler) SetMagicLinkEnabled(w http.ResponseWriter, r *http.Request) {
+	claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+	if !ok {
+		jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context")
+		return
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Invalid user ID in token")
+		return
+	}
+
+	var req magicLinkSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.SetMagicLinkEnabled(r.Context(), userID, req.Enabled); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]bool{"enabled": req.Enabled})
+}
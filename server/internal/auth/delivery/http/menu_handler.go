@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+type menuOverrideRequest struct {
+	Hidden *bool   `json:"hidden"`
+	Label  *string `json:"label"`
+	Icon   *string `json:"icon"`
+	Order  *int    `json:"order"`
+}
+
+type menuResetResponse struct {
+	Reset int `json:"reset"`
+}
+
+func (h *AuthHandler) GetMenuItems(w http.ResponseWriter, r *http.Request) {
+	items, err := h.svc.GetMenuItems(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, items)
+}
+
+func (h *AuthHandler) GetRouteManifest(w http.ResponseWriter, r *http.Request) {
+	manifest, err := h.svc.GetRouteManifest(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, manifest)
+}
+
+func (h *AuthHandler) GetMenuItem(w http.ResponseWriter, r *http.Request) {
+	item, err := h.svc.GetMenuItem(r.Context(), chi.URLParam(r, "menuID"))
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, item)
+}
+
+func (h *AuthHandler) SetMenuOverride(w http.ResponseWriter, r *http.Request) {
+	var req menuOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	item, err := h.svc.SetMenuOverride(r.Context(), chi.URLParam(r, "menuID"), domain.MenuOverride{
+		Hidden: req.Hidden,
+		Label:  req.Label,
+		Icon:   req.Icon,
+		Order:  req.Order,
+	})
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, item)
+}
+
+func (h *AuthHandler) ResetMenuOverride(w http.ResponseWriter, r *http.Request) {
+	item, err := h.svc.ResetMenuOverride(r.Context(), chi.URLParam(r, "menuID"))
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, item)
+}
+
+func (h *AuthHandler) ResetMenuOverrides(w http.ResponseWriter, r *http.Request) {
+	n, err := h.svc.ResetMenuOverrides(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, menuResetResponse{Reset: n})
+}
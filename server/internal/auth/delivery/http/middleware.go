@@ -2,15 +2,82 @@ package http
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/dpop"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
 )
 
-func AuthMiddleware(jwtSecret string) func(next http.Handler) http.Handler {
+// RequestActor attributes events published by unauthenticated requests, such as login attempts,
+// to the caller's address and request ID.
+func RequestActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := events.WithActor(r.Context(), events.Actor{
+			IP:        remoteIP(r),
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// remoteIP returns the caller's address without the port, so records of the same client match.
+// Addresses that cannot be parsed are returned as they are.
+func remoteIP(r *http.Request) string {
+	if ip, ok := httputil.ParseRemoteIP(r.RemoteAddr); ok {
+		return ip.String()
+	}
+	return r.RemoteAddr
+}
+
+// principalKey keys rate limits on the authenticated user or client. It must be used after
+// AuthMiddleware.
+func principalKey(r *http.Request) string {
+	claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if claims.IsService() {
+		return "client:" + claims.ClientID
+	}
+	return "user:" + claims.UserID
+}
+
+// DPoPProof verifies the optional DPoP proof on token requests and records its key thumbprint in
+// the context, so the tokens issued by the handler are bound to that key. Requests without a
+// proof pass through and receive bearer tokens.
+func DPoPProof(verifier *dpop.Verifier) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !dpop.Present(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			proof, err := verifier.Verify(r, "")
+			if err != nil {
+				jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_DPOP_PROOF", err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(domain.WithDPoPThumbprint(r.Context(), proof.Thumbprint)))
+		})
+	}
+}
+
+// AuthMiddleware authenticates requests by their access token. Tokens bound to a DPoP key must be
+// sent with the "DPoP" scheme and a proof signed by that key; unbound tokens use "Bearer". Client
+// tokens are rejected once their service client is revoked.
+func AuthMiddleware(svc domain.Service, jwtSecret string, verifier *dpop.Verifier) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -20,7 +87,7 @@ func AuthMiddleware(jwtSecret string) func(next http.Handler) http.Handler {
 			}
 
 			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
+			if len(parts) != 2 || (parts[0] != domain.TokenSchemeBearer && parts[0] != domain.TokenSchemeDPoP) {
 				jsonutil.RenderError(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid authorization header")
 				return
 			}
@@ -42,8 +109,145 @@ func AuthMiddleware(jwtSecret string) func(next http.Handler) http.Handler {
 				return
 			}
 
+			// A bound token sent as a bearer token would skip the proof, and an unbound one sent
+			// with the DPoP scheme would suggest a protection it does not have; reject both.
+			jkt := claims.BoundKey()
+			if (jkt != "") != (parts[0] == domain.TokenSchemeDPoP) {
+				renderDPoPError(w, "Authorization scheme does not match the token binding")
+				return
+			}
+			if jkt != "" {
+				proof, err := verifier.Verify(r, tokenString)
+				if err != nil {
+					renderDPoPError(w, err.Error())
+					return
+				}
+				if proof.Thumbprint != jkt {
+					renderDPoPError(w, "DPoP proof was signed by a different key than the token is bound to")
+					return
+				}
+			}
+
+			if claims.IsService() {
+				if err := svc.CheckServiceClient(r.Context(), claims.ClientID); err != nil {
+					if errors.Is(err, httputil.ErrUnauthorized) {
+						jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Client has been revoked")
+						return
+					}
+					status, code := httputil.MapError(err)
+					jsonutil.RenderError(w, status, code, err.Error())
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), domain.UserClaimsKey, claims)
+			ctx = domain.WithDPoPThumbprint(ctx, jkt)
+			if ip, ok := httputil.ParseRemoteIP(r.RemoteAddr); ok {
+				ctx = domain.WithClientIP(ctx, ip)
+			}
+			ctx = events.WithActor(ctx, events.Actor{
+				UserID:    claims.UserID,
+				ClientID:  claims.ClientID,
+				IP:        remoteIP(r),
+				RequestID: middleware.GetReqID(r.Context()),
+			})
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+func renderDPoPError(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+	jsonutil.RenderError(w, http.StatusUnauthorized, "INVALID_DPOP_PROOF", message)
+}
+
+// RequireUser refuses service clients. Routes that do not check a permission with
+// RequirePermission are mounted behind it, so a client token only reaches routes its scopes were
+// checked for. It must be mounted after AuthMiddleware.
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+		if !ok {
+			jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context")
+			return
+		}
+		if claims.IsService() {
+			jsonutil.RenderError(w, http.StatusForbidden, "FORBIDDEN", "Service clients cannot use this route")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireRecentAuth rejects user requests whose token was issued more than window after the user
+// last authenticated interactively, answering 401 REAUTH_REQUIRED so the client can send them
+// through /auth/reauthenticate. Service clients have no interactive login, so they are only let
+// through when a RequirePermission mounted before it checked their scopes; otherwise they get 403.
+// It must be mounted after AuthMiddleware.
+func RequireRecentAuth(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+			if !ok {
+				jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context")
+				return
+			}
+
+			if claims.IsService() {
+				if !domain.ScopeCheckedFromContext(r.Context()) {
+					jsonutil.RenderError(w, http.StatusForbidden, "FORBIDDEN", "Service clients cannot use this route")
+					return
+				}
+			} else if !claims.AuthenticatedWithin(window, time.Now()) {
+				jsonutil.RenderError(w, http.StatusUnauthorized, "REAUTH_REQUIRED", "Recent authentication required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission rejects requests whose authenticated principal does not hold the given permission.
+// Users are checked against their roles, service clients against the scopes in their token.
+// It must be mounted after AuthMiddleware.
+func RequirePermission(svc domain.Service, permission string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+			if !ok {
+				jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context")
+				return
+			}
+
+			if claims.IsService() {
+				if !slices.Contains(claims.Scopes, permission) {
+					jsonutil.RenderError(w, http.StatusForbidden, "FORBIDDEN", "Missing scope "+permission)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(domain.WithScopeChecked(r.Context())))
+				return
+			}
+
+			userID, err := uuid.Parse(claims.UserID)
+			if err != nil {
+				jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid user ID in token")
+				return
+			}
+
+			perms, err := svc.GetUserPermissions(r.Context(), userID)
+			if err != nil {
+				status, code := httputil.MapError(err)
+				jsonutil.RenderError(w, status, code, err.Error())
+				return
+			}
+
+			if !slices.Contains(perms, permission) {
+				jsonutil.RenderError(w, http.StatusForbidden, "FORBIDDEN", "Missing permission "+permission)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
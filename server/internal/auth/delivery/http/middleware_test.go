@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+func serveWithClaims(handler http.Handler, claims *domain.UserClaims) int {
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), domain.UserClaimsKey, claims))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequireRecentAuthOnlyAdmitsScopeCheckedServiceClients(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	recentAuth := RequireRecentAuth(5 * time.Minute)
+	service := &domain.UserClaims{Principal: domain.PrincipalService, ClientID: "c1", Scopes: []string{domain.PermissionClientWrite}}
+
+	if code := serveWithClaims(recentAuth(ok), service); code != http.StatusForbidden {
+		t.Fatalf("expected a service client to be refused without a scope check, got %d", code)
+	}
+	if code := serveWithClaims(RequirePermission(nil, domain.PermissionClientWrite)(recentAuth(ok)), service); code != http.StatusNoContent {
+		t.Fatalf("expected a service client with the route's scope to pass, got %d", code)
+	}
+	if code := serveWithClaims(RequirePermission(nil, domain.PermissionUserWrite)(recentAuth(ok)), service); code != http.StatusForbidden {
+		t.Fatalf("expected a service client without the route's scope to be refused, got %d", code)
+	}
+
+	stale := &domain.UserClaims{UserID: "u1", AuthTime: jwt.NewNumericDate(time.Now().Add(-time.Hour))}
+	if code := serveWithClaims(recentAuth(ok), stale); code != http.StatusUnauthorized {
+		t.Fatalf("expected a stale user login to require re-authentication, got %d", code)
+	}
+	fresh := &domain.UserClaims{UserID: "u1", AuthTime: jwt.NewNumericDate(time.Now())}
+	if code := serveWithClaims(recentAuth(ok), fresh); code != http.StatusNoContent {
+		t.Fatalf("expected a recent user login to pass, got %d", code)
+	}
+}
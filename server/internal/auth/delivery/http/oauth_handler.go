@@ -0,0 +1,174 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+const grantTypeClientCredentials = "client_credentials"
+
+// Token implements the OAuth2 client_credentials grant (RFC 6749 section 4.4).
+func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request", "Failed to parse form body")
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != grantTypeClientCredentials {
+		renderOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+
+	clientID, clientSecret, ok := clientCredentials(r)
+	if !ok {
+		renderOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	tokens, err := h.svc.IssueClientToken(r.Context(), clientID, clientSecret, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		switch {
+		case errors.Is(err, httputil.ErrUnauthorized):
+			renderOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		case errors.Is(err, domain.ErrInvalidScope):
+			renderOAuthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		default:
+			renderOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		}
+		return
+	}
+
+	renderOAuthJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(tokens.ExpiresAt).Seconds()),
+		Scope:       strings.Join(tokens.Scopes, " "),
+	})
+}
+
+// Introspect implements RFC 7662 token introspection for registered service clients.
+func (h *AuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request", "Failed to parse form body")
+		return
+	}
+
+	clientID, clientSecret, ok := clientCredentials(r)
+	if !ok {
+		renderOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		renderOAuthError(w, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	result, err := h.svc.IntrospectToken(r.Context(), clientID, clientSecret, token)
+	if err != nil {
+		if errors.Is(err, httputil.ErrUnauthorized) {
+			renderOAuthError(w, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+			return
+		}
+		renderOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	renderOAuthJSON(w, http.StatusOK, result)
+}
+
+func (h *AuthHandler) CreateServiceClient(w http.ResponseWriter, r *http.Request) {
+	var req createServiceClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	client, secret, err := h.svc.CreateServiceClient(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusCreated, createServiceClientResponse{
+		Client:       client,
+		ClientSecret: secret,
+	})
+}
+
+func (h *AuthHandler) GetServiceClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.svc.GetServiceClients(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, clients)
+}
+
+func (h *AuthHandler) RevokeServiceClient(w http.ResponseWriter, r *http.Request) {
+	clientID, err := uuid.Parse(chi.URLParam(r, "clientID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid Client ID")
+		return
+	}
+
+	if err := h.svc.RevokeServiceClient(r.Context(), clientID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
+// clientCredentials reads the client credentials from HTTP Basic auth or, failing that, the form body.
+func clientCredentials(r *http.Request) (uuid.UUID, string, bool) {
+	rawID, secret, ok := r.BasicAuth()
+	if ok {
+		// RFC 6749 section 2.3.1: Basic credentials are form-urlencoded first.
+		var err error
+		if rawID, err = url.QueryUnescape(rawID); err != nil {
+			return uuid.Nil, "", false
+		}
+		if secret, err = url.QueryUnescape(secret); err != nil {
+			return uuid.Nil, "", false
+		}
+	} else {
+		rawID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	clientID, err := uuid.Parse(rawID)
+	if err != nil || secret == "" {
+		return uuid.Nil, "", false
+	}
+	return clientID, secret, true
+}
+
+func renderOAuthJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func renderOAuthError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="auth"`)
+	}
+	renderOAuthJSON(w, status, oauthErrorResponse{Error: code, ErrorDescription: description})
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+func (h *AuthHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	challenge, err := h.svc.BeginPasskeyRegistration(r.Context(), userID)
+	if err != nil {
+		renderPasskeyError(w, err)
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, challenge)
+}
+
+func (h *AuthHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req finishPasskeyRegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	passkey, err := h.svc.FinishPasskeyRegistration(r.Context(), userID, req.CeremonyID, req.Name, req.Credential)
+	if err != nil {
+		renderPasskeyError(w, err)
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusCreated, passkey)
+}
+
+func (h *AuthHandler) GetPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	passkeys, err := h.svc.GetPasskeys(r.Context(), userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, passkeys)
+}
+
+func (h *AuthHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	passkeyID, err := uuid.Parse(chi.URLParam(r, "passkeyID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid Passkey ID")
+		return
+	}
+
+	if err := h.svc.DeletePasskey(r.Context(), userID, passkeyID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func (h *AuthHandler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	challenge, err := h.svc.BeginPasskeyLogin(r.Context())
+	if err != nil {
+		renderPasskeyError(w, err)
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, challenge)
+}
+
+func (h *AuthHandler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	var req finishPasskeyAssertionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	tokens, err := h.svc.FinishPasskeyLogin(r.Context(), req.CeremonyID, req.Credential)
+	if err != nil {
+		renderPasskeyError(w, err)
+		return
+	}
+
+	h.renderTokens(w, tokens)
+}
+
+func (h *AuthHandler) FinishPasskeySecondFactor(w http.ResponseWriter, r *http.Request) {
+	var req finishPasskeyAssertionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	tokens, err := h.svc.FinishPasskeySecondFactor(r.Context(), req.CeremonyID, req.Credential)
+	if err != nil {
+		renderPasskeyError(w, err)
+		return
+	}
+
+	h.renderTokens(w, tokens)
+}
+
+func (h *AuthHandler) renderTokens(w http.ResponseWriter, tokens domain.AuthTokens) {
+	h.setTokenCookies(w, tokens)
+	jsonutil.RenderJSON(w, http.StatusOK, loginResponse{
+		AccessToken:      tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		TokenType:        tokens.TokenType,
+		AccessExpiresAt:  tokens.AccessExpiresAt.Format(time.RFC3339),
+		RefreshExpiresAt: tokens.RefreshExpiresAt.Format(time.RFC3339),
+	})
+}
+
+func renderPasskeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrPasskeysDisabled) {
+		jsonutil.RenderError(w, http.StatusServiceUnavailable, "PASSKEYS_DISABLED", err.Error())
+		return
+	}
+	status, code := httputil.MapError(err)
+	jsonutil.RenderError(w, status, code, err.Error())
+}
+
+// currentUserID extracts the authenticated user's ID, rendering an error response when it is missing.
+func currentUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
+	if !ok {
+		jsonutil.RenderError(w, http.StatusUnauthorized, "UNAUTHORIZED", "User not found in context")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Invalid user ID in token")
+		return uuid.Nil, false
+	}
+	return userID, true
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+// maxAvatarBytes limits the size of an uploaded avatar (2 MiB).
+const maxAvatarBytes = 2 << 20
+
+func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req updateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	user, err := h.svc.UpdateProfile(r.Context(), userID, domain.ProfileUpdate{
+		FullName: req.FullName,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+	})
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, newMeResponse(user))
+}
+
+func (h *AuthHandler) UpdateAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarBytes+4096)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			jsonutil.RenderError(w, http.StatusRequestEntityTooLarge, "AVATAR_TOO_LARGE", "Avatar must be at most 2 MiB")
+			return
+		}
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Expected a multipart form with an avatar file")
+		return
+	}
+	defer file.Close()
+
+	// The content type is sniffed rather than trusted from the client.
+	br := bufio.NewReaderSize(file, 512)
+	head, _ := br.Peek(512)
+	contentType := http.DetectContentType(head)
+
+	user, err := h.svc.UpdateAvatar(r.Context(), userID, contentType, br)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, newMeResponse(user))
+}
+
+func (h *AuthHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.svc.DeleteAvatar(r.Context(), userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, newMeResponse(user))
+}
+
+func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req emailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.RequestEmailChange(r.Context(), userID, req.Email); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusAccepted, map[string]string{"message": "Check the new address for a confirmation link"})
+}
+
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req confirmEmailChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	user, err := h.svc.ConfirmEmailChange(r.Context(), req.Token)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, newMeResponse(user))
+}
+
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req deleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.DeleteAccount(r.Context(), userID, req.Password); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: "refresh_token", Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func newMeResponse(u *domain.User) meResponse {
+	return meResponse{
+		ID:        u.ID.String(),
+		Email:     u.Email,
+		FullName:  u.FullName,
+		Locale:    u.Locale,
+		Timezone:  u.Timezone,
+		AvatarURL: u.AvatarURL,
+	}
+}
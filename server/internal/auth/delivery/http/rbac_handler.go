@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -17,7 +18,9 @@ type createRoleRequest struct {
 }
 
 type assignRoleRequest struct {
-	RoleID int `json:"role_id"`
+	RoleID     int        `json:"role_id"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
 }
 
 type addPermissionRequest struct {
@@ -66,7 +69,7 @@ func (h *AuthHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.svc.AssignRole(r.Context(), userID, req.RoleID)
+	err = h.svc.AssignRole(r.Context(), userID, req.RoleID, req.ValidFrom, req.ValidUntil)
 	if err != nil {
 		status, code := httputil.MapError(err)
 		jsonutil.RenderError(w, status, code, err.Error())
@@ -76,6 +79,44 @@ func (h *AuthHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
 	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "assigned"})
 }
 
+func (h *AuthHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid User ID")
+		return
+	}
+
+	grants, err := h.svc.GetRoleGrants(r.Context(), userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, grants)
+}
+
+func (h *AuthHandler) RevokeRoleFromUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid User ID")
+		return
+	}
+	roleID, err := strconv.Atoi(chi.URLParam(r, "roleID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_ID", "Invalid Role ID")
+		return
+	}
+
+	if err := h.svc.RevokeRole(r.Context(), userID, roleID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
+
 func (h *AuthHandler) AddPermissionToRole(w http.ResponseWriter, r *http.Request) {
 	roleIDStr := chi.URLParam(r, "roleID")
 	roleID, err := strconv.Atoi(roleIDStr)
@@ -100,6 +141,45 @@ func (h *AuthHandler) AddPermissionToRole(w http.ResponseWriter, r *http.Request
 	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "added"})
 }
 
+func (h *AuthHandler) SetRoleAllowedCIDRs(w http.ResponseWriter, r *http.Request) {
+	roleID, err := strconv.Atoi(chi.URLParam(r, "roleID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_ID", "Invalid Role ID")
+		return
+	}
+
+	var req roleAllowedCIDRsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	role, err := h.svc.SetRoleAllowedCIDRs(r.Context(), roleID, req.CIDRs)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, role)
+}
+
+func (h *AuthHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	set, err := h.svc.GetMyPermissions(r.Context(), userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, set)
+}
+
 func (h *AuthHandler) GetMyMenu(w http.ResponseWriter, r *http.Request) {
 	claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
 	if !ok {
@@ -113,16 +193,105 @@ func (h *AuthHandler) GetMyMenu(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	menu, err := h.svc.GetMyMenu(r.Context(), userID)
+	ctx := domain.WithAcceptLanguage(r.Context(), r.Header.Get("Accept-Language"))
+	menu, err := h.svc.GetMyMenu(ctx, userID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	// Clients poll the menu; let them revalidate it instead of downloading it again.
+	w.Header().Set("Cache-Control", "private, no-cache")
+	etag, err := httputil.ETag(menu)
 	if err != nil {
 		status, code := httputil.MapError(err)
 		jsonutil.RenderError(w, status, code, err.Error())
 		return
 	}
+	if httputil.NotModified(w, r, etag) {
+		return
+	}
 
 	jsonutil.RenderJSON(w, http.StatusOK, menu)
 }
 
+type menuPinsRequest struct {
+	MenuIDs []string `json:"menu_ids"`
+}
+
+// SetMenuPins replaces the caller's pinned menu items with the given IDs, in order.
+func (h *AuthHandler) SetMenuPins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req menuPinsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	pins, err := h.svc.SetMenuPins(r.Context(), userID, req.MenuIDs)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string][]string{"menu_ids": pins})
+}
+
+type menuVisitRequest struct {
+	MenuID string `json:"menu_id"`
+}
+
+// RecordMenuVisit is called by the client on navigation to feed the recent items section.
+func (h *AuthHandler) RecordMenuVisit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := currentUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req menuVisitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.RecordMenuVisit(r.Context(), userID, req.MenuID); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, map[string]string{"status": "recorded"})
+}
+
+func (h *AuthHandler) GetMenuDiagnostics(w http.ResponseWriter, r *http.Request) {
+	diagnostics, err := h.svc.GetMenuDiagnostics(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, diagnostics)
+}
+
+func (h *AuthHandler) GetMenuTranslationReport(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.svc.GetMenuTranslationReport(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, reports)
+}
+
 func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 	claims, ok := r.Context().Value(domain.UserClaimsKey).(*domain.UserClaims)
 	if !ok {
@@ -143,9 +312,5 @@ func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonutil.RenderJSON(w, http.StatusOK, meResponse{
-		ID:       user.ID.String(),
-		Email:    user.Email,
-		FullName: user.FullName,
-	})
+	jsonutil.RenderJSON(w, http.StatusOK, newMeResponse(user))
 }
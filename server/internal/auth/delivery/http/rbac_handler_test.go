@@ -0,0 +1,237 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/service"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/dpop"
+)
+
+// roleRepo is an in-memory stand-in for the role and permission parts of domain.Repository. It
+// records the address permissions were last evaluated for.
+type roleRepo struct {
+	domain.Repository
+	role     domain.Role
+	perms    []string
+	clientIP netip.Addr
+}
+
+func (r *roleRepo) GetRoles(context.Context) ([]domain.Role, error) {
+	return []domain.Role{r.role}, nil
+}
+
+func (r *roleRepo) UpdateRoleAllowedCIDRs(_ context.Context, _ int, cidrs []netip.Prefix) error {
+	r.role.AllowedCIDRs = cidrs
+	return nil
+}
+
+func (r *roleRepo) GetUserPermissions(_ context.Context, _ uuid.UUID, clientIP netip.Addr) ([]string, error) {
+	r.clientIP = clientIP
+	return r.perms, nil
+}
+
+// newProtectedRouter mounts the protected routes with the feature flags in enabled turned on.
+func newProtectedRouter(svc domain.Service, enabled ...string) *chi.Mux {
+	requireFlag := func(key string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !slices.Contains(enabled, key) {
+					http.NotFound(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(AuthMiddleware(svc, testJWTSecret, dpop.NewVerifier(time.Minute)))
+		RegisterProtectedHTTPHandlers(r, svc, 5*time.Minute, requireFlag)
+	})
+	return r
+}
+
+func userToken(t *testing.T, authTime time.Time) string {
+	t.Helper()
+	claims := domain.UserClaims{
+		TokenType: domain.TokenTypeAccess,
+		UserID:    uuid.NewString(),
+		AuthTime:  jwt.NewNumericDate(authTime),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func putAllowedCIDRs(router http.Handler, token, remoteAddr, roleID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/backoffice/roles/"+roleID+"/allowed-cidrs", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSetRoleAllowedCIDRsHandler(t *testing.T) {
+	repo := &roleRepo{role: domain.Role{ID: 1, Name: "Admin"}, perms: []string{domain.PermissionRoleWrite}}
+	router := newProtectedRouter(service.NewAuthService(repo, nil, nil, nil, service.Config{JWTSecret: testJWTSecret}))
+	token := userToken(t, time.Now())
+	const peer = "203.0.113.7:4000"
+
+	rec := putAllowedCIDRs(router, token, peer, "1", `{"allowed_cidrs": ["10.1.2.3/8", "192.0.2.10"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", rec.Code, rec.Body.String())
+	}
+	if got := decode[struct{ Data domain.Role }](t, rec).Data.AllowedCIDRs; len(got) != 2 || got[0].String() != "10.0.0.0/8" || got[1].String() != "192.0.2.10/32" {
+		t.Fatalf("expected normalised networks, got %v", got)
+	}
+	if repo.clientIP != netip.MustParseAddr("203.0.113.7") {
+		t.Fatalf("expected permissions to be checked for the peer address, got %s", repo.clientIP)
+	}
+
+	cases := []struct {
+		name, roleID, body string
+		status             int
+	}{
+		{name: "invalid network", roleID: "1", body: `{"allowed_cidrs": ["office"]}`, status: http.StatusBadRequest},
+		{name: "invalid role ID", roleID: "admin", body: `{"allowed_cidrs": []}`, status: http.StatusBadRequest},
+		{name: "unknown role", roleID: "2", body: `{"allowed_cidrs": []}`, status: http.StatusNotFound},
+		{name: "malformed body", roleID: "1", body: `{`, status: http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		if rec := putAllowedCIDRs(router, token, peer, tc.roleID, tc.body); rec.Code != tc.status {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.status, rec.Code)
+		}
+	}
+
+	if rec := putAllowedCIDRs(router, userToken(t, time.Now().Add(-time.Hour)), peer, "1", `{"allowed_cidrs": []}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a stale login to require re-authentication, got %d", rec.Code)
+	}
+
+	repo.perms = []string{domain.PermissionRoleRead}
+	if rec := putAllowedCIDRs(router, token, peer, "1", `{"allowed_cidrs": []}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a user without auth.role.write to be refused, got %d", rec.Code)
+	}
+}
+
+func TestSetRoleAllowedCIDRsWithUnknownAddress(t *testing.T) {
+	repo := &roleRepo{role: domain.Role{ID: 1, Name: "Admin"}}
+	router := newProtectedRouter(service.NewAuthService(repo, nil, nil, nil, service.Config{JWTSecret: testJWTSecret}))
+
+	// An address that cannot be parsed is unknown: permissions are evaluated without one, which
+	// the repository passes as NULL so no IP-restricted role applies.
+	repo.clientIP = netip.MustParseAddr("192.0.2.1")
+	rec := putAllowedCIDRs(router, userToken(t, time.Now()), "@unix", "1", `{"allowed_cidrs": []}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the permission, got %d", rec.Code)
+	}
+	if repo.clientIP.IsValid() {
+		t.Fatalf("expected permissions to be evaluated without an address, got %s", repo.clientIP)
+	}
+}
+
+func TestMenuPreferenceRoutesRequireFlag(t *testing.T) {
+	svc := service.NewAuthService(&roleRepo{}, nil, nil, nil, service.Config{JWTSecret: testJWTSecret})
+	token := userToken(t, time.Now())
+
+	send := func(router http.Handler, method, path string) int {
+		// A malformed body is refused by the handler, so any status but 404 means the route was reached.
+		req := httptest.NewRequest(method, path, strings.NewReader(`{`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	dark, enabled := newProtectedRouter(svc), newProtectedRouter(svc, domain.FlagMenuPreferences)
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPut, "/backoffice/me/menu/pins"},
+		{http.MethodPost, "/backoffice/me/menu/recent"},
+	} {
+		if code := send(dark, route.method, route.path); code != http.StatusNotFound {
+			t.Fatalf("%s %s: expected 404 while the flag is off, got %d", route.method, route.path, code)
+		}
+		if code := send(enabled, route.method, route.path); code != http.StatusBadRequest {
+			t.Fatalf("%s %s: expected the handler to be reached once the flag is on, got %d", route.method, route.path, code)
+		}
+	}
+}
+
+func TestRoleRoutesRequireRolePermissions(t *testing.T) {
+	repo := &roleRepo{role: domain.Role{ID: 1, Name: "Editor"}}
+	router := newProtectedRouter(service.NewAuthService(repo, nil, nil, nil, service.Config{JWTSecret: testJWTSecret}))
+	token := userToken(t, time.Now())
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	routes := []struct{ method, path, body, permission string }{
+		{http.MethodGet, "/backoffice/roles", "", domain.PermissionRoleRead},
+		{http.MethodPost, "/backoffice/roles", `{`, domain.PermissionRoleWrite},
+		{http.MethodPost, "/backoffice/roles/1/permissions", `{`, domain.PermissionRoleWrite},
+	}
+	for _, route := range routes {
+		repo.perms = nil
+		if code := send(route.method, route.path, route.body); code != http.StatusForbidden {
+			t.Fatalf("%s %s: expected a user without %s to be refused, got %d", route.method, route.path, route.permission, code)
+		}
+		repo.perms = []string{route.permission}
+		if code := send(route.method, route.path, route.body); code == http.StatusForbidden {
+			t.Fatalf("%s %s: expected a holder of %s to pass, got %d", route.method, route.path, route.permission, code)
+		}
+	}
+}
+
+func TestGroupMembershipRequiresStepUp(t *testing.T) {
+	repo := &roleRepo{perms: []string{domain.PermissionGroupWrite}}
+	router := newProtectedRouter(service.NewAuthService(repo, nil, nil, nil, service.Config{JWTSecret: testJWTSecret}))
+	stale := userToken(t, time.Now().Add(-time.Hour))
+	groupID := uuid.NewString()
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/backoffice/groups/" + groupID + "/members"},
+		{http.MethodDelete, "/backoffice/groups/" + groupID + "/members/" + uuid.NewString()},
+	} {
+		req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{}`))
+		req.Header.Set("Authorization", "Bearer "+stale)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s: expected a stale login to require re-authentication, got %d", route.method, route.path, rec.Code)
+		}
+	}
+}
+
+func TestMagicLinkOptInRequiresStepUp(t *testing.T) {
+	router := newProtectedRouter(service.NewAuthService(&roleRepo{}, nil, nil, nil, service.Config{JWTSecret: testJWTSecret}))
+
+	req := httptest.NewRequest(http.MethodPut, "/me/magic-link", strings.NewReader(`{"enabled":true}`))
+	req.Header.Set("Authorization", "Bearer "+userToken(t, time.Now().Add(-time.Hour)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "REAUTH_REQUIRED") {
+		t.Fatalf("expected a stale login to require re-authentication, got %d %s", rec.Code, rec.Body.String())
+	}
+}
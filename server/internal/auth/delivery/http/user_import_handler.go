@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+// maxImportBytes caps the size of an uploaded import CSV.
+const maxImportBytes = 5 << 20
+
+type acceptInvitationRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *AuthHandler) ValidateUserImport(w http.ResponseWriter, r *http.Request) {
+	file, ok := importFile(w, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	report, err := h.svc.ValidateUserImport(r.Context(), file)
+	if err != nil {
+		renderImportError(w, err)
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, report)
+}
+
+func (h *AuthHandler) StartUserImport(w http.ResponseWriter, r *http.Request) {
+	file, ok := importFile(w, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	job, err := h.svc.StartUserImport(r.Context(), file)
+	if err != nil {
+		renderImportError(w, err)
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusAccepted, job)
+}
+
+func (h *AuthHandler) GetUserImportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_UUID", "Invalid Job ID")
+		return
+	}
+
+	job, err := h.svc.GetUserImportJob(r.Context(), jobID)
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, job)
+}
+
+func (h *AuthHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	// The CSV is streamed, so errors can only be reported before the first byte is written.
+	filename := fmt.Sprintf("users-%s.csv", time.Now().UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if err := h.svc.ExportUsers(r.Context(), w); err != nil {
+		w.Header().Del("Content-Disposition")
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+	}
+}
+
+func (h *AuthHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req acceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	if err := h.svc.AcceptInvitation(r.Context(), req.Token, req.Password); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// importFile returns the uploaded CSV: the "file" part of a multipart form, or the raw body for
+// any other content type.
+func importFile(w http.ResponseWriter, r *http.Request) (io.ReadCloser, bool) {
+	// Leave room for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes+4096)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, true
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			jsonutil.RenderError(w, http.StatusRequestEntityTooLarge, "IMPORT_TOO_LARGE", "Import file must be at most 5 MiB")
+			return nil, false
+		}
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Expected a multipart form with a file")
+		return nil, false
+	}
+	return file, true
+}
+
+func renderImportError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		jsonutil.RenderError(w, http.StatusRequestEntityTooLarge, "IMPORT_TOO_LARGE", "Import file must be at most 5 MiB")
+		return
+	}
+	status, code := httputil.MapError(err)
+	jsonutil.RenderError(w, status, code, err.Error())
+}
@@ -1,11 +1,72 @@
 package domain
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type ContextKey string
 
 const UserClaimsKey ContextKey = "user_claims"
 
+const (
+	clientIPKey       ContextKey = "client_ip"
+	dpopThumbprintKey ContextKey = "dpop_jkt"
+	acceptLanguageKey ContextKey = "accept_language"
+	scopeCheckedKey   ContextKey = "scope_checked"
+)
+
+// WithScopeChecked records that a service principal's scopes were checked for the route, which
+// RequireRecentAuth demands of service clients in place of a recent login.
+func WithScopeChecked(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeCheckedKey, true)
+}
+
+// ScopeCheckedFromContext reports whether WithScopeChecked was called for the request.
+func ScopeCheckedFromContext(ctx context.Context) bool {
+	checked, _ := ctx.Value(scopeCheckedKey).(bool)
+	return checked
+}
+
+// WithClientIP records the address a request came from so IP-restricted roles can be evaluated.
+func WithClientIP(ctx context.Context, ip netip.Addr) context.Context {
+	return context.WithValue(ctx, clientIPKey, ip)
+}
+
+// ClientIPFromContext returns the request address, or the zero Addr when it is unknown. Roles with
+// an IP allowlist never apply to an unknown address.
+func ClientIPFromContext(ctx context.Context) netip.Addr {
+	ip, _ := ctx.Value(clientIPKey).(netip.Addr)
+	return ip
+}
+
+// WithAcceptLanguage records the request's Accept-Language header for localized responses.
+func WithAcceptLanguage(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, acceptLanguageKey, header)
+}
+
+// AcceptLanguageFromContext returns the Accept-Language header, or "" when the request had none.
+func AcceptLanguageFromContext(ctx context.Context) string {
+	header, _ := ctx.Value(acceptLanguageKey).(string)
+	return header
+}
+
+// WithDPoPThumbprint records the thumbprint of the key that signed the request's verified DPoP
+// proof. Tokens issued while handling the request are bound to that key.
+func WithDPoPThumbprint(ctx context.Context, jkt string) context.Context {
+	return context.WithValue(ctx, dpopThumbprintKey, jkt)
+}
+
+// DPoPThumbprintFromContext returns the verified DPoP key thumbprint, or "" when the request
+// carried no proof.
+func DPoPThumbprintFromContext(ctx context.Context) string {
+	jkt, _ := ctx.Value(dpopThumbprintKey).(string)
+	return jkt
+}
+
 type TokenType string
 
 const (
@@ -13,10 +74,51 @@ const (
 	TokenTypeRefresh TokenType = "refresh"
 )
 
-// UserClaims represents the claims of a JWT token issued to a user.
+// PrincipalType distinguishes human users from registered service clients.
+type PrincipalType string
+
+const (
+	PrincipalUser    PrincipalType = "user"
+	PrincipalService PrincipalType = "service"
+)
+
+// UserClaims represents the claims of a JWT token issued to a user or a service client.
+// Service tokens leave UserID and SessionID empty and carry ClientID and Scopes instead.
 type UserClaims struct {
 	UserID    string
 	SessionID string
 	TokenType TokenType
+	Principal PrincipalType `json:",omitempty"`
+	ClientID  string        `json:",omitempty"`
+	Scopes    []string      `json:",omitempty"`
+	// AuthTime is when the user last proved who they are (login or re-authentication), as in
+	// OpenID Connect. Refreshing tokens does not move it forward.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Confirmation binds the token to a DPoP key (RFC 9449). Bound tokens are only accepted
+	// together with a proof signed by that key.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
 	jwt.RegisteredClaims
 }
+
+// Confirmation is the RFC 7800 "cnf" claim. JKT is the RFC 7638 thumbprint of the bound key.
+type Confirmation struct {
+	JKT string `json:"jkt"`
+}
+
+// BoundKey returns the thumbprint the token is bound to, or "" for a plain bearer token.
+func (c *UserClaims) BoundKey() string {
+	if c.Confirmation == nil {
+		return ""
+	}
+	return c.Confirmation.JKT
+}
+
+// AuthenticatedWithin reports whether the user authenticated interactively within window of now.
+func (c *UserClaims) AuthenticatedWithin(window time.Duration, now time.Time) bool {
+	return c.AuthTime != nil && now.Sub(c.AuthTime.Time) <= window
+}
+
+// IsService reports whether the token was issued to a service client rather than a user.
+func (c *UserClaims) IsService() bool {
+	return c.Principal == PrincipalService
+}
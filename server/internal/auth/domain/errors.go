@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+var (
+	ErrInvalidScope = fmt.Errorf("%w: invalid scope", httputil.ErrBadRequest)
+
+	ErrPasskeysDisabled = errors.New("passkeys are not configured")
+
+	ErrInvalidGrantWindow = fmt.Errorf("%w: valid_until must be after valid_from", httputil.ErrBadRequest)
+	ErrElevationDecided   = fmt.Errorf("%w: elevation request was already decided", httputil.ErrConflict)
+	ErrSelfApproval       = fmt.Errorf("%w: elevation requests cannot be decided by the requester", httputil.ErrForbidden)
+	ErrElevationTooLong   = fmt.Errorf("%w: requested elevation exceeds the maximum duration", httputil.ErrBadRequest)
+
+	ErrInvalidLocale     = fmt.Errorf("%w: invalid locale", httputil.ErrBadRequest)
+	ErrInvalidTimezone   = fmt.Errorf("%w: invalid timezone", httputil.ErrBadRequest)
+	ErrInvalidEmail      = fmt.Errorf("%w: invalid email address", httputil.ErrBadRequest)
+	ErrUnsupportedAvatar = fmt.Errorf("%w: avatar must be a PNG, JPEG, GIF or WebP image", httputil.ErrBadRequest)
+	ErrEmailTaken        = fmt.Errorf("%w: email address is already in use", httputil.ErrConflict)
+
+	ErrInvalidCIDR = fmt.Errorf("%w: invalid CIDR", httputil.ErrBadRequest)
+
+	ErrTOTPAlreadyEnabled = fmt.Errorf("%w: an authenticator app is already set up", httputil.ErrConflict)
+	ErrTOTPNotEnabled     = fmt.Errorf("%w: no authenticator app is set up", httputil.ErrBadRequest)
+	ErrFactorLocked       = fmt.Errorf("%w: too many failed attempts, try again later", httputil.ErrTooManyRequests)
+
+	ErrInvalidImportFile = fmt.Errorf("%w: invalid import file", httputil.ErrBadRequest)
+
+	ErrInvalidMenu         = fmt.Errorf("%w: invalid menu definitions", httputil.ErrBadRequest)
+	ErrMenuItemNotFound    = fmt.Errorf("%w: menu item not found", httputil.ErrNotFound)
+	ErrInvalidMenuOverride = fmt.Errorf("%w: invalid menu override", httputil.ErrBadRequest)
+	ErrTooManyMenuPins     = fmt.Errorf("%w: too many pinned menu items", httputil.ErrBadRequest)
+	ErrStaleMenuVersion    = fmt.Errorf("%w: menu registration is older than the stored version", httputil.ErrConflict)
+)
+
+// SecondFactorRequiredError is returned by Login when the password was correct but the user
+// must still complete a passkey assertion. Challenge starts that ceremony.
+type SecondFactorRequiredError struct {
+	Challenge PasskeyChallenge
+}
+
+func (e *SecondFactorRequiredError) Error() string {
+	return "second factor required"
+}
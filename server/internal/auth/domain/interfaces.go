@@ -2,33 +2,175 @@ package domain
 
 import (
 	"context"
+	"io"
+	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
 )
 
+// Mailer delivers transactional emails such as login links and invitations.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// FlagSource looks up feature flags, e.g. a features.Client replica.
+type FlagSource interface {
+	Flag(key string) (flags.Flag, bool)
+}
+
+// FlagMenuPreferences gates menu favorites and recent items. It is seeded on for everyone; while it
+// is off for a user, the preference routes answer 404 and the favorites and recent sections stay
+// empty.
+const FlagMenuPreferences = "auth.menu.preferences"
+
+// MediaStorage stores uploaded files such as avatars.
+type MediaStorage interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Encryptor seals secret-bearing columns at rest. aad binds a value to its record so it cannot be
+// copied onto another row.
+type Encryptor interface {
+	Encrypt(plaintext, aad []byte) (string, error)
+	Decrypt(sealed string, aad []byte) ([]byte, error)
+	// CurrentPrefix is shared by every value sealed under the primary master key; values without
+	// it need re-encryption.
+	CurrentPrefix() string
+}
+
 // Repository defines an interface for managing user data storage and retrieval operations in the system.
 type Repository interface {
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*User, error)
 	CreateUser(ctx context.Context, user *User) error
+	UpdateUserProfile(ctx context.Context, user *User) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+
+	// User import and export
+	// CreateInvitedUser stores a user without a password, their role grants and the invitation
+	// that lets them choose one, all or nothing.
+	CreateInvitedUser(ctx context.Context, user *User, grants []RoleGrant, invitation *UserInvitation) error
+	// AcceptInvitation redeems a pending invitation by token hash, sets the password and
+	// activates the user.
+	AcceptInvitation(ctx context.Context, tokenHash, passwordHash string) (*UserInvitation, error)
+	CreateUserImportJob(ctx context.Context, job *UserImportJob) error
+	UpdateUserImportJob(ctx context.Context, job *UserImportJob) error
+	GetUserImportJob(ctx context.Context, jobID uuid.UUID) (*UserImportJob, error)
+	// FailStaleUserImportJobs marks unfinished jobs last updated before staleBefore as failed.
+	FailStaleUserImportJobs(ctx context.Context, staleBefore time.Time) (int, error)
+	GetUsersForExport(ctx context.Context) ([]UserExportRow, error)
+	// GetRegisteredEmails returns, in lower case, those of emails (given in lower case) that belong
+	// to an account regardless of case.
+	GetRegisteredEmails(ctx context.Context, emails []string) ([]string, error)
+
+	// Email changes
+	CreateEmailChange(ctx context.Context, change *EmailChange) error
+	// ConfirmEmailChange redeems a pending change by token hash and moves the user to the new address.
+	ConfirmEmailChange(ctx context.Context, tokenHash string) (*EmailChange, error)
 
 	// Sessions
 	CreateSession(ctx context.Context, session *Session) error
 	GetSessionByID(ctx context.Context, sessionID uuid.UUID) (*Session, error)
 	UpdateSessionRefresh(ctx context.Context, sessionID uuid.UUID, refreshTokenHash string, expiresAt time.Time) error
+	UpdateSessionAuthTime(ctx context.Context, sessionID uuid.UUID, authTime time.Time) error
+
+	// Authenticator apps (TOTP)
+	GetTOTPFactor(ctx context.Context, userID uuid.UUID) (*TOTPFactor, error)
+	// SaveTOTPFactor stores an unconfirmed secret, replacing any previous unconfirmed one.
+	SaveTOTPFactor(ctx context.Context, factor *TOTPFactor) error
+	ConfirmTOTPFactor(ctx context.Context, userID uuid.UUID, step int64) error
+	// UseTOTPStep records step as used and reports false if it, or a later step, was already used.
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	DeleteTOTPFactor(ctx context.Context, userID uuid.UUID) error
+
+	// Re-authentication lockouts, per user and factor
+	GetReauthLockedUntil(ctx context.Context, userID uuid.UUID, method string) (*time.Time, error)
+	// RecordReauthFailure counts a failed attempt, locking the factor for lockFor on the maxAttempts-th.
+	RecordReauthFailure(ctx context.Context, userID uuid.UUID, method string, maxAttempts int, lockFor time.Duration) error
+	ResetReauthFailures(ctx context.Context, userID uuid.UUID, method string) error
+
+	// Magic links
+	SetMagicLinkEnabled(ctx context.Context, userID uuid.UUID, enabled bool) error
+	CreateMagicLink(ctx context.Context, link *MagicLink) error
+	CountRecentMagicLinks(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	ConsumeMagicLink(ctx context.Context, tokenHash, deviceHash string) (*MagicLink, error)
+
+	// Passkeys
+	CreatePasskey(ctx context.Context, passkey *Passkey) error
+	GetPasskeysByUserID(ctx context.Context, userID uuid.UUID) ([]Passkey, error)
+	UpdatePasskeyUsage(ctx context.Context, passkeyID uuid.UUID, signCount uint32, backupState bool) error
+	DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error
+	CreatePasskeyCeremony(ctx context.Context, ceremony *PasskeyCeremony) error
+	ConsumePasskeyCeremony(ctx context.Context, ceremonyID uuid.UUID, purposes ...PasskeyCeremonyPurpose) (*PasskeyCeremony, error)
 
 	// RBAC
 	UpsertPermissions(ctx context.Context, permissions []Permission) error
 	CreateRole(ctx context.Context, name string) (*Role, error)
 	GetRoles(ctx context.Context) ([]Role, error)
-	AssignRoleToUser(ctx context.Context, userID uuid.UUID, roleID int) error
-	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	AssignRoleToUser(ctx context.Context, grant *RoleGrant) error
+	RemoveRoleFromUser(ctx context.Context, userID uuid.UUID, roleID int) error
+	GetRoleGrantsByUserID(ctx context.Context, userID uuid.UUID) ([]RoleGrant, error)
+	DeleteExpiredRoleGrants(ctx context.Context, now time.Time) ([]RoleGrant, error)
+	UpdateRoleAllowedCIDRs(ctx context.Context, roleID int, cidrs []netip.Prefix) error
+	// GetUserPermissions returns the permissions of the user's active roles. Roles with an IP
+	// allowlist only count when clientIP is inside it.
+	GetUserPermissions(ctx context.Context, userID uuid.UUID, clientIP netip.Addr) ([]string, error)
+	// GetUserRoleNames returns the names of the same roles GetUserPermissions draws from.
+	GetUserRoleNames(ctx context.Context, userID uuid.UUID, clientIP netip.Addr) ([]string, error)
 	AddPermissionToRole(ctx context.Context, roleID int, permissionID string) error
 
+	// Groups
+	CreateGroup(ctx context.Context, group *Group) error
+	GetGroups(ctx context.Context) ([]Group, error)
+	GetGroupByID(ctx context.Context, groupID uuid.UUID) (*Group, error)
+	UpdateGroup(ctx context.Context, group *Group) error
+	DeleteGroup(ctx context.Context, groupID uuid.UUID) error
+	GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]GroupMember, error)
+	AddGroupMember(ctx context.Context, groupID, userID uuid.UUID) error
+	RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error
+	AssignRoleToGroup(ctx context.Context, groupID uuid.UUID, roleID int) error
+	RemoveRoleFromGroup(ctx context.Context, groupID uuid.UUID, roleID int) error
+
+	// Elevation requests
+	CreateElevationRequest(ctx context.Context, req *ElevationRequest) error
+	GetElevationRequestByID(ctx context.Context, requestID uuid.UUID) (*ElevationRequest, error)
+	GetElevationRequests(ctx context.Context, userID *uuid.UUID, status ElevationStatus) ([]ElevationRequest, error)
+	// DecideElevationRequest records the decision of a pending request and, when grant is not nil,
+	// stores the resulting role grant in the same transaction.
+	DecideElevationRequest(ctx context.Context, req *ElevationRequest, grant *RoleGrant) error
+
+	// Service clients
+	CreateServiceClient(ctx context.Context, client *ServiceClient) error
+	GetServiceClientByID(ctx context.Context, clientID uuid.UUID) (*ServiceClient, error)
+	GetServiceClients(ctx context.Context) ([]ServiceClient, error)
+	RevokeServiceClient(ctx context.Context, clientID uuid.UUID) error
+	GetExistingPermissionIDs(ctx context.Context, ids []string) ([]string, error)
+
+	// Encryption at rest
+	// Reencrypt re-seals a batch of secrets not yet under the primary master key, including
+	// legacy plaintext, and reports how many it changed.
+	Reencrypt(ctx context.Context) (int, error)
+
 	// Menu definitions
-	UpsertMenuDefinitions(ctx context.Context, defs []MenuDefinition) error
+	ReplaceMenuDefinitions(ctx context.Context, domain string, version int, defs []MenuDefinition) error
 	GetMenuDefinitions(ctx context.Context) ([]MenuDefinition, error)
+	GetMenuDomains(ctx context.Context) ([]MenuDomain, error)
+	GetMenuOverrides(ctx context.Context) ([]MenuOverride, error)
+	GetMenuOverride(ctx context.Context, menuID string) (*MenuOverride, error)
+	UpsertMenuOverride(ctx context.Context, override *MenuOverride) error
+	DeleteMenuOverride(ctx context.Context, menuID string) error
+	// DeleteMenuOverrides removes every override and returns the IDs of the items they applied to.
+	DeleteMenuOverrides(ctx context.Context) ([]string, error)
+
+	// Menu preferences
+	GetMenuPreferences(ctx context.Context, userID uuid.UUID) (*MenuPreferences, error)
+	ReplaceMenuPins(ctx context.Context, userID uuid.UUID, menuIDs []string) error
+	RecordMenuVisit(ctx context.Context, userID uuid.UUID, menuID string, keep int) error
 }
 
 // Service defines an interface for managing user authentication and registration operations in the system.
@@ -37,13 +179,121 @@ type Service interface {
 	RefreshTokens(ctx context.Context, refreshToken string) (AuthTokens, error)
 	GetMe(ctx context.Context, userID uuid.UUID) (*User, error)
 	Register(ctx context.Context, user User) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error
+
+	// Step-up authentication
+	// Reauthenticate re-checks the user's password or TOTP code and reissues the session's tokens
+	// with a fresh auth_time.
+	Reauthenticate(ctx context.Context, userID, sessionID uuid.UUID, password, totpCode string) (AuthTokens, error)
+	BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+
+	// Bulk user import and export
+	// ValidateUserImport checks an import CSV without creating anything.
+	ValidateUserImport(ctx context.Context, r io.Reader) (*UserImportReport, error)
+	// StartUserImport validates the CSV, then creates the users in the background and mails each
+	// an invitation. Progress is reported through GetUserImportJob.
+	StartUserImport(ctx context.Context, r io.Reader) (*UserImportJob, error)
+	GetUserImportJob(ctx context.Context, jobID uuid.UUID) (*UserImportJob, error)
+	// FailStaleUserImports marks imports whose runner stopped saving progress as failed.
+	FailStaleUserImports(ctx context.Context) (int, error)
+	AcceptInvitation(ctx context.Context, token, password string) error
+	ExportUsers(ctx context.Context, w io.Writer) error
+
+	// Profile self-service
+	UpdateProfile(ctx context.Context, userID uuid.UUID, update ProfileUpdate) (*User, error)
+	UpdateAvatar(ctx context.Context, userID uuid.UUID, contentType string, r io.Reader) (*User, error)
+	DeleteAvatar(ctx context.Context, userID uuid.UUID) (*User, error)
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error
+	ConfirmEmailChange(ctx context.Context, token string) (*User, error)
+	DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error
+
+	// Magic links
+	SetMagicLinkEnabled(ctx context.Context, userID uuid.UUID, enabled bool) error
+	RequestMagicLink(ctx context.Context, email, requestIP, currentDeviceToken string) (deviceToken string, err error)
+	LoginWithMagicLink(ctx context.Context, token, deviceToken string) (AuthTokens, error)
+
+	// Passkeys (WebAuthn)
+	BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (PasskeyChallenge, error)
+	FinishPasskeyRegistration(ctx context.Context, userID, ceremonyID uuid.UUID, name string, credential []byte) (*Passkey, error)
+	GetPasskeys(ctx context.Context, userID uuid.UUID) ([]Passkey, error)
+	DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error
+	BeginPasskeyLogin(ctx context.Context) (PasskeyChallenge, error)
+	FinishPasskeyLogin(ctx context.Context, ceremonyID uuid.UUID, credential []byte) (AuthTokens, error)
+	FinishPasskeySecondFactor(ctx context.Context, ceremonyID uuid.UUID, credential []byte) (AuthTokens, error)
 
 	// RBAC
 	RegisterModulePermissions(ctx context.Context, module string, permissions []string) error
-	RegisterModuleMenus(ctx context.Context, domain string, defs []MenuDefinition) error
+	// RegisterModuleMenus validates and applies a domain's menu, returning the problems found.
+	// Blocking problems reject it with a *MenuValidationError.
+	RegisterModuleMenus(ctx context.Context, domain string, version int, defs []MenuDefinition) ([]MenuProblem, error)
 	CreateRole(ctx context.Context, name string) (*Role, error)
 	GetRoles(ctx context.Context) ([]Role, error)
-	AssignRole(ctx context.Context, userID uuid.UUID, roleID int) error
-	GetMyMenu(ctx context.Context, userID uuid.UUID) ([]MenuNode, error)
+	AssignRole(ctx context.Context, userID uuid.UUID, roleID int, validFrom, validUntil *time.Time) error
+	RevokeRole(ctx context.Context, userID uuid.UUID, roleID int) error
+	GetRoleGrants(ctx context.Context, userID uuid.UUID) ([]RoleGrant, error)
+	// ExpireRoleGrants removes grants past their valid_until and publishes a revocation for each.
+	ExpireRoleGrants(ctx context.Context) (int, error)
+	SetRoleAllowedCIDRs(ctx context.Context, roleID int, cidrs []string) (*Role, error)
+	// GetUserPermissions evaluates IP-restricted roles against the address in ctx (see WithClientIP).
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// GetUserRoleNames names the roles behind GetUserPermissions, e.g. for feature flag targeting.
+	GetUserRoleNames(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// GetMyPermissions is GetUserPermissions sorted and versioned for clients.
+	GetMyPermissions(ctx context.Context, userID uuid.UUID) (*PermissionSet, error)
+	// GetMyMenu localizes labels for the Accept-Language in ctx (see WithAcceptLanguage), falling
+	// back to the user's stored locale. Every placement gets its own tree.
+	GetMyMenu(ctx context.Context, userID uuid.UUID) (MenuTrees, error)
+	// InvalidateMenuCache drops the cached menu trees, e.g. after another instance changed menus.
+	InvalidateMenuCache()
+	// SetMenuPins replaces the user's pinned menu items, in order, and returns them. The pins
+	// appear in the favorites section of GetMyMenu while the user may see the items.
+	SetMenuPins(ctx context.Context, userID uuid.UUID, menuIDs []string) ([]string, error)
+	// RecordMenuVisit adds the item to the recent section of the user's menu.
+	RecordMenuVisit(ctx context.Context, userID uuid.UUID, menuID string) error
+	// GetMenuDiagnostics re-validates every registered menu and lists the problems by domain.
+	GetMenuDiagnostics(ctx context.Context) ([]MenuDiagnostics, error)
+	// GetMenuTranslationReport lists, per non-default supported locale, the items lacking a label.
+	GetMenuTranslationReport(ctx context.Context) ([]MenuTranslationReport, error)
+	// GetRouteManifest lists the permissions guarding every registered menu path.
+	GetRouteManifest(ctx context.Context) (*RouteManifest, error)
+
+	// Menu overrides
+	GetMenuItems(ctx context.Context) ([]MenuItem, error)
+	GetMenuItem(ctx context.Context, menuID string) (*MenuItem, error)
+	SetMenuOverride(ctx context.Context, menuID string, override MenuOverride) (*MenuItem, error)
+	// ResetMenuOverride restores the declared item; ResetMenuOverrides does so for every item.
+	ResetMenuOverride(ctx context.Context, menuID string) (*MenuItem, error)
+	ResetMenuOverrides(ctx context.Context) (int, error)
 	AddPermissionToRole(ctx context.Context, roleID int, permissionID string) error
+
+	// Groups
+	CreateGroup(ctx context.Context, name, description string) (*Group, error)
+	GetGroups(ctx context.Context) ([]Group, error)
+	GetGroup(ctx context.Context, groupID uuid.UUID) (*Group, error)
+	UpdateGroup(ctx context.Context, groupID uuid.UUID, name, description string) (*Group, error)
+	DeleteGroup(ctx context.Context, groupID uuid.UUID) error
+	GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]GroupMember, error)
+	AddGroupMember(ctx context.Context, groupID, userID uuid.UUID) error
+	RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error
+	AssignRoleToGroup(ctx context.Context, groupID uuid.UUID, roleID int) error
+	RevokeRoleFromGroup(ctx context.Context, groupID uuid.UUID, roleID int) error
+
+	// Just-in-time elevation
+	RequestElevation(ctx context.Context, userID uuid.UUID, roleID int, reason string, durationMinutes int) (*ElevationRequest, error)
+	GetMyElevationRequests(ctx context.Context, userID uuid.UUID) ([]ElevationRequest, error)
+	GetElevationRequests(ctx context.Context, status ElevationStatus) ([]ElevationRequest, error)
+	ApproveElevation(ctx context.Context, requestID, approverID uuid.UUID, note string) (*ElevationRequest, error)
+	DenyElevation(ctx context.Context, requestID, approverID uuid.UUID, note string) (*ElevationRequest, error)
+
+	// Service clients (OAuth2 client_credentials)
+	CreateServiceClient(ctx context.Context, name string, scopes []string) (*ServiceClient, string, error)
+	GetServiceClients(ctx context.Context) ([]ServiceClient, error)
+	RevokeServiceClient(ctx context.Context, clientID uuid.UUID) error
+	IssueClientToken(ctx context.Context, clientID uuid.UUID, clientSecret string, scopes []string) (ClientToken, error)
+	IntrospectToken(ctx context.Context, clientID uuid.UUID, clientSecret string, token string) (TokenIntrospection, error)
+	// CheckServiceClient fails with httputil.ErrUnauthorized when the client a token was issued
+	// to is unknown or has been revoked.
+	CheckServiceClient(ctx context.Context, clientID string) error
 }
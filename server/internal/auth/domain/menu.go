@@ -1,6 +1,126 @@
 package domain
 
-import "github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+)
 
 type MenuDefinition = menu.MenuDefinition
 type MenuNode = menu.MenuNode
+type MenuTrees = menu.MenuTrees
+type MenuBadge = menu.Badge
+type MenuProblem = menu.Problem
+
+// MenuDomain records the menu version last applied for a domain.
+type MenuDomain struct {
+	Domain       string    `json:"domain"`
+	Version      int       `json:"version"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// MenuDiagnostics lists the problems found in one domain's registered menu.
+type MenuDiagnostics struct {
+	MenuDomain
+	Items    int           `json:"items"`
+	Problems []MenuProblem `json:"problems"`
+}
+
+// MenuValidationError rejects a menu registration with blocking problems.
+type MenuValidationError struct {
+	Problems []MenuProblem
+}
+
+func (e *MenuValidationError) Error() string {
+	return fmt.Sprintf("%v: %d blocking problem(s), first: %s", ErrInvalidMenu, len(e.Problems), e.Problems[0].Message)
+}
+
+func (e *MenuValidationError) Unwrap() error {
+	return ErrInvalidMenu
+}
+
+// MenuOverride is an admin change layered over a module-declared menu item. Nil fields keep the
+// declared value.
+type MenuOverride struct {
+	MenuID    string     `json:"menu_id"`
+	Hidden    *bool      `json:"hidden,omitempty"`
+	Label     *string    `json:"label,omitempty"`
+	Icon      *string    `json:"icon,omitempty"`
+	Order     *int       `json:"order,omitempty"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Apply returns def with the override's changes.
+func (o MenuOverride) Apply(def MenuDefinition) MenuDefinition {
+	if o.Hidden != nil {
+		def.Visible = def.Visible && !*o.Hidden
+	}
+	if o.Label != nil {
+		// An admin label replaces the text in every locale.
+		def.Label = *o.Label
+		def.Labels = nil
+	}
+	if o.Icon != nil {
+		def.Icon = *o.Icon
+	}
+	if o.Order != nil {
+		def.Order = *o.Order
+	}
+	return def
+}
+
+// MenuItem is a registered menu item as administrators see it: the effective definition, the
+// module's declaration and the override between them, if any.
+type MenuItem struct {
+	MenuDefinition
+	Declared MenuDefinition `json:"declared"`
+	Override *MenuOverride  `json:"override,omitempty"`
+}
+
+// MenuTranslationReport lists the menu items without a label in one supported locale.
+type MenuTranslationReport struct {
+	Locale     string               `json:"locale"`
+	Total      int                  `json:"total"`
+	Translated int                  `json:"translated"`
+	Missing    []MissingTranslation `json:"missing"`
+}
+
+// MissingTranslation is a menu item shown with its default-locale Label for lack of a translation.
+type MissingTranslation struct {
+	MenuID string `json:"menu_id"`
+	Domain string `json:"domain"`
+	Label  string `json:"label"`
+}
+
+// PermissionSet is a user's effective permissions. Version changes whenever the set does, so
+// clients can tell whether guards built from an earlier answer are stale.
+type PermissionSet struct {
+	Permissions []string `json:"permissions"`
+	Version     string   `json:"version"`
+}
+
+// MenuPreferences holds a user's pinned menu items in their order and recently visited ones,
+// latest first.
+type MenuPreferences struct {
+	Pins   []string `json:"pins"`
+	Recent []string `json:"recent"`
+}
+
+// RouteManifest lists the client routes declared by menu items and the permissions they need.
+type RouteManifest struct {
+	Routes  []RouteGuard `json:"routes"`
+	Version string       `json:"version"`
+}
+
+// RouteGuard guards the path of one menu item. Requires holds one group per level of the menu,
+// from the root to the item; the user needs at least one permission of every group, exactly as
+// for the item to appear in their menu. An empty Requires admits any authenticated user.
+type RouteGuard struct {
+	MenuID   string     `json:"menu_id"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Requires [][]string `json:"requires"`
+}
@@ -6,6 +6,17 @@ const (
 	PermissionRoleDelete = "auth.role.delete"
 	PermissionUserRead   = "auth.user.read"
 	PermissionUserWrite  = "auth.user.write"
+
+	PermissionClientRead  = "auth.client.read"
+	PermissionClientWrite = "auth.client.write"
+
+	PermissionElevationApprove = "auth.elevation.approve"
+
+	PermissionGroupRead  = "auth.group.read"
+	PermissionGroupWrite = "auth.group.write"
+
+	PermissionMenuRead  = "auth.menu.read"
+	PermissionMenuWrite = "auth.menu.write"
 )
 
 func GetAvailablePermissions() []string {
@@ -15,5 +26,12 @@ func GetAvailablePermissions() []string {
 		PermissionRoleDelete,
 		PermissionUserRead,
 		PermissionUserWrite,
+		PermissionClientRead,
+		PermissionClientWrite,
+		PermissionElevationApprove,
+		PermissionGroupRead,
+		PermissionGroupWrite,
+		PermissionMenuRead,
+		PermissionMenuWrite,
 	}
 }
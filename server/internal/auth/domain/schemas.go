@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"net/netip"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,14 @@ type User struct {
 	PasswordHash string
 	FullName     string
 
+	MagicLinkEnabled bool
+
+	// Profile preferences. AvatarKey locates the avatar in media storage; AvatarURL is derived from it.
+	Locale    string
+	Timezone  string
+	AvatarKey string
+	AvatarURL string
+
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	ActivatedAt *time.Time
@@ -29,21 +38,296 @@ type Permission struct {
 type Role struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
+	// AllowedCIDRs restricts the role to requests from these networks. Empty means anywhere.
+	AllowedCIDRs []netip.Prefix `json:"allowed_cidrs,omitempty"`
+}
+
+// ProfileUpdate carries the fields of a PATCH /me request. Nil fields are left unchanged.
+type ProfileUpdate struct {
+	FullName *string
+	Locale   *string
+	Timezone *string
+}
+
+// EmailChange is a pending request to move an account to a new address. It is applied once
+// the token mailed to NewEmail is confirmed.
+type EmailChange struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	NewEmail    string
+	TokenHash   string
+	ExpiresAt   time.Time
+	ConfirmedAt *time.Time
+	// PreviousEmail is only filled in once the change has been confirmed.
+	PreviousEmail string
+}
+
+// Group is a set of users that holds roles on their behalf, typically a department or team.
+type Group struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Roles       []Role    `json:"roles"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type GroupMember struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	FullName string    `json:"full_name"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// RoleGrant is a user's assignment to a role. It only counts between ValidFrom and ValidUntil;
+// a nil ValidUntil means the grant never expires.
+type RoleGrant struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	RoleID     int        `json:"role_id"`
+	RoleName   string     `json:"role_name,omitempty"`
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	GrantedBy  *uuid.UUID `json:"granted_by,omitempty"`
+}
+
+// Active reports whether the grant is in effect at t.
+func (g RoleGrant) Active(t time.Time) bool {
+	return !t.Before(g.ValidFrom) && (g.ValidUntil == nil || t.Before(*g.ValidUntil))
+}
+
+type ElevationStatus string
+
+const (
+	ElevationPending  ElevationStatus = "pending"
+	ElevationApproved ElevationStatus = "approved"
+	ElevationDenied   ElevationStatus = "denied"
+)
+
+// ElevationRequest is a user's request to hold a role temporarily. Approval creates a RoleGrant
+// that expires DurationMinutes after the decision.
+type ElevationRequest struct {
+	ID              uuid.UUID       `json:"id"`
+	UserID          uuid.UUID       `json:"user_id"`
+	RoleID          int             `json:"role_id"`
+	Reason          string          `json:"reason"`
+	DurationMinutes int             `json:"duration_minutes"`
+	Status          ElevationStatus `json:"status"`
+	DecidedBy       *uuid.UUID      `json:"decided_by,omitempty"`
+	DecisionNote    string          `json:"decision_note,omitempty"`
+	DecidedAt       *time.Time      `json:"decided_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
 }
 
 type AuthTokens struct {
-	AccessToken      string    `json:"access_token"`
-	RefreshToken     string    `json:"refresh_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	// TokenType is the Authorization scheme the access token must be sent with: "DPoP" for
+	// key-bound tokens, "Bearer" otherwise.
+	TokenType        string    `json:"token_type"`
 	AccessExpiresAt  time.Time `json:"access_expires_at"`
 	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
+// Authorization schemes reported in AuthTokens.TokenType.
+const (
+	TokenSchemeBearer = "Bearer"
+	TokenSchemeDPoP   = "DPoP"
+)
+
+// ServiceClient is a non-human principal authenticating through the client_credentials grant.
+type ServiceClient struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type ClientToken struct {
+	AccessToken string
+	ExpiresAt   time.Time
+	Scopes      []string
+}
+
+// TokenIntrospection is the RFC 7662 view of a token.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	// Confirmation is set for DPoP-bound tokens.
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+}
+
+// MagicLink is a single-use passwordless login token bound to the browser that requested it.
+type MagicLink struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TokenHash   string
+	DeviceHash  string
+	RequestedIP string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	CreatedAt   time.Time
+}
+
+// Passkey is a WebAuthn credential registered by a user.
+type Passkey struct {
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"-"`
+	Name            string     `json:"name"`
+	CredentialID    []byte     `json:"-"`
+	PublicKey       []byte     `json:"-"`
+	AttestationType string     `json:"-"`
+	AAGUID          []byte     `json:"-"`
+	SignCount       uint32     `json:"sign_count"`
+	Transports      []string   `json:"transports,omitempty"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+}
+
+type PasskeyCeremonyPurpose string
+
+const (
+	PasskeyCeremonyRegistration PasskeyCeremonyPurpose = "registration"
+	PasskeyCeremonyLogin        PasskeyCeremonyPurpose = "login"
+	PasskeyCeremonySecondFactor PasskeyCeremonyPurpose = "second_factor"
+	// PasskeyCeremonyMagicLinkFactor is the second factor of a magic-link login.
+	PasskeyCeremonyMagicLinkFactor PasskeyCeremonyPurpose = "magic_link_factor"
+)
+
+// PasskeyCeremony keeps the server-side state of an in-flight WebAuthn ceremony between its begin and finish steps.
+type PasskeyCeremony struct {
+	ID          uuid.UUID
+	UserID      *uuid.UUID
+	Purpose     PasskeyCeremonyPurpose
+	SessionData []byte
+	ExpiresAt   time.Time
+}
+
+// PasskeyChallenge is handed to the browser to start a ceremony. Options is passed as-is to
+// navigator.credentials.create() or navigator.credentials.get().
+// TOTPFactor is a user's authenticator app (RFC 6238) secret. It can only be used once confirmed.
+type TOTPFactor struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+// TOTPEnrollment is shown once while setting up an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	// URI is an otpauth:// URI, usually rendered as a QR code.
+	URI string `json:"otpauth_uri"`
+}
+
+type PasskeyChallenge struct {
+	CeremonyID uuid.UUID `json:"ceremony_id"`
+	Options    any       `json:"options"`
+}
+
 type Session struct {
 	ID               uuid.UUID
 	UserID           uuid.UUID
 	RefreshTokenHash string
 	ExpiresAt        time.Time
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
-	RevokedAt        *time.Time
+	// AuthTime is the last interactive authentication on the session; see UserClaims.AuthTime.
+	AuthTime time.Time
+	// DPoPJKT is the thumbprint of the DPoP key the session's tokens are bound to, or "" for
+	// bearer sessions. Refreshing a bound session requires a proof from the same key.
+	DPoPJKT   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// UserImportRow is one data line of a user import CSV. Line is the 1-based line number in the
+// file, counting the header.
+type UserImportRow struct {
+	Line     int
+	Email    string
+	FullName string
+	Roles    []string
+}
+
+type UserImportRowStatus string
+
+const (
+	// ImportRowValid marks a row that passed validation and would be imported.
+	ImportRowValid   UserImportRowStatus = "valid"
+	ImportRowInvalid UserImportRowStatus = "invalid"
+	ImportRowCreated UserImportRowStatus = "created"
+	// ImportRowInvitationNotSent marks a row whose user was created but could not be mailed the
+	// invitation.
+	ImportRowInvitationNotSent UserImportRowStatus = "created_invitation_not_sent"
+	ImportRowFailed            UserImportRowStatus = "failed"
+)
+
+type UserImportRowResult struct {
+	Line   int                 `json:"line"`
+	Email  string              `json:"email"`
+	Status UserImportRowStatus `json:"status"`
+	UserID *uuid.UUID          `json:"user_id,omitempty"`
+	Errors []string            `json:"errors,omitempty"`
+}
+
+// UserImportReport is the outcome of validating an import file without creating anything.
+type UserImportReport struct {
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`
+	Invalid int                   `json:"invalid"`
+	Rows    []UserImportRowResult `json:"rows"`
+}
+
+type UserImportStatus string
+
+const (
+	UserImportPending   UserImportStatus = "pending"
+	UserImportRunning   UserImportStatus = "running"
+	UserImportCompleted UserImportStatus = "completed"
+	// UserImportFailed marks a job that stopped making progress, e.g. because the instance running
+	// it was restarted. Rows still "valid" were not processed.
+	UserImportFailed UserImportStatus = "failed"
+)
+
+// UserImportJob is a user import running in the background. Rows fills in as it progresses.
+type UserImportJob struct {
+	ID         uuid.UUID             `json:"id"`
+	Status     UserImportStatus      `json:"status"`
+	CreatedBy  *uuid.UUID            `json:"created_by,omitempty"`
+	Total      int                   `json:"total"`
+	Succeeded  int                   `json:"succeeded"`
+	Failed     int                   `json:"failed"`
+	Rows       []UserImportRowResult `json:"rows"`
+	CreatedAt  time.Time             `json:"created_at"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+}
+
+// UserInvitation lets an imported user choose their password. Invited users have no password
+// and are not activated until it is accepted.
+type UserInvitation struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+}
+
+// UserExportRow is a user with the names of the roles granted to them directly.
+type UserExportRow struct {
+	ID          uuid.UUID
+	Email       string
+	FullName    string
+	Roles       []string
+	CreatedAt   time.Time
+	ActivatedAt *time.Time
 }
@@ -2,10 +2,15 @@ package auth
 
 import "github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
 
+// MenuVersion must be bumped whenever MenuDefinitions changes, so that an instance still running
+// older code cannot overwrite the newer menu during a rolling deploy.
+const MenuVersion = 2
+
 var MenuDefinitions = []domain.MenuDefinition{
 	{
 		ID:      "core:dashboard",
 		Label:   "Dashboard",
+		Labels:  map[string]string{"pt": "Painel", "es": "Panel"},
 		Path:    "/dashboard",
 		Icon:    "line-chart",
 		Order:   0,
@@ -14,6 +19,7 @@ var MenuDefinitions = []domain.MenuDefinition{
 	{
 		ID:          "auth:system",
 		Label:       "System",
+		Labels:      map[string]string{"pt": "Sistema", "es": "Sistema"},
 		Icon:        "settings",
 		Order:       90,
 		Permissions: []string{domain.PermissionRoleRead},
@@ -22,6 +28,7 @@ var MenuDefinitions = []domain.MenuDefinition{
 	{
 		ID:          "auth:roles",
 		Label:       "Roles",
+		Labels:      map[string]string{"pt": "Funções", "es": "Roles"},
 		Path:        "/system/roles",
 		Icon:        "shield",
 		Order:       10,
@@ -29,4 +36,15 @@ var MenuDefinitions = []domain.MenuDefinition{
 		Permissions: []string{domain.PermissionRoleRead},
 		Visible:     true,
 	},
+	{
+		ID:          "auth:groups",
+		Label:       "Groups",
+		Labels:      map[string]string{"pt": "Grupos", "es": "Grupos"},
+		Path:        "/system/groups",
+		Icon:        "users",
+		Order:       20,
+		ParentID:    "auth:system",
+		Permissions: []string{domain.PermissionGroupRead},
+		Visible:     true,
+	},
 }
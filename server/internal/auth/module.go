@@ -2,6 +2,9 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	nethttp "net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -11,32 +14,109 @@ import (
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/repositories"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/service"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/dpop"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/features"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/pow"
 )
 
 type AuthModule struct {
-	Service domain.Service
+	Service      domain.Service
+	jwtSecret    string
+	reauthWindow time.Duration
+	dpop         *dpop.Verifier
+	pow          *pow.Guard
 }
 
-func NewModule(pool *pgxpool.Pool, nc *nats.Conn, jwtSecret string) *AuthModule {
-	repo := repositories.NewPgxRepository(pool)
-	svc := service.NewAuthService(repo, nc, jwtSecret)
+func NewModule(pool *pgxpool.Pool, nc *nats.Conn, cfg *platform.Config, mailer platform.Mailer, media platform.MediaStorage, enc *platform.Encryptor, flags *features.Client) *AuthModule {
+	repo := repositories.NewPgxRepository(pool, enc)
+	svc := service.NewAuthService(repo, nc, mailer, media, service.Config{
+		JWTSecret:  cfg.JWTSecret,
+		AppBaseURL: cfg.AppBaseURL,
+		Issuer:     cfg.WebAuthnRPName,
+		Locales:    cfg.SupportedLocales,
 
-	events.RegisterListeners(nc, svc)
+		MenuBadgeTimeout:  cfg.MenuBadgeTimeout,
+		MenuBadgeCacheTTL: cfg.MenuBadgeCacheTTL,
+		MenuCacheTTL:      cfg.MenuCacheTTL,
+		Flags:             flags,
+
+		WebAuthnRPID:      cfg.WebAuthnRPID,
+		WebAuthnRPName:    cfg.WebAuthnRPName,
+		WebAuthnRPOrigins: cfg.WebAuthnRPOrigins,
+	})
+
+	events.RegisterListeners(nc, svc, cfg.PermissionCacheTTL)
+
+	go service.RunRoleGrantSweeper(context.Background(), svc, cfg.RoleGrantSweepInterval)
+	go service.RunUserImportSweeper(context.Background(), svc)
+	go platform.RunReencryption(context.Background(), cfg.ReencryptionInterval, repo)
 
 	// Register Auth Permissions
 	// We use a background context here as this is startup logic
 	go func() {
 		_ = svc.RegisterModulePermissions(context.Background(), "auth", domain.GetAvailablePermissions())
-		_ = svc.RegisterModuleMenus(context.Background(), "auth", MenuDefinitions)
+		_, _ = svc.RegisterModuleMenus(context.Background(), "auth", MenuVersion, MenuDefinitions)
 	}()
 
-	return &AuthModule{Service: svc}
+	return &AuthModule{
+		Service:      svc,
+		jwtSecret:    cfg.JWTSecret,
+		reauthWindow: cfg.ReauthWindow,
+		dpop:         dpop.NewVerifier(cfg.DPoPProofMaxAge),
+		pow:          newPoWGuard(cfg),
+	}
+}
+
+// newPoWGuard signs challenges with a key derived from JWT_SECRET. Five failed attempts from one
+// IP within 15 minutes, matching the magic-link rate limit, add one bit of difficulty.
+func newPoWGuard(cfg *platform.Config) *pow.Guard {
+	secret := sha256.Sum256([]byte("pow:" + cfg.JWTSecret))
+	return pow.New(secret[:], pow.Options{
+		Difficulty:       cfg.PoWDifficulty,
+		MaxDifficulty:    cfg.PoWMaxDifficulty,
+		TTL:              cfg.PoWChallengeTTL,
+		LoadThreshold:    cfg.PoWLoadThreshold,
+		FailureThreshold: 5,
+		FailureWindow:    15 * time.Minute,
+	})
 }
 
 func (m *AuthModule) RegisterRoutes(r *chi.Mux) {
-	http.RegisterHTTPHandlers(r, m.Service)
+	http.RegisterHTTPHandlers(r, m.Service, m.dpop, m.pow)
+}
+
+// AuthMiddleware authenticates requests on protected routes. It shares the module's DPoP replay
+// cache with the token endpoints.
+func (m *AuthModule) AuthMiddleware() func(next nethttp.Handler) nethttp.Handler {
+	return http.AuthMiddleware(m.Service, m.jwtSecret, m.dpop)
+}
+
+// RegisterProtectedRoutes mounts the authenticated routes. requireFlag hides routes of features
+// shipped dark behind their flag.
+func (m *AuthModule) RegisterProtectedRoutes(r chi.Router, requireFlag func(key string) func(nethttp.Handler) nethttp.Handler) {
+	http.RegisterProtectedHTTPHandlers(r, m.Service, m.reauthWindow, requireFlag)
+}
+
+// RequireUser returns a middleware other modules use to refuse service clients on routes that do
+// not check a permission, whose scopes could not be checked.
+func (m *AuthModule) RequireUser() func(next nethttp.Handler) nethttp.Handler {
+	return http.RequireUser
+}
+
+// RequireRecentAuth returns a middleware other modules can use to demand a recent login or
+// re-authentication on sensitive routes.
+func (m *AuthModule) RequireRecentAuth() func(next nethttp.Handler) nethttp.Handler {
+	return http.RequireRecentAuth(m.reauthWindow)
+}
+
+// RequireProofOfWork returns a middleware other modules can use to protect public form endpoints
+// from bots. Clients fetch a challenge from GET /auth/pow/challenge.
+func (m *AuthModule) RequireProofOfWork() func(next nethttp.Handler) nethttp.Handler {
+	return m.pow.Require
 }
 
-func (m *AuthModule) RegisterProtectedRoutes(r chi.Router) {
-	http.RegisterProtectedHTTPHandlers(r, m.Service)
+// RequirePermission returns a middleware other modules can use to guard their routes.
+func (m *AuthModule) RequirePermission(permission string) func(next nethttp.Handler) nethttp.Handler {
+	return http.RequirePermission(m.Service, permission)
 }
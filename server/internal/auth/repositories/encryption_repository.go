@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// reencryptBatchSize bounds how many rows one Reencrypt call locks.
+const reencryptBatchSize = 100
+
+func (r *pgxRepo) Reencrypt(ctx context.Context) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// SKIP LOCKED lets several instances share the work. The prefix only contains letters, digits,
+	// dashes and colons, none of which are LIKE wildcards.
+	query := `
+		SELECT user_id, secret
+		FROM user_totp_factors
+		WHERE secret NOT LIKE $1 || '%'
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+	rows, err := tx.Query(ctx, query, r.enc.CurrentPrefix(), reencryptBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+	}
+	type pending struct {
+		userID uuid.UUID
+		secret string
+	}
+	var batch []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.userID, &p.secret); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+		}
+		batch = append(batch, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+	}
+
+	for _, p := range batch {
+		plaintext, err := r.openTOTPSecret(p.userID, p.secret)
+		if err != nil {
+			return 0, fmt.Errorf("auth repo reencrypt totp secret of %s: %w", p.userID, err)
+		}
+		sealed, err := r.enc.Encrypt(plaintext, totpSecretAAD(p.userID))
+		if err != nil {
+			return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+		}
+		if _, err := tx.Exec(ctx, `UPDATE user_totp_factors SET secret = $2 WHERE user_id = $1`, p.userID, sealed); err != nil {
+			return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("auth repo reencrypt: %w", err)
+	}
+	return len(batch), nil
+}
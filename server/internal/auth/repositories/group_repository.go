@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// foreignKeyViolation is the Postgres SQLSTATE raised when a referenced row does not exist.
+const foreignKeyViolation = "23503"
+
+const groupColumns = `
+	g.id, g.name, g.description, g.created_at, g.updated_at,
+	(SELECT count(*) FROM group_members gm WHERE gm.group_id = g.id)
+`
+
+func (r *pgxRepo) CreateGroup(ctx context.Context, group *domain.Group) error {
+	query := `
+		INSERT INTO groups (id, name, description)
+		VALUES ($1, $2, $3)
+		RETURNING created_at, updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, group.ID, group.Name, group.Description).Scan(&group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return mapGroupError("create group", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) GetGroups(ctx context.Context) ([]domain.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups g ORDER BY g.name`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []domain.Group{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		g := domain.Group{Roles: []domain.Role{}}
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.UpdatedAt, &g.MemberCount); err != nil {
+			return nil, err
+		}
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roleRows, err := r.pool.Query(ctx, `
+		SELECT gr.group_id, ro.id, ro.name
+		FROM group_roles gr
+		JOIN roles ro ON ro.id = gr.role_id
+		ORDER BY ro.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get group roles: %w", err)
+	}
+	defer roleRows.Close()
+
+	for roleRows.Next() {
+		var groupID uuid.UUID
+		var role domain.Role
+		if err := roleRows.Scan(&groupID, &role.ID, &role.Name); err != nil {
+			return nil, err
+		}
+		if i, ok := index[groupID]; ok {
+			groups[i].Roles = append(groups[i].Roles, role)
+		}
+	}
+	return groups, roleRows.Err()
+}
+
+func (r *pgxRepo) GetGroupByID(ctx context.Context, groupID uuid.UUID) (*domain.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups g WHERE g.id = $1`
+	g := domain.Group{Roles: []domain.Role{}}
+	err := r.pool.QueryRow(ctx, query, groupID).Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt, &g.UpdatedAt, &g.MemberCount)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httputil.ErrNotFound
+		}
+		return nil, fmt.Errorf("auth repo get group: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT ro.id, ro.name
+		FROM group_roles gr
+		JOIN roles ro ON ro.id = gr.role_id
+		WHERE gr.group_id = $1
+		ORDER BY ro.name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get group roles: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role domain.Role
+		if err := rows.Scan(&role.ID, &role.Name); err != nil {
+			return nil, err
+		}
+		g.Roles = append(g.Roles, role)
+	}
+	return &g, rows.Err()
+}
+
+func (r *pgxRepo) UpdateGroup(ctx context.Context, group *domain.Group) error {
+	query := `
+		UPDATE groups SET name = $2, description = $3, updated_at = now()
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, group.ID, group.Name, group.Description).Scan(&group.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return httputil.ErrNotFound
+		}
+		return mapGroupError("update group", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM groups WHERE id = $1`, groupID)
+	if err != nil {
+		return fmt.Errorf("auth repo delete group: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgxRepo) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]domain.GroupMember, error) {
+	query := `
+		SELECT u.id, u.email, u.full_name, gm.added_at
+		FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = $1
+		ORDER BY u.full_name
+	`
+	rows, err := r.pool.Query(ctx, query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get group members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []domain.GroupMember{}
+	for rows.Next() {
+		var m domain.GroupMember
+		if err := rows.Scan(&m.UserID, &m.Email, &m.FullName, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (r *pgxRepo) AddGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	query := `INSERT INTO group_members (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.pool.Exec(ctx, query, groupID, userID); err != nil {
+		return mapGroupError("add group member", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM group_members WHERE group_id = $1 AND user_id = $2`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("auth repo remove group member: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgxRepo) AssignRoleToGroup(ctx context.Context, groupID uuid.UUID, roleID int) error {
+	query := `INSERT INTO group_roles (group_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.pool.Exec(ctx, query, groupID, roleID); err != nil {
+		return mapGroupError("assign group role", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) RemoveRoleFromGroup(ctx context.Context, groupID uuid.UUID, roleID int) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM group_roles WHERE group_id = $1 AND role_id = $2`, groupID, roleID)
+	if err != nil {
+		return fmt.Errorf("auth repo remove group role: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+// mapGroupError turns constraint violations into the matching service errors: duplicate
+// names conflict and references to missing groups, users or roles are not found.
+func mapGroupError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return httputil.ErrConflict
+		case foreignKeyViolation:
+			return httputil.ErrNotFound
+		}
+	}
+	return fmt.Errorf("auth repo %s: %w", op, err)
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+func TestMapGroupError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"duplicate name", &pgconn.PgError{Code: uniqueViolation}, httputil.ErrConflict},
+		{"unknown group, user or role", &pgconn.PgError{Code: foreignKeyViolation}, httputil.ErrNotFound},
+	}
+	for _, tc := range cases {
+		if got := mapGroupError("op", tc.err); !errors.Is(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	other := errors.New("connection reset")
+	if got := mapGroupError("add group member", other); !errors.Is(got, other) || got.Error() != "auth repo add group member: connection reset" {
+		t.Errorf("expected other errors to be wrapped, got %v", got)
+	}
+}
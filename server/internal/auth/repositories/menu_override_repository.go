@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+const menuOverrideColumns = `menu_id, hidden, label, icon, order_index, updated_by, updated_at`
+
+func (r *pgxRepo) GetMenuOverrides(ctx context.Context) ([]domain.MenuOverride, error) {
+	query := `SELECT ` + menuOverrideColumns + ` FROM menu_overrides ORDER BY menu_id`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get menu overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []domain.MenuOverride
+	for rows.Next() {
+		o, err := scanMenuOverride(rows)
+		if err != nil {
+			return nil, fmt.Errorf("auth repo get menu overrides: %w", err)
+		}
+		overrides = append(overrides, *o)
+	}
+	return overrides, rows.Err()
+}
+
+func (r *pgxRepo) GetMenuOverride(ctx context.Context, menuID string) (*domain.MenuOverride, error) {
+	query := `SELECT ` + menuOverrideColumns + ` FROM menu_overrides WHERE menu_id = $1`
+	o, err := scanMenuOverride(r.pool.QueryRow(ctx, query, menuID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get menu override: %w", err)
+	}
+	return o, nil
+}
+
+func (r *pgxRepo) UpsertMenuOverride(ctx context.Context, o *domain.MenuOverride) error {
+	query := `
+		INSERT INTO menu_overrides (menu_id, hidden, label, icon, order_index, updated_by, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (menu_id) DO UPDATE SET
+			hidden = EXCLUDED.hidden,
+			label = EXCLUDED.label,
+			icon = EXCLUDED.icon,
+			order_index = EXCLUDED.order_index,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = now()
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, o.MenuID, o.Hidden, o.Label, o.Icon, o.Order, o.UpdatedBy).Scan(&o.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return domain.ErrMenuItemNotFound
+		}
+		return fmt.Errorf("auth repo upsert menu override: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) DeleteMenuOverride(ctx context.Context, menuID string) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM menu_overrides WHERE menu_id = $1`, menuID); err != nil {
+		return fmt.Errorf("auth repo delete menu override: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) DeleteMenuOverrides(ctx context.Context) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `DELETE FROM menu_overrides RETURNING menu_id`)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo delete menu overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("auth repo delete menu overrides: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func scanMenuOverride(row pgx.Row) (*domain.MenuOverride, error) {
+	var o domain.MenuOverride
+	if err := row.Scan(&o.MenuID, &o.Hidden, &o.Label, &o.Icon, &o.Order, &o.UpdatedBy, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
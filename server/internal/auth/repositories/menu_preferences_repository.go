@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+func (r *pgxRepo) GetMenuPreferences(ctx context.Context, userID uuid.UUID) (*domain.MenuPreferences, error) {
+	pins, err := r.queryStrings(ctx, `SELECT menu_id FROM menu_pins WHERE user_id = $1 ORDER BY position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get menu preferences: %w", err)
+	}
+	recent, err := r.queryStrings(ctx, `SELECT menu_id FROM menu_recent_items WHERE user_id = $1 ORDER BY visited_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get menu preferences: %w", err)
+	}
+	return &domain.MenuPreferences{Pins: pins, Recent: recent}, nil
+}
+
+// ReplaceMenuPins makes menuIDs the user's pins, in that order.
+func (r *pgxRepo) ReplaceMenuPins(ctx context.Context, userID uuid.UUID, menuIDs []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("auth repo replace menu pins: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM menu_pins WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("auth repo replace menu pins: %w", err)
+	}
+	query := `
+		INSERT INTO menu_pins (user_id, menu_id, position)
+		SELECT $1, t.menu_id, t.position
+		FROM unnest($2::text[]) WITH ORDINALITY AS t(menu_id, position)
+	`
+	if _, err := tx.Exec(ctx, query, userID, menuIDs); err != nil {
+		return fmt.Errorf("auth repo replace menu pins: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("auth repo replace menu pins: %w", err)
+	}
+	return nil
+}
+
+// RecordMenuVisit marks menuID as the user's latest visit and forgets all but the keep most
+// recent items.
+func (r *pgxRepo) RecordMenuVisit(ctx context.Context, userID uuid.UUID, menuID string, keep int) error {
+	query := `
+		INSERT INTO menu_recent_items (user_id, menu_id, visited_at)
+		VALUES ($1, $2, now())
+		ON CONFLICT (user_id, menu_id) DO UPDATE SET visited_at = EXCLUDED.visited_at
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, menuID); err != nil {
+		return fmt.Errorf("auth repo record menu visit: %w", err)
+	}
+
+	query = `
+		DELETE FROM menu_recent_items
+		WHERE user_id = $1 AND menu_id NOT IN (
+			SELECT menu_id FROM menu_recent_items
+			WHERE user_id = $1
+			ORDER BY visited_at DESC
+			LIMIT $2
+		)
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, keep); err != nil {
+		return fmt.Errorf("auth repo record menu visit: %w", err)
+	}
+	return nil
+}
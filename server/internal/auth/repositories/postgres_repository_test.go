@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestNullableAddrPassesUnknownAddressesAsNull(t *testing.T) {
+	if got := nullableAddr(netip.Addr{}); got != nil {
+		t.Fatalf("expected an unknown address to be passed as NULL, got %v", *got)
+	}
+
+	ip := netip.MustParseAddr("203.0.113.7")
+	if got := nullableAddr(ip); got == nil || *got != ip {
+		t.Fatalf("expected %s to be passed through, got %v", ip, got)
+	}
+}
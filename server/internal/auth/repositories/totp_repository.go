@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+func (r *pgxRepo) GetTOTPFactor(ctx context.Context, userID uuid.UUID) (*domain.TOTPFactor, error) {
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step
+		FROM user_totp_factors
+		WHERE user_id = $1
+	`
+	var f domain.TOTPFactor
+	var sealed string
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&f.UserID, &sealed, &f.ConfirmedAt, &f.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httputil.ErrNotFound
+		}
+		return nil, fmt.Errorf("auth repo get totp factor: %w", err)
+	}
+	secret, err := r.openTOTPSecret(f.UserID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get totp factor: %w", err)
+	}
+	f.Secret = string(secret)
+	return &f, nil
+}
+
+func (r *pgxRepo) SaveTOTPFactor(ctx context.Context, factor *domain.TOTPFactor) error {
+	// A confirmed factor is never overwritten; it has to be disabled first.
+	query := `
+		INSERT INTO user_totp_factors (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = 0, created_at = now()
+		WHERE user_totp_factors.confirmed_at IS NULL
+	`
+	sealed, err := r.enc.Encrypt([]byte(factor.Secret), totpSecretAAD(factor.UserID))
+	if err != nil {
+		return fmt.Errorf("auth repo save totp factor: %w", err)
+	}
+	cmd, err := r.pool.Exec(ctx, query, factor.UserID, sealed)
+	if err != nil {
+		return fmt.Errorf("auth repo save totp factor: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return domain.ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+func (r *pgxRepo) ConfirmTOTPFactor(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_totp_factors
+		SET confirmed_at = now(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`
+	cmd, err := r.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("auth repo confirm totp factor: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgxRepo) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE user_totp_factors
+		SET last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+	`
+	cmd, err := r.pool.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("auth repo use totp step: %w", err)
+	}
+	return cmd.RowsAffected() == 1, nil
+}
+
+func (r *pgxRepo) DeleteTOTPFactor(ctx context.Context, userID uuid.UUID) error {
+	cmd, err := r.pool.Exec(ctx, `DELETE FROM user_totp_factors WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("auth repo delete totp factor: %w", err)
+	}
+	if cmd.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+// openTOTPSecret decrypts a stored secret. Rows written before encryption at rest was introduced
+// hold plaintext until Reencrypt reaches them, and are returned as they are.
+func (r *pgxRepo) openTOTPSecret(userID uuid.UUID, stored string) ([]byte, error) {
+	if !platform.IsSealed(stored) {
+		return []byte(stored), nil
+	}
+	return r.enc.Decrypt(stored, totpSecretAAD(userID))
+}
+
+func totpSecretAAD(userID uuid.UUID) []byte {
+	return []byte("user_totp_factors.secret:" + userID.String())
+}
+
+func (r *pgxRepo) GetReauthLockedUntil(ctx context.Context, userID uuid.UUID, method string) (*time.Time, error) {
+	query := `SELECT locked_until FROM reauth_lockouts WHERE user_id = $1 AND method = $2`
+	var lockedUntil *time.Time
+	err := r.pool.QueryRow(ctx, query, userID, method).Scan(&lockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("auth repo get reauth lockout: %w", err)
+	}
+	return lockedUntil, nil
+}
+
+// RecordReauthFailure counts a failed attempt. The attempt that reaches maxAttempts locks the
+// factor for lockFor and starts a new count.
+func (r *pgxRepo) RecordReauthFailure(ctx context.Context, userID uuid.UUID, method string, maxAttempts int, lockFor time.Duration) error {
+	query := `
+		INSERT INTO reauth_lockouts AS l (user_id, method, failed_attempts)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (user_id, method) DO UPDATE
+		SET failed_attempts = CASE WHEN l.failed_attempts + 1 >= $3 THEN 0 ELSE l.failed_attempts + 1 END,
+			locked_until = CASE WHEN l.failed_attempts + 1 >= $3 THEN now() + make_interval(secs => $4) ELSE l.locked_until END
+	`
+	if _, err := r.pool.Exec(ctx, query, userID, method, maxAttempts, lockFor.Seconds()); err != nil {
+		return fmt.Errorf("auth repo record reauth failure: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) ResetReauthFailures(ctx context.Context, userID uuid.UUID, method string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM reauth_lockouts WHERE user_id = $1 AND method = $2`, userID, method)
+	if err != nil {
+		return fmt.Errorf("auth repo reset reauth failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform"
+)
+
+// prefixEncryptor seals by prefixing the AAD, so a secret opened for another user fails.
+type prefixEncryptor struct{}
+
+func (prefixEncryptor) Encrypt(plaintext, aad []byte) (string, error) {
+	return "enc:v1:test:" + string(aad) + "|" + string(plaintext), nil
+}
+
+func (prefixEncryptor) Decrypt(sealed string, aad []byte) ([]byte, error) {
+	rest, ok := strings.CutPrefix(sealed, "enc:v1:test:"+string(aad)+"|")
+	if !ok {
+		return nil, platform.ErrDecrypt
+	}
+	return []byte(rest), nil
+}
+
+func (prefixEncryptor) CurrentPrefix() string { return "enc:v1:test:" }
+
+func TestOpenTOTPSecretReadsSealedAndLegacyRows(t *testing.T) {
+	r := &pgxRepo{enc: prefixEncryptor{}}
+	userID := uuid.New()
+
+	sealed, _ := r.enc.Encrypt([]byte("JBSWY3DPEHPK3PXP"), totpSecretAAD(userID))
+	if got, err := r.openTOTPSecret(userID, sealed); err != nil || !bytes.Equal(got, []byte("JBSWY3DPEHPK3PXP")) {
+		t.Fatalf("expected the sealed secret to open, got %q, %v", got, err)
+	}
+
+	if got, err := r.openTOTPSecret(userID, "JBSWY3DPEHPK3PXP"); err != nil || string(got) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("expected a legacy plaintext secret to be returned as is, got %q, %v", got, err)
+	}
+
+	if _, err := r.openTOTPSecret(uuid.New(), sealed); !errors.Is(err, platform.ErrDecrypt) {
+		t.Fatalf("expected a secret sealed for another user to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+func (r *pgxRepo) CreateInvitedUser(ctx context.Context, user *domain.User, grants []domain.RoleGrant, invitation *domain.UserInvitation) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("auth repo create invited user: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// activated_at stays NULL until the invitation is accepted.
+	query := `INSERT INTO users (id, email, password_hash, full_name, activated_at) VALUES ($1, $2, '', $3, NULL)`
+	if _, err := tx.Exec(ctx, query, user.ID, user.Email, user.FullName); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrEmailTaken
+		}
+		return fmt.Errorf("auth repo create invited user: %w", err)
+	}
+
+	for i := range grants {
+		if err := assignRole(ctx, tx, &grants[i]); err != nil {
+			return fmt.Errorf("auth repo create invited user: %w", err)
+		}
+	}
+
+	query = `INSERT INTO user_invitations (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, query, invitation.ID, invitation.UserID, invitation.TokenHash, invitation.ExpiresAt); err != nil {
+		return fmt.Errorf("auth repo create invited user: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("auth repo create invited user: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) AcceptInvitation(ctx context.Context, tokenHash, passwordHash string) (*domain.UserInvitation, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo accept invitation: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := `
+		UPDATE user_invitations
+		SET accepted_at = now()
+		WHERE token_hash = $1 AND accepted_at IS NULL AND expires_at > now()
+		RETURNING id, user_id, token_hash, expires_at, accepted_at
+	`
+	var inv domain.UserInvitation
+	err = tx.QueryRow(ctx, query, tokenHash).Scan(&inv.ID, &inv.UserID, &inv.TokenHash, &inv.ExpiresAt, &inv.AcceptedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httputil.ErrNotFound
+		}
+		return nil, fmt.Errorf("auth repo accept invitation: %w", err)
+	}
+
+	query = `
+		UPDATE users
+		SET password_hash = $2, activated_at = COALESCE(activated_at, now()), updated_at = now()
+		WHERE id = $1
+	`
+	tag, err := tx.Exec(ctx, query, inv.UserID, passwordHash)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo accept invitation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, httputil.ErrNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("auth repo accept invitation: %w", err)
+	}
+	return &inv, nil
+}
+
+func (r *pgxRepo) CreateUserImportJob(ctx context.Context, job *domain.UserImportJob) error {
+	results, err := json.Marshal(job.Rows)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO user_import_jobs (id, status, created_by, total_rows, succeeded_rows, failed_rows, results)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+	err = r.pool.QueryRow(ctx, query, job.ID, job.Status, job.CreatedBy, job.Total, job.Succeeded, job.Failed, results).
+		Scan(&job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("auth repo create user import job: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) UpdateUserImportJob(ctx context.Context, job *domain.UserImportJob) error {
+	results, err := json.Marshal(job.Rows)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE user_import_jobs
+		SET status = $2, succeeded_rows = $3, failed_rows = $4, results = $5, finished_at = $6, updated_at = now()
+		WHERE id = $1
+	`
+	tag, err := r.pool.Exec(ctx, query, job.ID, job.Status, job.Succeeded, job.Failed, results, job.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("auth repo update user import job: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+func (r *pgxRepo) GetUserImportJob(ctx context.Context, jobID uuid.UUID) (*domain.UserImportJob, error) {
+	query := `
+		SELECT id, status, created_by, total_rows, succeeded_rows, failed_rows, results, created_at, finished_at
+		FROM user_import_jobs
+		WHERE id = $1
+	`
+	var job domain.UserImportJob
+	var results []byte
+	err := r.pool.QueryRow(ctx, query, jobID).Scan(&job.ID, &job.Status, &job.CreatedBy, &job.Total,
+		&job.Succeeded, &job.Failed, &results, &job.CreatedAt, &job.FinishedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httputil.ErrNotFound
+		}
+		return nil, fmt.Errorf("auth repo get user import job: %w", err)
+	}
+	if err := json.Unmarshal(results, &job.Rows); err != nil {
+		return nil, fmt.Errorf("auth repo get user import job: %w", err)
+	}
+	return &job, nil
+}
+
+func (r *pgxRepo) FailStaleUserImportJobs(ctx context.Context, staleBefore time.Time) (int, error) {
+	query := `
+		UPDATE user_import_jobs
+		SET status = $1, finished_at = now(), updated_at = now()
+		WHERE finished_at IS NULL AND updated_at < $2
+	`
+	tag, err := r.pool.Exec(ctx, query, domain.UserImportFailed, staleBefore)
+	if err != nil {
+		return 0, fmt.Errorf("auth repo fail stale user import jobs: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (r *pgxRepo) GetUsersForExport(ctx context.Context) ([]domain.UserExportRow, error) {
+	query := `
+		SELECT u.id, u.email, u.full_name, u.created_at, u.activated_at,
+			COALESCE(array_agg(ro.name ORDER BY ro.name) FILTER (WHERE ro.name IS NOT NULL), '{}')
+		FROM users u
+		LEFT JOIN user_roles ur ON ur.user_id = u.id AND ur.valid_from <= now()
+			AND (ur.valid_until IS NULL OR ur.valid_until > now())
+		LEFT JOIN roles ro ON ro.id = ur.role_id
+		WHERE u.archived_at IS NULL
+		GROUP BY u.id
+		ORDER BY u.email
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get users for export: %w", err)
+	}
+	defer rows.Close()
+
+	users := []domain.UserExportRow{}
+	for rows.Next() {
+		var u domain.UserExportRow
+		if err := rows.Scan(&u.ID, &u.Email, &u.FullName, &u.CreatedAt, &u.ActivatedAt, &u.Roles); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("auth repo get users for export: %w", err)
+	}
+	return users, nil
+}
+
+func (r *pgxRepo) GetRegisteredEmails(ctx context.Context, emails []string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT DISTINCT lower(email) FROM users WHERE lower(email) = ANY($1)`, emails)
+	if err != nil {
+		return nil, fmt.Errorf("auth repo get registered emails: %w", err)
+	}
+	defer rows.Close()
+
+	var registered []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("auth repo get registered emails: %w", err)
+		}
+		registered = append(registered, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("auth repo get registered emails: %w", err)
+	}
+	return registered, nil
+}
@@ -6,28 +6,86 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/netip"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
 	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Config holds the settings the auth service needs from the platform configuration.
+type Config struct {
+	JWTSecret string
+	// AppBaseURL is the client application URL used to build links sent by email.
+	AppBaseURL string
+	// Issuer names the application in authenticator apps.
+	Issuer string
+	// Locales are the supported locales, the default first.
+	Locales []string
+	// MenuBadgeTimeout bounds the wait for badge replies when building a menu; MenuBadgeCacheTTL
+	// is how long answered badges are reused.
+	MenuBadgeTimeout  time.Duration
+	MenuBadgeCacheTTL time.Duration
+	// MenuCacheTTL is how long built menu trees are reused; changes to menus purge them sooner.
+	MenuCacheTTL time.Duration
+	// Flags evaluates the feature flags menu items require. Without it flagged items are hidden.
+	Flags domain.FlagSource
+
+	// WebAuthn relying party settings. Passkeys are disabled when they are invalid.
+	WebAuthnRPID      string
+	WebAuthnRPName    string
+	WebAuthnRPOrigins []string
+}
+
 type authService struct {
-	repo      domain.Repository
-	nc        *nats.Conn
-	jwtSecret string
+	repo       domain.Repository
+	nc         *nats.Conn
+	mailer     domain.Mailer
+	media      domain.MediaStorage
+	webAuthn   *webauthn.WebAuthn
+	jwtSecret  string
+	appBaseURL string
+	issuer     string
+	locales    locales
+	badges     *badgeResolver
+	menus      *menuCache
+	flags      domain.FlagSource
 }
 
-func NewAuthService(repository domain.Repository, nc *nats.Conn, jwtSecret string) domain.Service {
+func NewAuthService(repository domain.Repository, nc *nats.Conn, mailer domain.Mailer, media domain.MediaStorage, cfg Config) domain.Service {
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPName,
+		RPOrigins:     cfg.WebAuthnRPOrigins,
+	})
+	if err != nil {
+		slog.Error("passkeys disabled, invalid WebAuthn configuration", "error", err)
+		wa = nil
+	}
+
 	return &authService{
-		repo:      repository,
-		nc:        nc,
-		jwtSecret: jwtSecret,
+		repo:       repository,
+		nc:         nc,
+		mailer:     mailer,
+		media:      media,
+		webAuthn:   wa,
+		jwtSecret:  cfg.JWTSecret,
+		appBaseURL: strings.TrimRight(cfg.AppBaseURL, "/"),
+		issuer:     cfg.Issuer,
+		locales:    newLocales(cfg.Locales),
+		badges:     newBadgeResolver(natsBadgeRequester(nc), cfg.MenuBadgeTimeout, cfg.MenuBadgeCacheTTL),
+		menus:      newMenuCache(cfg.MenuCacheTTL),
+		flags:      cfg.Flags,
 	}
 }
 
@@ -40,45 +98,49 @@ func (a authService) Login(ctx context.Context, email, password string) (domain.
 	u, err := a.repo.GetUserByEmail(ctx, email)
 	if err != nil {
 		if errors.Is(err, httputil.ErrNotFound) {
+			a.publishLoginFailed(ctx, nil, email, events.LoginMethodPassword, "unknown_user")
 			return domain.AuthTokens{}, httputil.ErrUnauthorized // Don't reveal user existence
 		}
 		return domain.AuthTokens{}, err
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		a.publishLoginFailed(ctx, &u.ID, email, events.LoginMethodPassword, "invalid_password")
 		return domain.AuthTokens{}, httputil.ErrUnauthorized
 	}
 
-	sessionID := uuid.New()
-	now := time.Now()
-	accessExpires := now.Add(accessTokenTTL)
-	refreshExpires := now.Add(refreshTokenTTL)
-
-	accessToken, err := a.signToken(u.ID, sessionID, domain.TokenTypeAccess, accessExpires)
-	if err != nil {
+	// Users with passkeys must complete an assertion before a session is opened.
+	if err := a.beginSecondFactor(ctx, u, domain.PasskeyCeremonySecondFactor); err != nil {
 		return domain.AuthTokens{}, err
 	}
-	refreshToken, err := a.signToken(u.ID, sessionID, domain.TokenTypeRefresh, refreshExpires)
+
+	return a.createSession(ctx, u.ID, events.LoginMethodPassword)
+}
+
+// createSession opens a new refresh session for the user and issues its first token pair.
+// Every login method funnels through here so sessions behave identically. When the request
+// carried a DPoP proof the session, and every token issued for it, is bound to the proof's key.
+func (a authService) createSession(ctx context.Context, userID uuid.UUID, method string) (domain.AuthTokens, error) {
+	session := &domain.Session{
+		ID:       uuid.New(),
+		UserID:   userID,
+		AuthTime: time.Now(),
+		DPoPJKT:  domain.DPoPThumbprintFromContext(ctx),
+	}
+
+	tokens, err := a.signTokenPair(session)
 	if err != nil {
 		return domain.AuthTokens{}, err
 	}
 
-	session := &domain.Session{
-		ID:               sessionID,
-		UserID:           u.ID,
-		RefreshTokenHash: hashToken(refreshToken),
-		ExpiresAt:        refreshExpires,
-	}
+	session.RefreshTokenHash = hashToken(tokens.RefreshToken)
+	session.ExpiresAt = tokens.RefreshExpiresAt
 	if err := a.repo.CreateSession(ctx, session); err != nil {
 		return domain.AuthTokens{}, err
 	}
+	a.publishLoginSucceeded(ctx, userID, session.ID, method)
 
-	return domain.AuthTokens{
-		AccessToken:      accessToken,
-		RefreshToken:     refreshToken,
-		AccessExpiresAt:  accessExpires,
-		RefreshExpiresAt: refreshExpires,
-	}, nil
+	return tokens, nil
 }
 
 func (a authService) RefreshTokens(ctx context.Context, refreshToken string) (domain.AuthTokens, error) {
@@ -89,6 +151,11 @@ func (a authService) RefreshTokens(ctx context.Context, refreshToken string) (do
 	if err != nil || !token.Valid || claims.TokenType != domain.TokenTypeRefresh {
 		return domain.AuthTokens{}, httputil.ErrUnauthorized
 	}
+	// A bound refresh token is useless without the private key, so a leaked token cannot be
+	// replayed from another client.
+	if claims.BoundKey() != domain.DPoPThumbprintFromContext(ctx) {
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
 
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
@@ -111,37 +178,59 @@ func (a authService) RefreshTokens(ctx context.Context, refreshToken string) (do
 		return domain.AuthTokens{}, httputil.ErrUnauthorized
 	}
 
-	if !hashMatches(refreshToken, session.RefreshTokenHash) {
+	if !hashMatches(refreshToken, session.RefreshTokenHash) || session.DPoPJKT != claims.BoundKey() {
 		return domain.AuthTokens{}, httputil.ErrUnauthorized
 	}
 
+	return a.rotateSessionTokens(ctx, session)
+}
+
+// rotateSessionTokens issues a new token pair for an existing session, carrying over its auth_time
+// and DPoP binding.
+func (a authService) rotateSessionTokens(ctx context.Context, session *domain.Session) (domain.AuthTokens, error) {
+	tokens, err := a.signTokenPair(session)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	if err := a.repo.UpdateSessionRefresh(ctx, session.ID, hashToken(tokens.RefreshToken), tokens.RefreshExpiresAt); err != nil {
+		return domain.AuthTokens{}, err
+	}
+	return tokens, nil
+}
+
+func (a authService) signTokenPair(session *domain.Session) (domain.AuthTokens, error) {
 	now := time.Now()
 	accessExpires := now.Add(accessTokenTTL)
 	refreshExpires := now.Add(refreshTokenTTL)
 
-	newAccessToken, err := a.signToken(userID, sessionID, domain.TokenTypeAccess, accessExpires)
+	accessToken, err := a.signToken(session, domain.TokenTypeAccess, accessExpires)
 	if err != nil {
 		return domain.AuthTokens{}, err
 	}
-	newRefreshToken, err := a.signToken(userID, sessionID, domain.TokenTypeRefresh, refreshExpires)
+	refreshToken, err := a.signToken(session, domain.TokenTypeRefresh, refreshExpires)
 	if err != nil {
 		return domain.AuthTokens{}, err
 	}
 
-	if err := a.repo.UpdateSessionRefresh(ctx, sessionID, hashToken(newRefreshToken), refreshExpires); err != nil {
-		return domain.AuthTokens{}, err
+	tokenType := domain.TokenSchemeBearer
+	if session.DPoPJKT != "" {
+		tokenType = domain.TokenSchemeDPoP
 	}
-
 	return domain.AuthTokens{
-		AccessToken:      newAccessToken,
-		RefreshToken:     newRefreshToken,
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        tokenType,
 		AccessExpiresAt:  accessExpires,
 		RefreshExpiresAt: refreshExpires,
 	}, nil
 }
 
 func (a authService) GetMe(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
-	return a.repo.GetUserByID(ctx, userID)
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return a.withAvatarURL(u), nil
 }
 
 func (a authService) Register(ctx context.Context, user domain.User) error {
@@ -154,7 +243,18 @@ func (a authService) Register(ctx context.Context, user domain.User) error {
 	}
 
 	user.PasswordHash = string(hashedPassword)
-	return a.repo.CreateUser(ctx, &user)
+	if err := a.repo.CreateUser(ctx, &user); err != nil {
+		return err
+	}
+
+	event := events.AuthUserRegisteredData{
+		Trail:    events.NewTrail(ctx, userTarget(user.ID), nil, map[string]string{"email": user.Email, "full_name": user.FullName}),
+		UserID:   user.ID,
+		Email:    user.Email,
+		FullName: user.FullName,
+	}
+	a.publish(events.AuthUserRegistered, event)
+	return nil
 }
 
 func (a authService) RegisterModulePermissions(ctx context.Context, module string, permissions []string) error {
@@ -168,37 +268,103 @@ func (a authService) RegisterModulePermissions(ctx context.Context, module strin
 	return a.repo.UpsertPermissions(ctx, perms)
 }
 
-func (a authService) RegisterModuleMenus(ctx context.Context, domainName string, defs []domain.MenuDefinition) error {
-	for i := range defs {
-		defs[i].Domain = domainName
-	}
-	err := a.repo.UpsertMenuDefinitions(ctx, defs)
+func (a authService) CreateRole(ctx context.Context, name string) (*domain.Role, error) {
+	role, err := a.repo.CreateRole(ctx, name)
 	if err != nil {
-		slog.Error("failed to register module menus", "domain", domainName, "error", err)
-		return err
+		return nil, err
 	}
-	slog.Info("module menus registered", "domain", domainName, "count", len(defs))
-	return nil
-}
 
-func (a authService) CreateRole(ctx context.Context, name string) (*domain.Role, error) {
-	return a.repo.CreateRole(ctx, name)
+	event := events.AuthRoleCreatedData{
+		Trail:  events.NewTrail(ctx, roleTarget(role.ID), nil, role),
+		RoleID: role.ID,
+		Name:   role.Name,
+	}
+	a.publish(events.AuthRoleCreated, event)
+	return role, nil
 }
 
 func (a authService) GetRoles(ctx context.Context) ([]domain.Role, error) {
 	return a.repo.GetRoles(ctx)
 }
 
-func (a authService) AssignRole(ctx context.Context, userID uuid.UUID, roleID int) error {
-	return a.repo.AssignRoleToUser(ctx, userID, roleID)
+// SetRoleAllowedCIDRs replaces the networks a role is usable from. Bare addresses are treated as
+// single-host networks and an empty list lifts the restriction.
+func (a authService) SetRoleAllowedCIDRs(ctx context.Context, roleID int, cidrs []string) (*domain.Role, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		p, err := netip.ParsePrefix(c)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(c)
+			if ipErr != nil {
+				return nil, fmt.Errorf("%w: %q", domain.ErrInvalidCIDR, c)
+			}
+			p = netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+
+	role, err := a.findRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	before := *role
+
+	if err := a.repo.UpdateRoleAllowedCIDRs(ctx, roleID, prefixes); err != nil {
+		return nil, err
+	}
+	role.AllowedCIDRs = prefixes
+
+	event := events.AuthRoleUpdatedData{
+		Trail:  events.NewTrail(ctx, roleTarget(roleID), before, role),
+		RoleID: roleID,
+		Fields: []string{"allowed_cidrs"},
+	}
+	a.publish(events.AuthRoleUpdated, event)
+	return role, nil
 }
 
 func (a authService) AddPermissionToRole(ctx context.Context, roleID int, permissionID string) error {
-	return a.repo.AddPermissionToRole(ctx, roleID, permissionID)
+	if err := a.repo.AddPermissionToRole(ctx, roleID, permissionID); err != nil {
+		return err
+	}
+
+	event := events.AuthRolePermissionGrantedData{
+		Trail:        events.NewTrail(ctx, roleTarget(roleID), nil, map[string]string{"permission_id": permissionID}),
+		RoleID:       roleID,
+		PermissionID: permissionID,
+	}
+	a.publish(events.AuthRolePermissionGranted, event)
+	return nil
 }
 
-func (a authService) GetMyMenu(ctx context.Context, userID uuid.UUID) ([]domain.MenuNode, error) {
-	perms, err := a.repo.GetUserPermissions(ctx, userID)
+func (a authService) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return a.repo.GetUserPermissions(ctx, userID, domain.ClientIPFromContext(ctx))
+}
+
+func (a authService) GetUserRoleNames(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return a.repo.GetUserRoleNames(ctx, userID, domain.ClientIPFromContext(ctx))
+}
+
+func (a authService) GetMyPermissions(ctx context.Context, userID uuid.UUID) (*domain.PermissionSet, error) {
+	perms, err := a.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]string, 0, len(perms))
+	seen := make(map[string]bool, len(perms))
+	for _, p := range perms {
+		if !seen[p] {
+			seen[p] = true
+			sorted = append(sorted, p)
+		}
+	}
+	sort.Strings(sorted)
+	return &domain.PermissionSet{Permissions: sorted, Version: versionHash(sorted)}, nil
+}
+
+func (a authService) GetMyMenu(ctx context.Context, userID uuid.UUID) (domain.MenuTrees, error) {
+	perms, err := a.GetUserPermissions(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -208,25 +374,64 @@ func (a authService) GetMyMenu(ctx context.Context, userID uuid.UUID) ([]domain.
 		permMap[p] = true
 	}
 
-	defs, err := a.repo.GetMenuDefinitions(ctx)
+	defs, generation, err := a.menus.definitions(ctx, a.loadMenuDefinitions)
 	if err != nil {
 		return nil, err
 	}
+	locale := a.menuLocale(ctx, userID)
+	flagEnabled := a.menuFlags(ctx, userID)
+	enabled := enabledMenuFlags(defs, flagEnabled)
+
+	key := menuTreeKey(permMap, locale.String(), enabled)
+	trees, ok := a.menus.get(key)
+	if !ok {
+		trees = buildMenuTrees(localizeMenus(defs, locale), permMap, func(flag string) bool { return enabled[flag] })
+		a.menus.set(generation, key, trees)
+	}
+	prefs := &domain.MenuPreferences{}
+	if flagEnabled != nil && flagEnabled(domain.FlagMenuPreferences) {
+		if prefs, err = a.repo.GetMenuPreferences(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	trees = cloneMenuTrees(trees)
+	addMenuSections(trees, prefs)
+	a.badges.attach(ctx, userID, defs, trees)
+	return trees, nil
+}
+
+func (a authService) InvalidateMenuCache() {
+	a.menus.purge()
+}
 
-	return buildMenuTree(defs, permMap), nil
+// loadMenuDefinitions returns the registered definitions with the admin overrides applied.
+func (a authService) loadMenuDefinitions(ctx context.Context) ([]domain.MenuDefinition, error) {
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := a.repo.GetMenuOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return applyMenuOverrides(defs, overrides), nil
 }
 
-func (a authService) signToken(userID uuid.UUID, sessionID uuid.UUID, tokenType domain.TokenType, expiresAt time.Time) (string, error) {
+func (a authService) signToken(session *domain.Session, tokenType domain.TokenType, expiresAt time.Time) (string, error) {
 	claims := domain.UserClaims{
-		UserID:    userID.String(),
-		SessionID: sessionID.String(),
+		UserID:    session.UserID.String(),
+		SessionID: session.ID.String(),
 		TokenType: tokenType,
+		AuthTime:  jwt.NewNumericDate(session.AuthTime),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Subject:   userID.String(),
+			Subject:   session.UserID.String(),
 		},
 	}
+	if session.DPoPJKT != "" {
+		claims.Confirmation = &domain.Confirmation{JKT: session.DPoPJKT}
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(a.jwtSecret))
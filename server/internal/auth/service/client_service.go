@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const clientTokenTTL = time.Hour
+
+func (a authService) CreateServiceClient(ctx context.Context, name string, scopes []string) (*domain.ServiceClient, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", httputil.ErrBadRequest
+	}
+
+	scopes = normalizeScopes(scopes)
+	if len(scopes) > 0 {
+		existing, err := a.repo.GetExistingPermissionIDs(ctx, scopes)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(existing) != len(scopes) {
+			return nil, "", domain.ErrInvalidScope
+		}
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &domain.ServiceClient{
+		ID:         uuid.New(),
+		Name:       name,
+		SecretHash: string(secretHash),
+		Scopes:     scopes,
+	}
+	if err := a.repo.CreateServiceClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	event := events.AuthClientCreatedData{
+		Trail:    events.NewTrail(ctx, clientTarget(client.ID), nil, map[string]any{"name": name, "scopes": scopes}),
+		ClientID: client.ID,
+		Name:     name,
+		Scopes:   scopes,
+	}
+	a.publish(events.AuthClientCreated, event)
+
+	// The plaintext secret is only ever returned here.
+	return client, secret, nil
+}
+
+func (a authService) GetServiceClients(ctx context.Context) ([]domain.ServiceClient, error) {
+	return a.repo.GetServiceClients(ctx)
+}
+
+func (a authService) RevokeServiceClient(ctx context.Context, clientID uuid.UUID) error {
+	if err := a.repo.RevokeServiceClient(ctx, clientID); err != nil {
+		return err
+	}
+
+	event := events.AuthClientRevokedData{
+		Trail:    events.NewTrail(ctx, clientTarget(clientID), nil, nil),
+		ClientID: clientID,
+	}
+	a.publish(events.AuthClientRevoked, event)
+	return nil
+}
+
+func (a authService) IssueClientToken(ctx context.Context, clientID uuid.UUID, clientSecret string, scopes []string) (domain.ClientToken, error) {
+	client, err := a.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return domain.ClientToken{}, err
+	}
+
+	// Without an explicit scope request the client receives everything it was registered with.
+	granted := client.Scopes
+	if requested := normalizeScopes(scopes); len(requested) > 0 {
+		for _, s := range requested {
+			if !slices.Contains(client.Scopes, s) {
+				return domain.ClientToken{}, domain.ErrInvalidScope
+			}
+		}
+		granted = requested
+	}
+
+	expiresAt := time.Now().Add(clientTokenTTL)
+	claims := domain.UserClaims{
+		TokenType: domain.TokenTypeAccess,
+		Principal: domain.PrincipalService,
+		ClientID:  client.ID.String(),
+		Scopes:    granted,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   client.ID.String(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(a.jwtSecret))
+	if err != nil {
+		return domain.ClientToken{}, err
+	}
+
+	return domain.ClientToken{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		Scopes:      granted,
+	}, nil
+}
+
+func (a authService) IntrospectToken(ctx context.Context, clientID uuid.UUID, clientSecret string, token string) (domain.TokenIntrospection, error) {
+	// RFC 7662 requires the caller itself to be authenticated.
+	if _, err := a.authenticateClient(ctx, clientID, clientSecret); err != nil {
+		return domain.TokenIntrospection{}, err
+	}
+
+	inactive := domain.TokenIntrospection{Active: false}
+
+	claims := &domain.UserClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		return []byte(a.jwtSecret), nil
+	})
+	if err != nil || !parsed.Valid || claims.TokenType != domain.TokenTypeAccess {
+		return inactive, nil
+	}
+
+	if claims.IsService() {
+		if err := a.CheckServiceClient(ctx, claims.ClientID); err != nil {
+			return inactive, nil
+		}
+	}
+
+	result := domain.TokenIntrospection{
+		Active:    true,
+		Scope:     strings.Join(claims.Scopes, " "),
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		TokenType: domain.TokenSchemeBearer,
+	}
+	if jkt := claims.BoundKey(); jkt != "" {
+		result.TokenType = domain.TokenSchemeDPoP
+		result.Confirmation = &domain.Confirmation{JKT: jkt}
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return result, nil
+}
+
+// CheckServiceClient is called for every request made with a client token, so revoking a client
+// takes effect immediately rather than when its tokens expire.
+func (a authService) CheckServiceClient(ctx context.Context, clientID string) error {
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return httputil.ErrUnauthorized
+	}
+	client, err := a.repo.GetServiceClientByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return httputil.ErrUnauthorized
+		}
+		return err
+	}
+	if client.RevokedAt != nil {
+		return httputil.ErrUnauthorized
+	}
+	return nil
+}
+
+func (a authService) authenticateClient(ctx context.Context, clientID uuid.UUID, clientSecret string) (*domain.ServiceClient, error) {
+	client, err := a.repo.GetServiceClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return nil, httputil.ErrUnauthorized
+		}
+		return nil, err
+	}
+	if client.RevokedAt != nil {
+		return nil, httputil.ErrUnauthorized
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(clientSecret)); err != nil {
+		return nil, httputil.ErrUnauthorized
+	}
+	return client, nil
+}
+
+func normalizeScopes(scopes []string) []string {
+	var out []string
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if s != "" && !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
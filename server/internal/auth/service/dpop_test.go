@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// sessionRepo records the session opened by a login so it can be refreshed afterwards.
+type sessionRepo struct {
+	stepUpRepo
+}
+
+func (r *sessionRepo) CreateSession(_ context.Context, s *domain.Session) error {
+	r.session = *s
+	return nil
+}
+
+func TestDPoPBindsSessionTokens(t *testing.T) {
+	repo := &sessionRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"}).(*authService)
+	bound := domain.WithDPoPThumbprint(context.Background(), "jkt-1")
+
+	tokens, err := svc.createSession(bound, uuid.New(), events.LoginMethodPassword)
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if tokens.TokenType != domain.TokenSchemeDPoP || repo.session.DPoPJKT != "jkt-1" {
+		t.Fatalf("expected a DPoP-bound session, got %q / %q", tokens.TokenType, repo.session.DPoPJKT)
+	}
+
+	claims := &domain.UserClaims{}
+	if _, err := jwt.ParseWithClaims(tokens.AccessToken, claims, func(*jwt.Token) (any, error) {
+		return []byte("test-secret"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if claims.BoundKey() != "jkt-1" {
+		t.Fatalf("expected cnf.jkt in the access token, got %#v", claims.Confirmation)
+	}
+
+	if _, err := svc.RefreshTokens(context.Background(), tokens.RefreshToken); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected refresh without a proof to be rejected, got %v", err)
+	}
+	other := domain.WithDPoPThumbprint(context.Background(), "jkt-2")
+	if _, err := svc.RefreshTokens(other, tokens.RefreshToken); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected refresh with another key to be rejected, got %v", err)
+	}
+
+	refreshed, err := svc.RefreshTokens(bound, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if refreshed.TokenType != domain.TokenSchemeDPoP {
+		t.Fatalf("expected refreshed tokens to stay bound, got %q", refreshed.TokenType)
+	}
+}
+
+func TestBearerSessionIgnoresDPoP(t *testing.T) {
+	repo := &sessionRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"}).(*authService)
+
+	tokens, err := svc.createSession(context.Background(), uuid.New(), events.LoginMethodPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.TokenType != domain.TokenSchemeBearer {
+		t.Fatalf("expected bearer tokens, got %q", tokens.TokenType)
+	}
+	// A bearer session cannot be upgraded by presenting a proof on refresh.
+	bound := domain.WithDPoPThumbprint(context.Background(), "jkt-1")
+	if _, err := svc.RefreshTokens(bound, tokens.RefreshToken); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected refresh with an unexpected proof to be rejected, got %v", err)
+	}
+}
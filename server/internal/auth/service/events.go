@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// publish emits an auth event. It is a no-op without a NATS connection so the service can run
+// standalone, e.g. in tests. Events are published after the change they describe is committed,
+// so failures are logged rather than returned: the caller's request has already succeeded.
+func (a authService) publish(subject string, payload any) {
+	if a.nc == nil {
+		return
+	}
+	eventBytes, err := json.Marshal(payload)
+	if err == nil {
+		err = a.nc.Publish(subject, eventBytes)
+	}
+	if err != nil {
+		slog.Error("failed to publish auth event", "subject", subject, "error", err)
+	}
+}
+
+func (a authService) publishLoginSucceeded(ctx context.Context, userID, sessionID uuid.UUID, method string) {
+	event := events.AuthLoginSucceededData{
+		Trail:     events.NewTrail(ctx, userTarget(userID), nil, map[string]string{"method": method}),
+		UserID:    userID,
+		SessionID: sessionID,
+		Method:    method,
+	}
+	a.publish(events.AuthLoginSucceeded, event)
+}
+
+// publishLoginFailed reports a rejected login attempt. Publishing failures are logged only so
+// they never change the response the caller sees.
+func (a authService) publishLoginFailed(ctx context.Context, userID *uuid.UUID, email, method, reason string) {
+	target := events.Target{Type: "auth.user"}
+	if userID != nil {
+		target = userTarget(*userID)
+	}
+	event := events.AuthLoginFailedData{
+		Trail:  events.NewTrail(ctx, target, nil, map[string]string{"method": method, "reason": reason, "email": email}),
+		UserID: userID,
+		Email:  email,
+		Method: method,
+		Reason: reason,
+	}
+	a.publish(events.AuthLoginFailed, event)
+}
+
+func userTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "auth.user", ID: id.String()}
+}
+
+func roleTarget(id int) events.Target {
+	return events.Target{Type: "auth.role", ID: strconv.Itoa(id)}
+}
+
+func clientTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "auth.client", ID: id.String()}
+}
+
+func elevationTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "auth.elevation", ID: id.String()}
+}
+
+func groupTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "auth.group", ID: id.String()}
+}
+
+func importTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "auth.user_import", ID: id.String()}
+}
+
+func menuTarget(id string) events.Target {
+	return events.Target{Type: "auth.menu", ID: id}
+}
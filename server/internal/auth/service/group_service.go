@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+func (a authService) CreateGroup(ctx context.Context, name, description string) (*domain.Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, httputil.ErrBadRequest
+	}
+
+	group := &domain.Group{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		Roles:       []domain.Role{},
+	}
+	if err := a.repo.CreateGroup(ctx, group); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthGroupCreatedData{
+		Trail:   events.NewTrail(ctx, groupTarget(group.ID), nil, group),
+		GroupID: group.ID,
+		Name:    group.Name,
+	}
+	a.publish(events.AuthGroupCreated, event)
+	return group, nil
+}
+
+func (a authService) GetGroups(ctx context.Context) ([]domain.Group, error) {
+	return a.repo.GetGroups(ctx)
+}
+
+func (a authService) GetGroup(ctx context.Context, groupID uuid.UUID) (*domain.Group, error) {
+	return a.repo.GetGroupByID(ctx, groupID)
+}
+
+func (a authService) UpdateGroup(ctx context.Context, groupID uuid.UUID, name, description string) (*domain.Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, httputil.ErrBadRequest
+	}
+
+	before, err := a.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	after := *before
+	after.Name = name
+	after.Description = strings.TrimSpace(description)
+	if err := a.repo.UpdateGroup(ctx, &after); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthGroupUpdatedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), before, after),
+		GroupID: groupID,
+		Name:    after.Name,
+	}
+	a.publish(events.AuthGroupUpdated, event)
+	return &after, nil
+}
+
+func (a authService) DeleteGroup(ctx context.Context, groupID uuid.UUID) error {
+	before, err := a.repo.GetGroupByID(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.DeleteGroup(ctx, groupID); err != nil {
+		return err
+	}
+
+	event := events.AuthGroupDeletedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), before, nil),
+		GroupID: groupID,
+		Name:    before.Name,
+	}
+	a.publish(events.AuthGroupDeleted, event)
+	return nil
+}
+
+func (a authService) GetGroupMembers(ctx context.Context, groupID uuid.UUID) ([]domain.GroupMember, error) {
+	if _, err := a.repo.GetGroupByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+	return a.repo.GetGroupMembers(ctx, groupID)
+}
+
+func (a authService) AddGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	if err := a.repo.AddGroupMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+
+	event := events.AuthGroupMemberAddedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), nil, map[string]string{"user_id": userID.String()}),
+		GroupID: groupID,
+		UserID:  userID,
+	}
+	a.publish(events.AuthGroupMemberAdded, event)
+	return nil
+}
+
+func (a authService) RemoveGroupMember(ctx context.Context, groupID, userID uuid.UUID) error {
+	if err := a.repo.RemoveGroupMember(ctx, groupID, userID); err != nil {
+		return err
+	}
+
+	event := events.AuthGroupMemberRemovedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), map[string]string{"user_id": userID.String()}, nil),
+		GroupID: groupID,
+		UserID:  userID,
+	}
+	a.publish(events.AuthGroupMemberRemoved, event)
+	return nil
+}
+
+func (a authService) AssignRoleToGroup(ctx context.Context, groupID uuid.UUID, roleID int) error {
+	if err := a.repo.AssignRoleToGroup(ctx, groupID, roleID); err != nil {
+		return err
+	}
+
+	event := events.AuthGroupRoleAssignedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), nil, map[string]int{"role_id": roleID}),
+		GroupID: groupID,
+		RoleID:  roleID,
+	}
+	a.publish(events.AuthGroupRoleAssigned, event)
+	return nil
+}
+
+func (a authService) RevokeRoleFromGroup(ctx context.Context, groupID uuid.UUID, roleID int) error {
+	if err := a.repo.RemoveRoleFromGroup(ctx, groupID, roleID); err != nil {
+		return err
+	}
+
+	event := events.AuthGroupRoleRevokedData{
+		Trail:   events.NewTrail(ctx, groupTarget(groupID), map[string]int{"role_id": roleID}, nil),
+		GroupID: groupID,
+		RoleID:  roleID,
+	}
+	a.publish(events.AuthGroupRoleRevoked, event)
+	return nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// groupRepo keeps groups in memory. Permissions are resolved like the effective roles query: a
+// user holds the permissions of the roles of every group they belong to.
+type groupRepo struct {
+	domain.Repository
+	groups    map[uuid.UUID]domain.Group
+	members   map[uuid.UUID][]uuid.UUID
+	roles     map[uuid.UUID][]int
+	rolePerms map[int][]string
+}
+
+func newGroupRepo() *groupRepo {
+	return &groupRepo{
+		groups:    map[uuid.UUID]domain.Group{},
+		members:   map[uuid.UUID][]uuid.UUID{},
+		roles:     map[uuid.UUID][]int{},
+		rolePerms: map[int][]string{1: {"cms.page.read"}, 2: {"cms.page.publish"}},
+	}
+}
+
+func (r *groupRepo) CreateGroup(_ context.Context, group *domain.Group) error {
+	r.groups[group.ID] = *group
+	return nil
+}
+
+func (r *groupRepo) GetGroupByID(_ context.Context, groupID uuid.UUID) (*domain.Group, error) {
+	g, ok := r.groups[groupID]
+	if !ok {
+		return nil, httputil.ErrNotFound
+	}
+	return &g, nil
+}
+
+func (r *groupRepo) GetGroupMembers(_ context.Context, groupID uuid.UUID) ([]domain.GroupMember, error) {
+	members := []domain.GroupMember{}
+	for _, id := range r.members[groupID] {
+		members = append(members, domain.GroupMember{UserID: id})
+	}
+	return members, nil
+}
+
+func (r *groupRepo) AddGroupMember(_ context.Context, groupID, userID uuid.UUID) error {
+	// The foreign key on group_members maps a missing group to ErrNotFound.
+	if _, ok := r.groups[groupID]; !ok {
+		return httputil.ErrNotFound
+	}
+	if !slices.Contains(r.members[groupID], userID) {
+		r.members[groupID] = append(r.members[groupID], userID)
+	}
+	return nil
+}
+
+func (r *groupRepo) RemoveGroupMember(_ context.Context, groupID, userID uuid.UUID) error {
+	i := slices.Index(r.members[groupID], userID)
+	if i < 0 {
+		return httputil.ErrNotFound
+	}
+	r.members[groupID] = slices.Delete(r.members[groupID], i, i+1)
+	return nil
+}
+
+func (r *groupRepo) AssignRoleToGroup(_ context.Context, groupID uuid.UUID, roleID int) error {
+	if _, ok := r.groups[groupID]; !ok {
+		return httputil.ErrNotFound
+	}
+	r.roles[groupID] = append(r.roles[groupID], roleID)
+	return nil
+}
+
+func (r *groupRepo) RemoveRoleFromGroup(_ context.Context, groupID uuid.UUID, roleID int) error {
+	i := slices.Index(r.roles[groupID], roleID)
+	if i < 0 {
+		return httputil.ErrNotFound
+	}
+	r.roles[groupID] = slices.Delete(r.roles[groupID], i, i+1)
+	return nil
+}
+
+func (r *groupRepo) GetUserPermissions(_ context.Context, userID uuid.UUID, _ netip.Addr) ([]string, error) {
+	var perms []string
+	for groupID, members := range r.members {
+		if !slices.Contains(members, userID) {
+			continue
+		}
+		for _, roleID := range r.roles[groupID] {
+			perms = append(perms, r.rolePerms[roleID]...)
+		}
+	}
+	return perms, nil
+}
+
+func newGroupTestService() (domain.Service, *groupRepo) {
+	repo := newGroupRepo()
+	return NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"}), repo
+}
+
+func TestCreateGroup(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newGroupTestService()
+
+	group, err := svc.CreateGroup(ctx, "  Support  ", " First line ")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if group.Name != "Support" || group.Description != "First line" || group.Roles == nil {
+		t.Fatalf("unexpected group %+v", group)
+	}
+	if _, ok := repo.groups[group.ID]; !ok {
+		t.Fatal("expected the group to be stored")
+	}
+
+	if _, err := svc.CreateGroup(ctx, "   ", ""); !errors.Is(err, httputil.ErrBadRequest) {
+		t.Fatalf("expected a blank name to be rejected, got %v", err)
+	}
+}
+
+func TestGroupMembership(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newGroupTestService()
+	group, err := svc.CreateGroup(ctx, "Support", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := uuid.New()
+
+	if err := svc.AddGroupMember(ctx, group.ID, user); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	// Adding a member twice is a no-op.
+	if err := svc.AddGroupMember(ctx, group.ID, user); err != nil {
+		t.Fatalf("add member again: %v", err)
+	}
+	members, err := svc.GetGroupMembers(ctx, group.ID)
+	if err != nil || len(members) != 1 || members[0].UserID != user {
+		t.Fatalf("expected one member, got %v %v", members, err)
+	}
+
+	if err := svc.AddGroupMember(ctx, uuid.New(), user); !errors.Is(err, httputil.ErrNotFound) {
+		t.Fatalf("expected an unknown group to be reported, got %v", err)
+	}
+	if _, err := svc.GetGroupMembers(ctx, uuid.New()); !errors.Is(err, httputil.ErrNotFound) {
+		t.Fatalf("expected the members of an unknown group to be reported, got %v", err)
+	}
+
+	if err := svc.RemoveGroupMember(ctx, group.ID, user); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	if err := svc.RemoveGroupMember(ctx, group.ID, user); !errors.Is(err, httputil.ErrNotFound) {
+		t.Fatalf("expected removing a non-member to be reported, got %v", err)
+	}
+}
+
+func TestGroupMembersInheritAndLoseGroupRoles(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newGroupTestService()
+	group, err := svc.CreateGroup(ctx, "Editors", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := uuid.New()
+
+	if err := svc.AssignRoleToGroup(ctx, group.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddGroupMember(ctx, group.ID, user); err != nil {
+		t.Fatal(err)
+	}
+	if perms, _ := svc.GetUserPermissions(ctx, user); !slices.Contains(perms, "cms.page.read") {
+		t.Fatalf("expected the member to inherit the group's role, got %v", perms)
+	}
+
+	// Roles granted to the group later reach existing members too.
+	if err := svc.AssignRoleToGroup(ctx, group.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if perms, _ := svc.GetUserPermissions(ctx, user); !slices.Contains(perms, "cms.page.publish") {
+		t.Fatalf("expected the member to inherit a role added later, got %v", perms)
+	}
+
+	if err := svc.RevokeRoleFromGroup(ctx, group.ID, 2); err != nil {
+		t.Fatal(err)
+	}
+	if perms, _ := svc.GetUserPermissions(ctx, user); slices.Contains(perms, "cms.page.publish") {
+		t.Fatalf("expected the revoked group role to be gone, got %v", perms)
+	}
+
+	if err := svc.RemoveGroupMember(ctx, group.ID, user); err != nil {
+		t.Fatal(err)
+	}
+	if perms, _ := svc.GetUserPermissions(ctx, user); len(perms) != 0 {
+		t.Fatalf("expected a removed member to lose the group's roles, got %v", perms)
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+const (
+	magicLinkTTL = 15 * time.Minute
+	// At most magicLinkMaxPerWindow links are sent to one user per magicLinkRateWindow.
+	magicLinkMaxPerWindow = 3
+	magicLinkRateWindow   = time.Hour
+)
+
+func (a authService) SetMagicLinkEnabled(ctx context.Context, userID uuid.UUID, enabled bool) error {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.SetMagicLinkEnabled(ctx, userID, enabled); err != nil {
+		return err
+	}
+
+	event := events.AuthUserUpdatedData{
+		Trail: events.NewTrail(ctx, userTarget(userID),
+			map[string]bool{"magic_link_enabled": u.MagicLinkEnabled},
+			map[string]bool{"magic_link_enabled": enabled}),
+		UserID: userID,
+		Fields: []string{"magic_link_enabled"},
+	}
+	a.publish(events.AuthUserUpdated, event)
+	return nil
+}
+
+// RequestMagicLink emails a login link to the user and returns the device token the caller must
+// hand to the requesting browser. A device token is returned even when no link is sent so the
+// response never reveals whether the address belongs to an opted-in account. The browser's
+// current deviceToken, if any, is kept so links it requested earlier remain usable.
+func (a authService) RequestMagicLink(ctx context.Context, email, requestIP, deviceToken string) (string, error) {
+	if deviceToken == "" {
+		var err error
+		if deviceToken, err = generateSecret(); err != nil {
+			return "", err
+		}
+	}
+
+	u, err := a.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return deviceToken, nil
+		}
+		return "", err
+	}
+	if !u.MagicLinkEnabled {
+		return deviceToken, nil
+	}
+
+	recent, err := a.repo.CountRecentMagicLinks(ctx, u.ID, time.Now().Add(-magicLinkRateWindow))
+	if err != nil {
+		return "", err
+	}
+	if recent >= magicLinkMaxPerWindow {
+		slog.Warn("magic link rate limit reached", "user_id", u.ID)
+		return deviceToken, nil
+	}
+
+	token, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+
+	link := &domain.MagicLink{
+		ID:          uuid.New(),
+		UserID:      u.ID,
+		TokenHash:   hashToken(token),
+		DeviceHash:  hashToken(deviceToken),
+		RequestedIP: requestIP,
+		ExpiresAt:   time.Now().Add(magicLinkTTL),
+	}
+	if err := a.repo.CreateMagicLink(ctx, link); err != nil {
+		return "", err
+	}
+
+	loginURL := fmt.Sprintf("%s/auth/magic-link?token=%s", a.appBaseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nUse the link below to sign in. It expires in %d minutes and only works in the browser where you requested it.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+		u.FullName, int(magicLinkTTL.Minutes()), loginURL)
+	if err := a.mailer.Send(ctx, u.Email, "Your sign-in link", body); err != nil {
+		return "", err
+	}
+
+	return deviceToken, nil
+}
+
+func (a authService) LoginWithMagicLink(ctx context.Context, token, deviceToken string) (domain.AuthTokens, error) {
+	if token == "" || deviceToken == "" {
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
+
+	link, err := a.repo.ConsumeMagicLink(ctx, hashToken(token), hashToken(deviceToken))
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			a.publishLoginFailed(ctx, nil, "", events.LoginMethodMagicLink, "invalid_token")
+			return domain.AuthTokens{}, httputil.ErrUnauthorized
+		}
+		return domain.AuthTokens{}, err
+	}
+
+	u, err := a.repo.GetUserByID(ctx, link.UserID)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	// A link proves control of the mailbox only; users with passkeys must still present one.
+	if err := a.beginSecondFactor(ctx, u, domain.PasskeyCeremonyMagicLinkFactor); err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	return a.createSession(ctx, u.ID, events.LoginMethodMagicLink)
+}
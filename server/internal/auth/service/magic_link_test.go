@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// magicLinkRepo adds the magic link parts of domain.Repository to passkeyRepo.
+type magicLinkRepo struct {
+	*passkeyRepo
+	links []domain.MagicLink
+}
+
+func (r *magicLinkRepo) CountRecentMagicLinks(context.Context, uuid.UUID, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *magicLinkRepo) CreateMagicLink(_ context.Context, link *domain.MagicLink) error {
+	r.links = append(r.links, *link)
+	return nil
+}
+
+func (r *magicLinkRepo) ConsumeMagicLink(_ context.Context, tokenHash, deviceHash string) (*domain.MagicLink, error) {
+	for i, l := range r.links {
+		if l.TokenHash == tokenHash && l.DeviceHash == deviceHash && l.UsedAt == nil {
+			now := time.Now()
+			r.links[i].UsedAt = &now
+			return &l, nil
+		}
+	}
+	return nil, httputil.ErrNotFound
+}
+
+var magicLinkToken = regexp.MustCompile(`token=(\S+)`)
+
+// linkMailer keeps the tokens of the sign-in links it was asked to send.
+type linkMailer struct {
+	tokens []string
+}
+
+func (m *linkMailer) Send(_ context.Context, _, _, body string) error {
+	match := magicLinkToken.FindStringSubmatch(body)
+	if match == nil {
+		return errors.New("no sign-in link in body")
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		return err
+	}
+	m.tokens = append(m.tokens, token)
+	return nil
+}
+
+func newMagicLinkTestService(t *testing.T) (domain.Service, *magicLinkRepo, *linkMailer, *domain.User) {
+	t.Helper()
+	_, passkeys, user := newPasskeyTestService(t)
+	user.MagicLinkEnabled = true
+	repo := &magicLinkRepo{passkeyRepo: passkeys}
+	mailer := &linkMailer{}
+	svc := NewAuthService(repo, nil, mailer, nil, Config{
+		JWTSecret:         "test-secret",
+		WebAuthnRPID:      testRPID,
+		WebAuthnRPName:    "Test",
+		WebAuthnRPOrigins: []string{testOrigin},
+	})
+	return svc, repo, mailer, user
+}
+
+func TestMagicLinkKeepsEarlierLinksOfTheSameBrowser(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, mailer, user := newMagicLinkTestService(t)
+
+	device, err := svc.RequestMagicLink(ctx, user.Email, "203.0.113.7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := svc.RequestMagicLink(ctx, user.Email, "203.0.113.7", device)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != device {
+		t.Fatal("expected the browser's device token to be kept")
+	}
+	if len(mailer.tokens) != 2 {
+		t.Fatalf("expected two links to be sent, got %d", len(mailer.tokens))
+	}
+
+	if _, err := svc.LoginWithMagicLink(ctx, mailer.tokens[0], device); err != nil {
+		t.Fatalf("expected the earlier link to still work, got %v", err)
+	}
+	if len(repo.sessions) != 1 {
+		t.Fatalf("expected a session, got %d", len(repo.sessions))
+	}
+
+	if _, err := svc.LoginWithMagicLink(ctx, mailer.tokens[1], "another-browser"); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected a link opened in another browser to be rejected, got %v", err)
+	}
+}
+
+func TestMagicLinkRequiresPasskeyAsSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, mailer, user := newMagicLinkTestService(t)
+	authenticator := newSoftwareAuthenticator(t)
+	registerPasskey(t, svc, user, authenticator)
+
+	device, err := svc.RequestMagicLink(ctx, user.Email, "203.0.113.7", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = svc.LoginWithMagicLink(ctx, mailer.tokens[0], device)
+	var secondFactor *domain.SecondFactorRequiredError
+	if !errors.As(err, &secondFactor) {
+		t.Fatalf("expected second factor to be required, got %v", err)
+	}
+	if len(repo.sessions) != 0 {
+		t.Fatal("no session must be opened before the second factor")
+	}
+
+	credential := authenticator.assert(t, secondFactor.Challenge.Options, user.ID[:])
+	tokens, err := svc.FinishPasskeySecondFactor(ctx, secondFactor.Challenge.CeremonyID, credential)
+	if err != nil {
+		t.Fatalf("finish second factor: %v", err)
+	}
+	if tokens.AccessToken == "" || len(repo.sessions) != 1 {
+		t.Fatal("expected a session after the second factor")
+	}
+}
@@ -1,12 +1,22 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"sort"
 
+	"github.com/google/uuid"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+	"golang.org/x/text/language"
 )
 
-func buildMenuTree(defs []domain.MenuDefinition, userPerms map[string]bool) []domain.MenuNode {
+// buildMenuTree arranges the visible definitions the user may see into a tree. flagEnabled
+// reports whether a feature flag is on for the user; when nil, items requiring a flag are hidden.
+func buildMenuTree(defs []domain.MenuDefinition, userPerms map[string]bool, flagEnabled func(key string) bool) []domain.MenuNode {
 	childrenByParent := make(map[string][]domain.MenuDefinition)
 	rootKey := ""
 	for _, d := range defs {
@@ -32,6 +42,9 @@ func buildMenuTree(defs []domain.MenuDefinition, userPerms map[string]bool) []do
 			if len(d.Permissions) > 0 && !hasAnyPermission(d.Permissions, userPerms) {
 				continue
 			}
+			if d.RequiredFlag != "" && (flagEnabled == nil || !flagEnabled(d.RequiredFlag)) {
+				continue
+			}
 
 			sub := build(d.ID)
 			// If it's a leaf node (no children) and has no path, it's probably just a container
@@ -56,6 +69,95 @@ func buildMenuTree(defs []domain.MenuDefinition, userPerms map[string]bool) []do
 	return build(rootKey)
 }
 
+// buildMenuTrees builds one tree per placement. Items are placed with their parents, so each
+// placement's tree is built from its own definitions alone.
+func buildMenuTrees(defs []domain.MenuDefinition, userPerms map[string]bool, flagEnabled func(key string) bool) domain.MenuTrees {
+	byPlacement := make(map[menu.Placement][]domain.MenuDefinition)
+	for _, d := range defs {
+		byPlacement[d.Placement.OrDefault()] = append(byPlacement[d.Placement.OrDefault()], d)
+	}
+
+	trees := make(domain.MenuTrees, len(menu.Placements))
+	for _, p := range menu.Placements {
+		tree := buildMenuTree(byPlacement[p], userPerms, flagEnabled)
+		if tree == nil {
+			tree = []domain.MenuNode{}
+		}
+		trees[p] = tree
+	}
+	return trees
+}
+
+// menuFlags returns the flag evaluator for userID's menu. Roles are only loaded, once, when a
+// flag targets roles and is not already on for the user.
+func (a authService) menuFlags(ctx context.Context, userID uuid.UUID) func(key string) bool {
+	if a.flags == nil {
+		return nil
+	}
+	var (
+		roles  []string
+		loaded bool
+	)
+	return func(key string) bool {
+		f, ok := a.flags.Flag(key)
+		if !ok {
+			return false
+		}
+		s := flags.Subject{UserID: userID}
+		if on := f.EnabledFor(s); on || len(f.Roles) == 0 {
+			return on
+		}
+		if !loaded {
+			loaded = true
+			var err error
+			if roles, err = a.GetUserRoleNames(ctx, userID); err != nil {
+				slog.Warn("menu flags: failed to load roles", "user_id", userID, "error", err)
+			}
+		}
+		s.Roles = roles
+		return f.EnabledFor(s)
+	}
+}
+
+// enabledMenuFlags evaluates the flags required by defs, returning those that are on.
+func enabledMenuFlags(defs []domain.MenuDefinition, flagEnabled func(key string) bool) map[string]bool {
+	enabled := make(map[string]bool)
+	if flagEnabled == nil {
+		return enabled
+	}
+	for _, d := range defs {
+		if d.RequiredFlag == "" {
+			continue
+		}
+		if _, seen := enabled[d.RequiredFlag]; !seen {
+			enabled[d.RequiredFlag] = flagEnabled(d.RequiredFlag)
+		}
+	}
+	return enabled
+}
+
+// menuTreeKey identifies a built menu: users with the same permissions, locale and enabled flags
+// see the same tree.
+func menuTreeKey(perms map[string]bool, locale string, flags map[string]bool) string {
+	var key struct {
+		Permissions []string `json:"permissions"`
+		Locale      string   `json:"locale"`
+		Flags       []string `json:"flags"`
+	}
+	for p := range perms {
+		key.Permissions = append(key.Permissions, p)
+	}
+	for f, on := range flags {
+		if on {
+			key.Flags = append(key.Flags, f)
+		}
+	}
+	sort.Strings(key.Permissions)
+	sort.Strings(key.Flags)
+	key.Locale = locale
+	return versionHash(key)
+}
+
 func hasAnyPermission(perms []string, userPerms map[string]bool) bool {
 	for _, p := range perms {
 		if userPerms[p] {
@@ -64,3 +166,257 @@ func hasAnyPermission(perms []string, userPerms map[string]bool) bool {
 	}
 	return false
 }
+
+// RegisterModuleMenus validates defs and replaces the menu of domainName with them, pruning items
+// it no longer declares. Duplicate IDs, cycles and labels for invalid locales reject the
+// registration, as does a version older than the one already applied; orphans and unknown
+// permissions are logged and returned.
+func (a authService) RegisterModuleMenus(ctx context.Context, domainName string, version int, defs []domain.MenuDefinition) ([]domain.MenuProblem, error) {
+	for i := range defs {
+		defs[i].Domain = domainName
+		defs[i].Labels = normalizeLabels(defs[i].Labels)
+	}
+	resolvePlacements(defs)
+
+	problems, err := a.validateMenus(ctx, domainName, defs)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range problems {
+		slog.Warn("menu definition problem", "domain", p.Domain, "menu_id", p.MenuID, "kind", p.Kind, "blocking", p.Blocking, "message", p.Message)
+	}
+	if blocking := blockingProblems(problems); len(blocking) > 0 {
+		err := &domain.MenuValidationError{Problems: blocking}
+		slog.Error("module menus rejected", "domain", domainName, "version", version, "error", err)
+		return problems, err
+	}
+
+	err = a.repo.ReplaceMenuDefinitions(ctx, domainName, version, defs)
+	// Every instance applies registrations itself; a stale version means another one got there
+	// first, so the menu changed either way.
+	if err == nil || errors.Is(err, domain.ErrStaleMenuVersion) {
+		a.menus.purge()
+	}
+	if errors.Is(err, domain.ErrStaleMenuVersion) {
+		slog.Warn("stale module menus rejected", "domain", domainName, "version", version, "error", err)
+		return problems, err
+	}
+	if err != nil {
+		slog.Error("failed to register module menus", "domain", domainName, "error", err)
+		return problems, err
+	}
+	slog.Info("module menus registered", "domain", domainName, "version", version, "count", len(defs), "problems", len(problems))
+	return problems, nil
+}
+
+// validateMenus checks a domain's definitions against the menus other domains registered and the
+// permissions known so far.
+func (a authService) validateMenus(ctx context.Context, domainName string, defs []domain.MenuDefinition) ([]domain.MenuProblem, error) {
+	stored, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var others []domain.MenuDefinition
+	for _, d := range stored {
+		if d.Domain != domainName {
+			others = append(others, d)
+		}
+	}
+
+	known, err := a.knownPermissions(ctx, defs)
+	if err != nil {
+		return nil, err
+	}
+	return validateMenuDefinitions(defs, others, known), nil
+}
+
+func (a authService) GetMenuDiagnostics(ctx context.Context) ([]domain.MenuDiagnostics, error) {
+	domains, err := a.repo.GetMenuDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known, err := a.knownPermissions(ctx, defs)
+	if err != nil {
+		return nil, err
+	}
+
+	byDomain := make(map[string][]domain.MenuDefinition)
+	for _, d := range defs {
+		byDomain[d.Domain] = append(byDomain[d.Domain], d)
+	}
+	// Items registered before versioning may belong to a domain without a menu_domains row.
+	seen := make(map[string]bool, len(domains))
+	for _, d := range domains {
+		seen[d.Domain] = true
+	}
+	for name := range byDomain {
+		if !seen[name] {
+			domains = append(domains, domain.MenuDomain{Domain: name})
+		}
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Domain < domains[j].Domain })
+
+	result := make([]domain.MenuDiagnostics, 0, len(domains))
+	for _, md := range domains {
+		own := byDomain[md.Domain]
+		var others []domain.MenuDefinition
+		for _, d := range defs {
+			if d.Domain != md.Domain {
+				others = append(others, d)
+			}
+		}
+		problems := validateMenuDefinitions(own, others, known)
+		if problems == nil {
+			problems = []domain.MenuProblem{}
+		}
+		result = append(result, domain.MenuDiagnostics{MenuDomain: md, Items: len(own), Problems: problems})
+	}
+	return result, nil
+}
+
+// knownPermissions returns which of the permissions referenced by defs have been registered.
+func (a authService) knownPermissions(ctx context.Context, defs []domain.MenuDefinition) (map[string]bool, error) {
+	var referenced []string
+	for _, d := range defs {
+		referenced = append(referenced, d.Permissions...)
+	}
+	known := make(map[string]bool)
+	if len(referenced) == 0 {
+		return known, nil
+	}
+	existing, err := a.repo.GetExistingPermissionIDs(ctx, referenced)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range existing {
+		known[p] = true
+	}
+	return known, nil
+}
+
+// validateMenuDefinitions reports duplicate IDs, parents that do not exist, parent chains that
+// loop, permissions missing from known, labels keyed by invalid locales, badge subjects that are
+// not plain NATS subjects and placements that are unknown or differ from the parent's. defs may
+// hang off items in others, the menus of other domains, which are otherwise not checked.
+func validateMenuDefinitions(defs, others []domain.MenuDefinition, known map[string]bool) []domain.MenuProblem {
+	var problems []domain.MenuProblem
+	report := func(d domain.MenuDefinition, kind menu.ProblemKind, blocking bool, format string, args ...any) {
+		problems = append(problems, domain.MenuProblem{
+			Domain:   d.Domain,
+			MenuID:   d.ID,
+			Kind:     kind,
+			Blocking: blocking,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	owner := make(map[string]string, len(others))
+	parents := make(map[string]string, len(defs)+len(others))
+	placements := make(map[string]menu.Placement, len(defs)+len(others))
+	for _, d := range others {
+		owner[d.ID] = d.Domain
+		parents[d.ID] = d.ParentID
+		placements[d.ID] = d.Placement.OrDefault()
+	}
+
+	seen := make(map[string]bool, len(defs))
+	for _, d := range defs {
+		switch {
+		case seen[d.ID]:
+			report(d, menu.ProblemDuplicateID, true, "menu item %q is declared more than once", d.ID)
+		case owner[d.ID] != "":
+			report(d, menu.ProblemDuplicateID, true, "menu item %q is already registered by domain %q", d.ID, owner[d.ID])
+		}
+		seen[d.ID] = true
+		parents[d.ID] = d.ParentID
+		placements[d.ID] = d.Placement.OrDefault()
+	}
+
+	for _, d := range defs {
+		if d.ParentID != "" {
+			if _, ok := parents[d.ParentID]; !ok {
+				report(d, menu.ProblemOrphan, false, "parent %q of menu item %q does not exist", d.ParentID, d.ID)
+			}
+		}
+		if inCycle(d.ID, parents) {
+			report(d, menu.ProblemCycle, true, "menu item %q is its own ancestor", d.ID)
+		}
+		for _, p := range d.Permissions {
+			if !known[p] {
+				report(d, menu.ProblemUnknownPermission, false, "menu item %q requires unregistered permission %q", d.ID, p)
+			}
+		}
+		for key := range d.Labels {
+			if _, err := language.Parse(key); err != nil {
+				report(d, menu.ProblemInvalidLocale, true, "menu item %q has a label for invalid locale %q", d.ID, key)
+			}
+		}
+		if d.RequiredFlag != "" && !flags.ValidKey(d.RequiredFlag) {
+			report(d, menu.ProblemInvalidFlag, true, "menu item %q requires invalid flag key %q", d.ID, d.RequiredFlag)
+		}
+		if d.BadgeSubject != "" && !validBadgeSubject(d.BadgeSubject) {
+			report(d, menu.ProblemInvalidBadge, true, "menu item %q has invalid badge subject %q", d.ID, d.BadgeSubject)
+		}
+		if placement := d.Placement.OrDefault(); !placement.Valid() {
+			report(d, menu.ProblemInvalidPlacement, true, "menu item %q has unknown placement %q", d.ID, placement)
+		} else if parent, ok := placements[d.ParentID]; ok && d.ParentID != "" && parent != placement {
+			report(d, menu.ProblemInvalidPlacement, true, "menu item %q is placed in %q but its parent %q is in %q", d.ID, placement, d.ParentID, parent)
+		}
+	}
+	return problems
+}
+
+// resolvePlacements gives items declared without a placement their parent's, or the sidebar for
+// top-level items and items whose parent belongs to another domain.
+func resolvePlacements(defs []domain.MenuDefinition) {
+	byID := make(map[string]int, len(defs))
+	for i, d := range defs {
+		byID[d.ID] = i
+	}
+
+	var resolve func(i int, visited map[int]bool) menu.Placement
+	resolve = func(i int, visited map[int]bool) menu.Placement {
+		if defs[i].Placement != "" {
+			return defs[i].Placement
+		}
+		placement := menu.PlacementSidebar
+		if parent, ok := byID[defs[i].ParentID]; ok && !visited[parent] {
+			visited[i] = true
+			placement = resolve(parent, visited)
+		}
+		defs[i].Placement = placement
+		return placement
+	}
+	for i := range defs {
+		resolve(i, map[int]bool{})
+	}
+}
+
+func inCycle(id string, parents map[string]string) bool {
+	visited := make(map[string]bool)
+	for cur := parents[id]; cur != ""; cur = parents[cur] {
+		if cur == id {
+			return true
+		}
+		if visited[cur] {
+			// A loop further up that does not include id.
+			return false
+		}
+		visited[cur] = true
+	}
+	return false
+}
+
+func blockingProblems(problems []domain.MenuProblem) []domain.MenuProblem {
+	var blocking []domain.MenuProblem
+	for _, p := range problems {
+		if p.Blocking {
+			blocking = append(blocking, p)
+		}
+	}
+	return blocking
+}
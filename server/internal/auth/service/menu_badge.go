@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/expiry"
+)
+
+const defaultMenuBadgeTimeout = 250 * time.Millisecond
+
+// badgeRequester sends a request to subject and returns the reply payload.
+type badgeRequester func(ctx context.Context, subject string, data []byte) ([]byte, error)
+
+func natsBadgeRequester(nc *nats.Conn) badgeRequester {
+	if nc == nil {
+		return nil
+	}
+	return func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+		msg, err := nc.RequestWithContext(ctx, subject, data)
+		if err != nil {
+			return nil, err
+		}
+		return msg.Data, nil
+	}
+}
+
+// badgeResolver asks the modules declaring badge subjects for a user's badge counts. All requests
+// of a menu share one deadline, so a slow or absent module costs at most the timeout and only its
+// badges are missing. Answers are cached per user and item for the TTL; failures are not cached.
+type badgeResolver struct {
+	request badgeRequester
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries expiry.Map[string, int]
+}
+
+func newBadgeResolver(request badgeRequester, timeout, ttl time.Duration) *badgeResolver {
+	if timeout <= 0 {
+		timeout = defaultMenuBadgeTimeout
+	}
+	return &badgeResolver{request: request, timeout: timeout, ttl: ttl}
+}
+
+// attach sets the badges of the items in trees that declare a badge subject in defs. Items with a
+// zero count, or whose module did not answer in time, get no badge.
+func (b *badgeResolver) attach(ctx context.Context, userID uuid.UUID, defs []domain.MenuDefinition, trees domain.MenuTrees) {
+	if b == nil || b.request == nil {
+		return
+	}
+
+	subjects := make(map[string]string)
+	for _, d := range defs {
+		if d.BadgeSubject != "" {
+			subjects[d.ID] = d.BadgeSubject
+		}
+	}
+	wanted := make(map[string]string)
+	var collect func(nodes []domain.MenuNode)
+	collect = func(nodes []domain.MenuNode) {
+		for _, n := range nodes {
+			if subject, ok := subjects[n.Id]; ok {
+				wanted[n.Id] = subject
+			}
+			collect(n.Children)
+		}
+	}
+	for _, tree := range trees {
+		collect(tree)
+	}
+	if len(wanted) == 0 {
+		return
+	}
+
+	counts := b.resolve(ctx, userID, wanted)
+
+	var set func(nodes []domain.MenuNode)
+	set = func(nodes []domain.MenuNode) {
+		for i := range nodes {
+			if count := counts[nodes[i].Id]; count > 0 {
+				nodes[i].Badge = &domain.MenuBadge{Count: count}
+			}
+			set(nodes[i].Children)
+		}
+	}
+	for _, tree := range trees {
+		set(tree)
+	}
+}
+
+// resolve returns the badge counts of the menu items in wanted, keyed by item ID, querying their
+// subjects concurrently for anything not cached.
+func (b *badgeResolver) resolve(ctx context.Context, userID uuid.UUID, wanted map[string]string) map[string]int {
+	counts := make(map[string]int, len(wanted))
+	missing := make(map[string]string)
+	for menuID, subject := range wanted {
+		if count, ok := b.get(badgeKey(userID, menuID)); ok {
+			counts[menuID] = count
+		} else {
+			missing[menuID] = subject
+		}
+	}
+	if len(missing) == 0 {
+		return counts
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for menuID, subject := range missing {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := b.fetch(ctx, subject, events.MenuBadgeRequest{UserID: userID, MenuID: menuID})
+			if err != nil {
+				slog.Warn("menu badge unavailable", "menu_id", menuID, "subject", subject, "error", err)
+				return
+			}
+			b.set(badgeKey(userID, menuID), count)
+			mu.Lock()
+			counts[menuID] = count
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return counts
+}
+
+func (b *badgeResolver) fetch(ctx context.Context, subject string, req events.MenuBadgeRequest) (int, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := b.request(ctx, subject, data)
+	if err != nil {
+		return 0, err
+	}
+	var reply events.MenuBadgeReply
+	if err := json.Unmarshal(resp, &reply); err != nil {
+		return 0, err
+	}
+	if reply.Error != "" {
+		return 0, errors.New(reply.Error)
+	}
+	return reply.Count, nil
+}
+
+func (b *badgeResolver) get(key string) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.entries.Get(key, time.Now())
+}
+
+func (b *badgeResolver) set(key string, count int) {
+	if b.ttl <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.entries.Set(key, count, now.Add(b.ttl), now)
+}
+
+func badgeKey(userID uuid.UUID, menuID string) string {
+	return userID.String() + "|" + menuID
+}
+
+// validBadgeSubject reports whether subject is a plain NATS subject: dot-separated, non-empty
+// tokens without wildcards or whitespace.
+func validBadgeSubject(subject string) bool {
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" || token == "*" || token == ">" || strings.ContainsAny(token, " \t\r\n") {
+			return false
+		}
+	}
+	return true
+}
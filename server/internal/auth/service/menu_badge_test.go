@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+func badgeTrees() ([]domain.MenuDefinition, domain.MenuTrees) {
+	defs := []domain.MenuDefinition{
+		{ID: "cms", Label: "CMS", Visible: true},
+		{ID: "pages", ParentID: "cms", Label: "Pages", Path: "/pages", Visible: true, BadgeSubject: "cms.badge.pages"},
+		{ID: "media", ParentID: "cms", Label: "Media", Path: "/media", Order: 1, Visible: true, BadgeSubject: "cms.badge.media"},
+		{ID: "orders", Label: "Orders", Path: "/orders", Visible: true, Permissions: []string{"shop.order.read"}, BadgeSubject: "shop.badge.orders"},
+	}
+	return defs, buildMenuTrees(defs, map[string]bool{}, nil)
+}
+
+func TestBadgeResolverAttachesCountsAndSkipsSlowModules(t *testing.T) {
+	var requests atomic.Int32
+	request := func(ctx context.Context, subject string, data []byte) ([]byte, error) {
+		requests.Add(1)
+		var req events.MenuBadgeRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		switch subject {
+		case "cms.badge.pages":
+			return json.Marshal(events.MenuBadgeReply{Count: 3})
+		case "cms.badge.media":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		default:
+			t.Errorf("unexpected request on %s for hidden item %s", subject, req.MenuID)
+			return nil, context.Canceled
+		}
+	}
+	b := newBadgeResolver(request, 20*time.Millisecond, time.Minute)
+	defs, trees := badgeTrees()
+
+	start := time.Now()
+	b.attach(context.Background(), uuid.New(), defs, trees)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("slow module blocked the menu for %s", elapsed)
+	}
+
+	sidebar := trees[menu.PlacementSidebar]
+	pages, media := sidebar[0].Children[0], sidebar[0].Children[1]
+	if pages.Badge == nil || pages.Badge.Count != 3 {
+		t.Fatalf("expected pages badge 3, got %#v", pages.Badge)
+	}
+	if media.Badge != nil {
+		t.Fatalf("expected no media badge, got %#v", media.Badge)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests.Load())
+	}
+}
+
+func TestBadgeResolverCachesPerUser(t *testing.T) {
+	var requests atomic.Int32
+	request := func(context.Context, string, []byte) ([]byte, error) {
+		requests.Add(1)
+		return json.Marshal(events.MenuBadgeReply{Count: 1})
+	}
+	b := newBadgeResolver(request, time.Second, time.Minute)
+	defs, _ := badgeTrees()
+	user := uuid.New()
+
+	for range 2 {
+		_, trees := badgeTrees()
+		b.attach(context.Background(), user, defs, trees)
+	}
+	if requests.Load() != 2 {
+		t.Fatalf("expected cached badges to be reused, got %d requests", requests.Load())
+	}
+
+	_, trees := badgeTrees()
+	b.attach(context.Background(), uuid.New(), defs, trees)
+	if requests.Load() != 4 {
+		t.Fatalf("expected another user's badges to be fetched, got %d requests", requests.Load())
+	}
+}
+
+func TestBadgeResolverExpiresCachedCounts(t *testing.T) {
+	b := newBadgeResolver(nil, time.Second, 10*time.Millisecond)
+
+	b.set("a", 1)
+	b.set("b", 2)
+	time.Sleep(15 * time.Millisecond)
+	if _, ok := b.get("a"); ok {
+		t.Fatal("expected the count to expire")
+	}
+
+	b.set("c", 3)
+	if got := b.entries.Len(); got != 1 {
+		t.Fatalf("expected the expired counts to be evicted, got %d", got)
+	}
+}
+
+func TestValidBadgeSubject(t *testing.T) {
+	for subject, want := range map[string]bool{
+		"cms.menu.badge.drafts": true,
+		"cms.*.drafts":          false,
+		"cms.>":                 false,
+		"cms..drafts":           false,
+		"cms.draft pages":       false,
+	} {
+		if got := validBadgeSubject(subject); got != want {
+			t.Errorf("validBadgeSubject(%q) = %v, want %v", subject, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// maxCachedMenuTrees bounds the trees kept at once; the cache is emptied when it is reached.
+const maxCachedMenuTrees = 1000
+
+// menuCache keeps the menu definitions, with overrides applied, and the trees built from them.
+// Trees depend only on the definitions and the key they are stored under, which hashes everything
+// else a tree is built from: the user's permission set, locale and enabled flags. Everything is
+// purged when menus change and otherwise expires after the TTL. A non-positive TTL disables it.
+type menuCache struct {
+	ttl time.Duration
+
+	mu         sync.Mutex
+	generation uint64
+	defs       []domain.MenuDefinition
+	defsExpiry time.Time
+	trees      map[string]cachedMenuTrees
+}
+
+type cachedMenuTrees struct {
+	trees     domain.MenuTrees
+	expiresAt time.Time
+}
+
+func newMenuCache(ttl time.Duration) *menuCache {
+	return &menuCache{ttl: ttl, trees: make(map[string]cachedMenuTrees)}
+}
+
+// definitions returns the cached definitions, calling load on a miss. It also returns the
+// generation they belong to, which trees built from them must be stored with.
+func (c *menuCache) definitions(ctx context.Context, load func(ctx context.Context) ([]domain.MenuDefinition, error)) ([]domain.MenuDefinition, uint64, error) {
+	c.mu.Lock()
+	generation := c.generation
+	if c.defs != nil && time.Now().Before(c.defsExpiry) {
+		defs := c.defs
+		c.mu.Unlock()
+		return defs, generation, nil
+	}
+	c.mu.Unlock()
+
+	defs, err := load(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if defs == nil {
+		defs = []domain.MenuDefinition{}
+	}
+	if c.ttl <= 0 {
+		return defs, generation, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Definitions loaded while a purge happened may already be stale.
+	if c.generation == generation {
+		c.defs = defs
+		c.defsExpiry = time.Now().Add(c.ttl)
+	}
+	return defs, generation, nil
+}
+
+func (c *menuCache) get(key string) (domain.MenuTrees, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.trees[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, false
+	}
+	return e.trees, true
+}
+
+// set stores trees built from the definitions of generation, unless they were purged since.
+func (c *menuCache) set(generation uint64, key string, trees domain.MenuTrees) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if len(c.trees) >= maxCachedMenuTrees {
+		clear(c.trees)
+	}
+	c.trees[key] = cachedMenuTrees{trees: trees, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *menuCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.defs = nil
+	clear(c.trees)
+}
+
+// cloneMenuTrees deep-copies trees so per-user additions such as badges do not reach the cache.
+func cloneMenuTrees(trees domain.MenuTrees) domain.MenuTrees {
+	out := make(domain.MenuTrees, len(trees))
+	for p, nodes := range trees {
+		out[p] = cloneMenuNodes(nodes)
+	}
+	return out
+}
+
+func cloneMenuNodes(nodes []domain.MenuNode) []domain.MenuNode {
+	if nodes == nil {
+		return nil
+	}
+	out := make([]domain.MenuNode, len(nodes))
+	for i, n := range nodes {
+		n.Children = cloneMenuNodes(n.Children)
+		out[i] = n
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// cacheRepo counts definition loads and grants permissions per user.
+type cacheRepo struct {
+	domain.Repository
+	perms     map[uuid.UUID][]string
+	loads     int
+	overrides []domain.MenuOverride
+}
+
+func (r *cacheRepo) GetUserPermissions(_ context.Context, userID uuid.UUID, _ netip.Addr) ([]string, error) {
+	return r.perms[userID], nil
+}
+
+func (r *cacheRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+func (r *cacheRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	r.loads++
+	return []domain.MenuDefinition{
+		{ID: "pages", Label: "Pages", Path: "/pages", Visible: true, BadgeSubject: "cms.badge.pages"},
+		{ID: "roles", Label: "Roles", Path: "/roles", Order: 1, Visible: true, Permissions: []string{"auth.role.read"}},
+	}, nil
+}
+
+func (r *cacheRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	return r.overrides, nil
+}
+
+func (r *cacheRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	return &domain.MenuPreferences{}, nil
+}
+
+func TestGetMyMenuCachesTreesPerPermissionSet(t *testing.T) {
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	repo := &cacheRepo{perms: map[uuid.UUID][]string{
+		alice: {"auth.role.read"},
+		bob:   {"auth.role.read", "auth.role.read"},
+	}}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret", MenuCacheTTL: time.Minute})
+	a := svc.(*authService)
+	a.badges = newBadgeResolver(func(context.Context, string, []byte) ([]byte, error) {
+		return json.Marshal(events.MenuBadgeReply{Count: 2})
+	}, time.Second, 0)
+
+	for _, user := range []uuid.UUID{alice, bob, carol} {
+		trees, err := svc.GetMyMenu(context.Background(), user)
+		if err != nil {
+			t.Fatalf("GetMyMenu: %v", err)
+		}
+		if sidebar := trees[menu.PlacementSidebar]; sidebar[0].Badge == nil {
+			t.Fatalf("expected a badge for %s", user)
+		}
+	}
+	if repo.loads != 1 {
+		t.Fatalf("expected definitions to be loaded once, got %d loads", repo.loads)
+	}
+	if len(a.menus.trees) != 2 {
+		t.Fatalf("expected a tree per permission set, got %d", len(a.menus.trees))
+	}
+	for _, cached := range a.menus.trees {
+		if cached.trees[menu.PlacementSidebar][0].Badge != nil {
+			t.Fatal("badges must not be cached with the shared tree")
+		}
+	}
+
+	label := "Content"
+	repo.overrides = []domain.MenuOverride{{MenuID: "pages", Label: &label}}
+	svc.InvalidateMenuCache()
+
+	trees, err := svc.GetMyMenu(context.Background(), alice)
+	if err != nil {
+		t.Fatalf("GetMyMenu: %v", err)
+	}
+	if got := trees[menu.PlacementSidebar][0].Label; got != "Content" {
+		t.Fatalf("expected the override after invalidation, got %q", got)
+	}
+	if repo.loads != 2 {
+		t.Fatalf("expected definitions to be reloaded, got %d loads", repo.loads)
+	}
+}
+
+func TestMenuCacheDropsResultsLoadedDuringPurge(t *testing.T) {
+	c := newMenuCache(time.Minute)
+	_, generation, _ := c.definitions(context.Background(), func(context.Context) ([]domain.MenuDefinition, error) {
+		c.purge()
+		return []domain.MenuDefinition{{ID: "stale"}}, nil
+	})
+	c.set(generation, "key", domain.MenuTrees{})
+
+	if c.defs != nil {
+		t.Fatal("expected definitions loaded during a purge not to be cached")
+	}
+	if _, ok := c.get("key"); ok {
+		t.Fatal("expected a tree built from purged definitions not to be cached")
+	}
+}
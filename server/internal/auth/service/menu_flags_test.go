@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+)
+
+// flagRepo serves a menu with flagged items to a user holding the editor role.
+type flagRepo struct {
+	domain.Repository
+	roleLookups int
+}
+
+func (r *flagRepo) GetUserPermissions(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	return nil, nil
+}
+
+func (r *flagRepo) GetUserRoleNames(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	r.roleLookups++
+	return []string{"editor"}, nil
+}
+
+func (r *flagRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+func (r *flagRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return []domain.MenuDefinition{
+		{ID: "pages", Label: "Pages", Path: "/pages", Visible: true},
+		{ID: "shop", Label: "Shop", Order: 1, Visible: true, RequiredFlag: "shop"},
+		{ID: "orders", ParentID: "shop", Label: "Orders", Path: "/orders", Visible: true},
+		{ID: "reports", Label: "Reports", Path: "/reports", Order: 2, Visible: true, RequiredFlag: "reports"},
+		{ID: "beta", Label: "Beta", Path: "/beta", Order: 3, Visible: true, RequiredFlag: "unknown"},
+	}, nil
+}
+
+func (r *flagRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	return nil, nil
+}
+
+func (r *flagRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	return &domain.MenuPreferences{}, nil
+}
+
+type flagSet map[string]flags.Flag
+
+func (s flagSet) Flag(key string) (flags.Flag, bool) {
+	f, ok := s[key]
+	return f, ok
+}
+
+func menuIDs(nodes []domain.MenuNode) []string {
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, n.Id)
+		ids = append(ids, menuIDs(n.Children)...)
+	}
+	return ids
+}
+
+func TestGetMyMenuHonorsRequiredFlags(t *testing.T) {
+	repo := &flagRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{
+		JWTSecret: "test-secret",
+		Flags: flagSet{
+			"shop":    {Key: "shop", Roles: []string{"editor"}},
+			"reports": {Key: "reports", Roles: []string{"admin"}},
+		},
+	})
+
+	trees, err := svc.GetMyMenu(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("GetMyMenu: %v", err)
+	}
+
+	got := menuIDs(trees[menu.PlacementSidebar])
+	want := []string{"pages", "shop", "orders"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if repo.roleLookups != 1 {
+		t.Fatalf("expected roles to be loaded once, got %d lookups", repo.roleLookups)
+	}
+}
+
+func TestGetMyMenuHidesFlaggedItemsWithoutFlags(t *testing.T) {
+	svc := NewAuthService(&flagRepo{}, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	trees, err := svc.GetMyMenu(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("GetMyMenu: %v", err)
+	}
+	if got := menuIDs(trees[menu.PlacementSidebar]); len(got) != 1 || got[0] != "pages" {
+		t.Fatalf("expected only pages, got %v", got)
+	}
+}
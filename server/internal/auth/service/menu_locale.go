@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"golang.org/x/text/language"
+)
+
+// defaultLocale is used when no supported locale is configured.
+var defaultLocale = language.English
+
+// locales holds the supported locales, the default first, and a matcher over them.
+type locales struct {
+	tags    []language.Tag
+	matcher language.Matcher
+}
+
+func newLocales(configured []string) locales {
+	var tags []language.Tag
+	for _, l := range configured {
+		tag, err := language.Parse(strings.TrimSpace(l))
+		if err != nil {
+			slog.Error("ignoring invalid supported locale", "locale", l, "error", err)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		tags = []language.Tag{defaultLocale}
+	}
+	return locales{tags: tags, matcher: language.NewMatcher(tags)}
+}
+
+// match returns the supported locale closest to the preferences, if any is close enough.
+func (l locales) match(prefs ...language.Tag) (language.Tag, bool) {
+	if len(prefs) == 0 || l.matcher == nil {
+		return language.Tag{}, false
+	}
+	_, i, confidence := l.matcher.Match(prefs...)
+	if confidence == language.No {
+		return language.Tag{}, false
+	}
+	return l.tags[i], true
+}
+
+func (l locales) fallback() language.Tag {
+	if len(l.tags) == 0 {
+		return defaultLocale
+	}
+	return l.tags[0]
+}
+
+// menuLocale negotiates the menu language: the request's Accept-Language first, as it reflects
+// the language the client is showing, then the user's stored locale, then the default.
+func (a authService) menuLocale(ctx context.Context, userID uuid.UUID) language.Tag {
+	if prefs, _, err := language.ParseAcceptLanguage(domain.AcceptLanguageFromContext(ctx)); err == nil {
+		if tag, ok := a.locales.match(prefs...); ok {
+			return tag
+		}
+	}
+	if user, err := a.repo.GetUserByID(ctx, userID); err == nil && user.Locale != "" {
+		if pref, err := language.Parse(user.Locale); err == nil {
+			if tag, ok := a.locales.match(pref); ok {
+				return tag
+			}
+		}
+	}
+	return a.locales.fallback()
+}
+
+// localizeMenus replaces each Label with its translation for locale, when there is one.
+func localizeMenus(defs []domain.MenuDefinition, locale language.Tag) []domain.MenuDefinition {
+	localized := make([]domain.MenuDefinition, len(defs))
+	for i, d := range defs {
+		if label, ok := translatedLabel(d.Labels, locale); ok {
+			d.Label = label
+		}
+		localized[i] = d
+	}
+	return localized
+}
+
+// translatedLabel looks up locale in labels, then its parent locales, so "pt-BR" falls back to
+// "pt".
+func translatedLabel(labels map[string]string, locale language.Tag) (string, bool) {
+	for tag := locale; !tag.IsRoot(); tag = tag.Parent() {
+		if label, ok := labels[tag.String()]; ok {
+			return label, true
+		}
+	}
+	return "", false
+}
+
+// normalizeLabels canonicalizes the locale keys of labels and drops empty translations. Keys that
+// do not parse are kept so validation can report them.
+func normalizeLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	normalized := make(map[string]string, len(labels))
+	for key, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		if tag, err := language.Parse(key); err == nil {
+			key = tag.String()
+		}
+		normalized[key] = label
+	}
+	return normalized
+}
+
+func (a authService) GetMenuTranslationReport(ctx context.Context) ([]domain.MenuTranslationReport, error) {
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]domain.MenuTranslationReport, 0, len(a.locales.tags))
+	// The default locale is covered by Label itself.
+	for _, locale := range a.locales.tags[1:] {
+		report := domain.MenuTranslationReport{
+			Locale:  locale.String(),
+			Total:   len(defs),
+			Missing: []domain.MissingTranslation{},
+		}
+		for _, d := range defs {
+			if _, ok := translatedLabel(d.Labels, locale); ok {
+				report.Translated++
+				continue
+			}
+			report.Missing = append(report.Missing, domain.MissingTranslation{MenuID: d.ID, Domain: d.Domain, Label: d.Label})
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+)
+
+// localeRepo serves a translated menu to a user with a stored locale.
+type localeRepo struct {
+	domain.Repository
+	userLocale string
+}
+
+func (r *localeRepo) GetUserPermissions(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	return nil, nil
+}
+
+func (r *localeRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id, Locale: r.userLocale}, nil
+}
+
+func (r *localeRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return []domain.MenuDefinition{
+		{ID: "roles", Label: "Roles", Labels: map[string]string{"pt": "Funções", "es": "Roles"}, Path: "/roles", Visible: true},
+		{ID: "groups", Label: "Groups", Labels: map[string]string{"pt-BR": "Grupos"}, Path: "/groups", Order: 1, Visible: true},
+	}, nil
+}
+
+func (r *localeRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	return nil, nil
+}
+
+func (r *localeRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	return &domain.MenuPreferences{}, nil
+}
+
+func TestGetMyMenuNegotiatesLocale(t *testing.T) {
+	cases := []struct {
+		name           string
+		acceptLanguage string
+		userLocale     string
+		want           [2]string
+	}{
+		{"accept-language", "pt-BR,pt;q=0.9,en;q=0.5", "es", [2]string{"Funções", "Grupos"}},
+		{"unsupported header falls back to user", "fr-FR", "pt", [2]string{"Funções", "Grupos"}},
+		{"user preference", "", "es-MX", [2]string{"Roles", "Groups"}},
+		{"default", "", "de", [2]string{"Roles", "Groups"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &localeRepo{userLocale: tc.userLocale}
+			svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret", Locales: []string{"en", "pt-BR", "es"}})
+
+			ctx := domain.WithAcceptLanguage(context.Background(), tc.acceptLanguage)
+			trees, err := svc.GetMyMenu(ctx, uuid.New())
+			if err != nil {
+				t.Fatalf("get menu: %v", err)
+			}
+			sidebar := trees[menu.PlacementSidebar]
+			if got := [2]string{sidebar[0].Label, sidebar[1].Label}; got != tc.want {
+				t.Fatalf("expected labels %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGetMenuTranslationReport(t *testing.T) {
+	svc := NewAuthService(&localeRepo{}, nil, nil, nil, Config{JWTSecret: "test-secret", Locales: []string{"en", "pt", "es"}})
+
+	reports, err := svc.GetMenuTranslationReport(context.Background())
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if len(reports) != 2 || reports[0].Locale != "pt" || reports[1].Locale != "es" {
+		t.Fatalf("expected reports for pt and es, got %#v", reports)
+	}
+	// "pt-BR" does not cover plain "pt".
+	if reports[0].Translated != 1 || len(reports[0].Missing) != 1 || reports[0].Missing[0].MenuID != "groups" {
+		t.Fatalf("unexpected pt report: %#v", reports[0])
+	}
+	if reports[1].Translated != 1 || reports[1].Missing[0].Label != "Groups" {
+		t.Fatalf("unexpected es report: %#v", reports[1])
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	got := normalizeLabels(map[string]string{"PT-br": " Grupos ", "es": " ", "not a locale": "x"})
+	if len(got) != 2 || got["pt-BR"] != "Grupos" || got["not a locale"] != "x" {
+		t.Fatalf("unexpected labels: %#v", got)
+	}
+}
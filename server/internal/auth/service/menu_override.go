@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// maxMenuTextLength matches the label and icon columns.
+const maxMenuTextLength = 100
+
+func (a authService) GetMenuItems(ctx context.Context) ([]domain.MenuItem, error) {
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := a.repo.GetMenuOverrides(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.MenuOverride, len(overrides))
+	for _, o := range overrides {
+		byID[o.MenuID] = o
+	}
+
+	items := make([]domain.MenuItem, 0, len(defs))
+	for _, d := range defs {
+		var o *domain.MenuOverride
+		if found, ok := byID[d.ID]; ok {
+			o = &found
+		}
+		items = append(items, newMenuItem(d, o))
+	}
+	return items, nil
+}
+
+func (a authService) GetMenuItem(ctx context.Context, menuID string) (*domain.MenuItem, error) {
+	def, err := a.findMenuDefinition(ctx, menuID)
+	if err != nil {
+		return nil, err
+	}
+	o, err := a.repo.GetMenuOverride(ctx, menuID)
+	if err != nil {
+		return nil, err
+	}
+	item := newMenuItem(*def, o)
+	return &item, nil
+}
+
+// SetMenuOverride replaces the override of a menu item. Nil fields keep the declared value; an
+// override that changes nothing is rejected, use ResetMenuOverride instead.
+func (a authService) SetMenuOverride(ctx context.Context, menuID string, override domain.MenuOverride) (*domain.MenuItem, error) {
+	if override.Label != nil {
+		label := strings.TrimSpace(*override.Label)
+		if label == "" || utf8.RuneCountInString(label) > maxMenuTextLength {
+			return nil, domain.ErrInvalidMenuOverride
+		}
+		override.Label = &label
+	}
+	if override.Icon != nil {
+		icon := strings.TrimSpace(*override.Icon)
+		if icon == "" || utf8.RuneCountInString(icon) > maxMenuTextLength {
+			return nil, domain.ErrInvalidMenuOverride
+		}
+		override.Icon = &icon
+	}
+	if override.Hidden == nil && override.Label == nil && override.Icon == nil && override.Order == nil {
+		return nil, domain.ErrInvalidMenuOverride
+	}
+
+	before, err := a.GetMenuItem(ctx, menuID)
+	if err != nil {
+		return nil, err
+	}
+
+	override.MenuID = menuID
+	override.UpdatedBy = actorUserID(ctx)
+	if err := a.repo.UpsertMenuOverride(ctx, &override); err != nil {
+		return nil, err
+	}
+	a.menus.purge()
+	after := newMenuItem(before.Declared, &override)
+
+	event := events.AuthMenuOverrideUpdatedData{
+		Trail:  events.NewTrail(ctx, menuTarget(menuID), before.Override, after.Override),
+		MenuID: menuID,
+	}
+	a.publish(events.AuthMenuOverrideUpdated, event)
+	return &after, nil
+}
+
+func (a authService) ResetMenuOverride(ctx context.Context, menuID string) (*domain.MenuItem, error) {
+	before, err := a.GetMenuItem(ctx, menuID)
+	if err != nil {
+		return nil, err
+	}
+	after := newMenuItem(before.Declared, nil)
+	if before.Override == nil {
+		return &after, nil
+	}
+
+	if err := a.repo.DeleteMenuOverride(ctx, menuID); err != nil {
+		return nil, err
+	}
+	a.menus.purge()
+
+	event := events.AuthMenuOverrideResetData{
+		Trail:   events.NewTrail(ctx, menuTarget(menuID), before.Override, nil),
+		MenuIDs: []string{menuID},
+	}
+	a.publish(events.AuthMenuOverrideReset, event)
+	return &after, nil
+}
+
+func (a authService) ResetMenuOverrides(ctx context.Context) (int, error) {
+	ids, err := a.repo.DeleteMenuOverrides(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	a.menus.purge()
+
+	event := events.AuthMenuOverrideResetData{
+		Trail:   events.NewTrail(ctx, menuTarget(""), ids, nil),
+		MenuIDs: ids,
+	}
+	a.publish(events.AuthMenuOverrideReset, event)
+	return len(ids), nil
+}
+
+func (a authService) findMenuDefinition(ctx context.Context, menuID string) (*domain.MenuDefinition, error) {
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range defs {
+		if defs[i].ID == menuID {
+			return &defs[i], nil
+		}
+	}
+	return nil, domain.ErrMenuItemNotFound
+}
+
+// applyMenuOverrides layers the admin overrides over the declared definitions.
+func applyMenuOverrides(defs []domain.MenuDefinition, overrides []domain.MenuOverride) []domain.MenuDefinition {
+	if len(overrides) == 0 {
+		return defs
+	}
+	byID := make(map[string]domain.MenuOverride, len(overrides))
+	for _, o := range overrides {
+		byID[o.MenuID] = o
+	}
+	merged := make([]domain.MenuDefinition, len(defs))
+	for i, d := range defs {
+		if o, ok := byID[d.ID]; ok {
+			d = o.Apply(d)
+		}
+		merged[i] = d
+	}
+	return merged
+}
+
+func newMenuItem(def domain.MenuDefinition, o *domain.MenuOverride) domain.MenuItem {
+	item := domain.MenuItem{MenuDefinition: def, Declared: def, Override: o}
+	if o != nil {
+		item.MenuDefinition = o.Apply(def)
+	}
+	return item
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+)
+
+// overrideRepo keeps menu definitions and overrides in memory.
+type overrideRepo struct {
+	domain.Repository
+	defs      []domain.MenuDefinition
+	overrides map[string]domain.MenuOverride
+}
+
+func newOverrideRepo() *overrideRepo {
+	return &overrideRepo{
+		defs: []domain.MenuDefinition{
+			{ID: "root", Label: "Root", Order: 10, Visible: true},
+			{ID: "a", Label: "A", ParentID: "root", Path: "/a", Icon: "a", Order: 10, Visible: true},
+			{ID: "b", Label: "B", ParentID: "root", Path: "/b", Icon: "b", Order: 20, Visible: true},
+		},
+		overrides: make(map[string]domain.MenuOverride),
+	}
+}
+
+func (r *overrideRepo) GetUserPermissions(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	return nil, nil
+}
+
+func (r *overrideRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id, Locale: "en"}, nil
+}
+
+func (r *overrideRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return r.defs, nil
+}
+
+func (r *overrideRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	var out []domain.MenuOverride
+	for _, o := range r.overrides {
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (r *overrideRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	return &domain.MenuPreferences{}, nil
+}
+
+func (r *overrideRepo) GetMenuOverride(_ context.Context, menuID string) (*domain.MenuOverride, error) {
+	o, ok := r.overrides[menuID]
+	if !ok {
+		return nil, nil
+	}
+	return &o, nil
+}
+
+func (r *overrideRepo) UpsertMenuOverride(_ context.Context, o *domain.MenuOverride) error {
+	r.overrides[o.MenuID] = *o
+	return nil
+}
+
+func (r *overrideRepo) DeleteMenuOverride(_ context.Context, menuID string) error {
+	delete(r.overrides, menuID)
+	return nil
+}
+
+func (r *overrideRepo) DeleteMenuOverrides(context.Context) ([]string, error) {
+	var ids []string
+	for id := range r.overrides {
+		ids = append(ids, id)
+	}
+	r.overrides = make(map[string]domain.MenuOverride)
+	return ids, nil
+}
+
+func TestMenuOverridesMergeIntoMyMenu(t *testing.T) {
+	repo := newOverrideRepo()
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+	ctx := context.Background()
+
+	hidden, label, order := true, "  Renamed ", 5
+	if _, err := svc.SetMenuOverride(ctx, "a", domain.MenuOverride{Hidden: &hidden}); err != nil {
+		t.Fatalf("hide a: %v", err)
+	}
+	item, err := svc.SetMenuOverride(ctx, "b", domain.MenuOverride{Label: &label, Order: &order})
+	if err != nil {
+		t.Fatalf("override b: %v", err)
+	}
+	if item.Label != "Renamed" || item.Declared.Label != "B" || item.Icon != "b" {
+		t.Fatalf("unexpected merged item: %#v", item)
+	}
+
+	trees, err := svc.GetMyMenu(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("get menu: %v", err)
+	}
+	want := []domain.MenuNode{{Id: "root", Label: "Root", Children: []domain.MenuNode{{Id: "b", Label: "Renamed", Path: "/b", Icon: "b"}}}}
+	if menu := trees[menu.PlacementSidebar]; !reflect.DeepEqual(menu, want) {
+		t.Fatalf("unexpected menu: %#v", menu)
+	}
+
+	if n, err := svc.ResetMenuOverrides(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 overrides reset, got %d, %v", n, err)
+	}
+	trees, _ = svc.GetMyMenu(ctx, uuid.New())
+	if menu := trees[menu.PlacementSidebar]; len(menu) != 1 || len(menu[0].Children) != 2 || menu[0].Children[1].Label != "B" {
+		t.Fatalf("expected declared menu after reset, got %#v", menu)
+	}
+}
+
+func TestSetMenuOverrideValidates(t *testing.T) {
+	repo := newOverrideRepo()
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+	ctx := context.Background()
+
+	blank := "  "
+	cases := map[string]struct {
+		menuID   string
+		override domain.MenuOverride
+		want     error
+	}{
+		"empty override": {"a", domain.MenuOverride{}, domain.ErrInvalidMenuOverride},
+		"blank label":    {"a", domain.MenuOverride{Label: &blank}, domain.ErrInvalidMenuOverride},
+		"blank icon":     {"a", domain.MenuOverride{Icon: &blank}, domain.ErrInvalidMenuOverride},
+	}
+	for name, tc := range cases {
+		if _, err := svc.SetMenuOverride(ctx, tc.menuID, tc.override); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+
+	icon := "star"
+	if _, err := svc.SetMenuOverride(ctx, "missing", domain.MenuOverride{Icon: &icon}); !errors.Is(err, domain.ErrMenuItemNotFound) {
+		t.Errorf("expected unknown item to be not found, got %v", err)
+	}
+}
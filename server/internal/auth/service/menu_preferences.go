@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+)
+
+const (
+	maxMenuPins        = 20
+	maxRecentMenuItems = 10
+)
+
+// SetMenuPins drops duplicate and empty IDs and rejects items that do not exist. Items the user
+// may not see can still be pinned; they stay out of the favorites section until they may.
+func (a authService) SetMenuPins(ctx context.Context, userID uuid.UUID, menuIDs []string) ([]string, error) {
+	pins := make([]string, 0, len(menuIDs))
+	seen := make(map[string]bool, len(menuIDs))
+	for _, id := range menuIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			pins = append(pins, id)
+		}
+	}
+	if len(pins) > maxMenuPins {
+		return nil, domain.ErrTooManyMenuPins
+	}
+
+	defs, _, err := a.menus.definitions(ctx, a.loadMenuDefinitions)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range pins {
+		if !hasMenuDefinition(defs, id) {
+			return nil, domain.ErrMenuItemNotFound
+		}
+	}
+
+	if err := a.repo.ReplaceMenuPins(ctx, userID, pins); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+func (a authService) RecordMenuVisit(ctx context.Context, userID uuid.UUID, menuID string) error {
+	defs, _, err := a.menus.definitions(ctx, a.loadMenuDefinitions)
+	if err != nil {
+		return err
+	}
+	if !hasMenuDefinition(defs, menuID) {
+		return domain.ErrMenuItemNotFound
+	}
+	return a.repo.RecordMenuVisit(ctx, userID, menuID, maxRecentMenuItems)
+}
+
+// addMenuSections adds the favorites and recent sections to trees. Only items present in the
+// placement trees are listed, so the rules deciding what the user may see apply to them too.
+func addMenuSections(trees domain.MenuTrees, prefs *domain.MenuPreferences) {
+	visible := make(map[string]domain.MenuNode)
+	var collect func(nodes []domain.MenuNode)
+	collect = func(nodes []domain.MenuNode) {
+		for _, n := range nodes {
+			collect(n.Children)
+			// Containers without a path cannot be navigated to.
+			if n.Path != "" {
+				n.Children = nil
+				visible[n.Id] = n
+			}
+		}
+	}
+	for _, p := range menu.Placements {
+		collect(trees[p])
+	}
+
+	section := func(ids []string) []domain.MenuNode {
+		nodes := []domain.MenuNode{}
+		for _, id := range ids {
+			if n, ok := visible[id]; ok {
+				nodes = append(nodes, n)
+			}
+		}
+		return nodes
+	}
+	trees[menu.SectionFavorites] = section(prefs.Pins)
+	trees[menu.SectionRecent] = section(prefs.Recent)
+}
+
+func hasMenuDefinition(defs []domain.MenuDefinition, menuID string) bool {
+	for _, d := range defs {
+		if d.ID == menuID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// preferencesRepo stores one user's menu preferences in memory.
+type preferencesRepo struct {
+	domain.Repository
+	perms   []string
+	prefs   domain.MenuPreferences
+	visited []string
+	keep    int
+}
+
+func (r *preferencesRepo) GetUserPermissions(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	return r.perms, nil
+}
+
+func (r *preferencesRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id}, nil
+}
+
+func (r *preferencesRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return []domain.MenuDefinition{
+		{ID: "cms", Label: "CMS", Visible: true, Permissions: []string{"cms.page.read"}},
+		{ID: "pages", ParentID: "cms", Label: "Pages", Path: "/pages", Icon: "file", Visible: true},
+		{ID: "roles", Label: "Roles", Path: "/roles", Visible: true},
+		{ID: "profile", Label: "Profile", Path: "/profile", Visible: true, Placement: menu.PlacementUserMenu},
+		{ID: "legacy", Label: "Legacy", Path: "/legacy", Visible: false},
+	}, nil
+}
+
+func (r *preferencesRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	return nil, nil
+}
+
+func (r *preferencesRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	prefs := r.prefs
+	return &prefs, nil
+}
+
+func (r *preferencesRepo) ReplaceMenuPins(_ context.Context, _ uuid.UUID, menuIDs []string) error {
+	r.prefs.Pins = menuIDs
+	return nil
+}
+
+func (r *preferencesRepo) RecordMenuVisit(_ context.Context, _ uuid.UUID, menuID string, keep int) error {
+	r.visited = append(r.visited, menuID)
+	r.keep = keep
+	return nil
+}
+
+func sectionIDs(nodes []domain.MenuNode) []string {
+	ids := []string{}
+	for _, n := range nodes {
+		ids = append(ids, n.Id)
+	}
+	return ids
+}
+
+func TestGetMyMenuListsVisibleFavoritesAndRecentItems(t *testing.T) {
+	repo := &preferencesRepo{
+		perms: []string{"cms.page.read"},
+		prefs: domain.MenuPreferences{
+			Pins:   []string{"profile", "legacy", "cms", "pages", "gone"},
+			Recent: []string{"pages", "roles"},
+		},
+	}
+	svc := NewAuthService(repo, nil, nil, nil, Config{
+		JWTSecret: "test-secret",
+		Flags:     flagSet{domain.FlagMenuPreferences: {Key: domain.FlagMenuPreferences, Enabled: true}},
+	})
+
+	trees, err := svc.GetMyMenu(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("GetMyMenu: %v", err)
+	}
+	if got := sectionIDs(trees[menu.SectionFavorites]); !reflect.DeepEqual(got, []string{"profile", "pages"}) {
+		t.Fatalf("unexpected favorites %v", got)
+	}
+	if got := trees[menu.SectionFavorites][1]; got.Label != "Pages" || got.Path != "/pages" || got.Icon != "file" {
+		t.Fatalf("unexpected favorite %#v", got)
+	}
+	if got := sectionIDs(trees[menu.SectionRecent]); !reflect.DeepEqual(got, []string{"pages", "roles"}) {
+		t.Fatalf("unexpected recent items %v", got)
+	}
+
+	// Revoking the permission of an ancestor hides the pin too.
+	repo.perms = nil
+	trees, err = svc.GetMyMenu(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("GetMyMenu: %v", err)
+	}
+	if got := sectionIDs(trees[menu.SectionFavorites]); !reflect.DeepEqual(got, []string{"profile"}) {
+		t.Fatalf("expected the revoked pin to be hidden, got %v", got)
+	}
+	if got := sectionIDs(trees[menu.SectionRecent]); !reflect.DeepEqual(got, []string{"roles"}) {
+		t.Fatalf("expected the revoked recent item to be hidden, got %v", got)
+	}
+}
+
+func TestGetMyMenuHidesSectionsWhileTheFlagIsOff(t *testing.T) {
+	repo := &preferencesRepo{prefs: domain.MenuPreferences{Pins: []string{"roles"}, Recent: []string{"roles"}}}
+
+	for name, source := range map[string]domain.FlagSource{
+		"no flags":     nil,
+		"flag off":     flagSet{domain.FlagMenuPreferences: {Key: domain.FlagMenuPreferences}},
+		"unknown flag": flagSet{},
+	} {
+		cfg := Config{JWTSecret: "test-secret"}
+		if source != nil {
+			cfg.Flags = source
+		}
+		trees, err := NewAuthService(repo, nil, nil, nil, cfg).GetMyMenu(context.Background(), uuid.New())
+		if err != nil {
+			t.Fatalf("%s: GetMyMenu: %v", name, err)
+		}
+		if len(trees[menu.SectionFavorites]) != 0 || len(trees[menu.SectionRecent]) != 0 {
+			t.Fatalf("%s: expected empty sections, got %v", name, trees)
+		}
+		if trees[menu.SectionFavorites] == nil || trees[menu.SectionRecent] == nil {
+			t.Fatalf("%s: expected the sections to be present", name)
+		}
+	}
+}
+
+func TestSetMenuPins(t *testing.T) {
+	repo := &preferencesRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+	user := uuid.New()
+
+	pins, err := svc.SetMenuPins(context.Background(), user, []string{"roles", "", "pages", "roles"})
+	if err != nil {
+		t.Fatalf("SetMenuPins: %v", err)
+	}
+	if !reflect.DeepEqual(pins, []string{"roles", "pages"}) || !reflect.DeepEqual(repo.prefs.Pins, pins) {
+		t.Fatalf("unexpected pins %v, stored %v", pins, repo.prefs.Pins)
+	}
+
+	if _, err := svc.SetMenuPins(context.Background(), user, []string{"roles", "gone"}); !errors.Is(err, httputil.ErrNotFound) {
+		t.Fatalf("expected an unknown item to be rejected, got %v", err)
+	}
+	tooMany := make([]string, maxMenuPins+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("item-%d", i)
+	}
+	if _, err := svc.SetMenuPins(context.Background(), user, tooMany); !errors.Is(err, httputil.ErrBadRequest) {
+		t.Fatalf("expected too many pins to be rejected, got %v", err)
+	}
+	if !reflect.DeepEqual(repo.prefs.Pins, []string{"roles", "pages"}) {
+		t.Fatalf("rejected pins must not be stored, got %v", repo.prefs.Pins)
+	}
+}
+
+func TestRecordMenuVisit(t *testing.T) {
+	repo := &preferencesRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	if err := svc.RecordMenuVisit(context.Background(), uuid.New(), "pages"); err != nil {
+		t.Fatalf("RecordMenuVisit: %v", err)
+	}
+	if err := svc.RecordMenuVisit(context.Background(), uuid.New(), "gone"); !errors.Is(err, httputil.ErrNotFound) {
+		t.Fatalf("expected an unknown item to be rejected, got %v", err)
+	}
+	if !reflect.DeepEqual(repo.visited, []string{"pages"}) || repo.keep != maxRecentMenuItems {
+		t.Fatalf("unexpected visits %v, keep %d", repo.visited, repo.keep)
+	}
+}
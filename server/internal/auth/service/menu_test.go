@@ -1,10 +1,13 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
 )
 
 func TestBuildMenuTreeFiltersAndOrders(t *testing.T) {
@@ -17,23 +20,173 @@ func TestBuildMenuTreeFiltersAndOrders(t *testing.T) {
 	}
 
 	userPerms := map[string]bool{"p.read": true}
-	menu := buildMenuTree(defs, userPerms)
+	tree := buildMenuTree(defs, userPerms, nil)
 
 	expected := []domain.MenuNode{
 		{
+			Id:    "dashboard",
 			Label: "Dashboard",
 			Path:  "/dashboard",
 		},
 		{
+			Id:    "root",
 			Label: "Root",
 			Children: []domain.MenuNode{
-				{Label: "B", Path: "/b"},
-				{Label: "A", Path: "/a"},
+				{Id: "b", Label: "B", Path: "/b"},
+				{Id: "a", Label: "A", Path: "/a"},
 			},
 		},
 	}
 
-	if !reflect.DeepEqual(menu, expected) {
-		t.Fatalf("unexpected menu: %#v", menu)
+	if !reflect.DeepEqual(tree, expected) {
+		t.Fatalf("unexpected menu: %#v", tree)
+	}
+}
+
+func TestBuildMenuTreesSplitsPlacements(t *testing.T) {
+	defs := []domain.MenuDefinition{
+		{ID: "dashboard", Label: "Dashboard", Path: "/dashboard", Visible: true},
+		{ID: "profile", Label: "Profile", Path: "/profile", Visible: true, Placement: menu.PlacementUserMenu},
+		{ID: "new", Label: "New", Visible: true, Placement: menu.PlacementQuickActions},
+		{ID: "new:page", Label: "Page", ParentID: "new", Path: "/pages/new", Visible: true, Placement: menu.PlacementQuickActions, Permissions: []string{"p.write"}},
+	}
+
+	trees := buildMenuTrees(defs, map[string]bool{}, nil)
+
+	if len(trees) != len(menu.Placements) {
+		t.Fatalf("expected a tree per placement, got %#v", trees)
+	}
+	if got := trees[menu.PlacementSidebar]; len(got) != 1 || got[0].Id != "dashboard" {
+		t.Fatalf("unexpected sidebar: %#v", got)
+	}
+	if got := trees[menu.PlacementUserMenu]; len(got) != 1 || got[0].Id != "profile" {
+		t.Fatalf("unexpected user menu: %#v", got)
+	}
+	// The quick-actions container has no permitted children left and is dropped like any other.
+	if got := trees[menu.PlacementQuickActions]; got == nil || len(got) != 0 {
+		t.Fatalf("expected an empty quick actions tree, got %#v", got)
+	}
+}
+
+func TestResolvePlacementsInheritsFromParent(t *testing.T) {
+	defs := []domain.MenuDefinition{
+		{ID: "top", Placement: menu.PlacementTopbar},
+		{ID: "top:child", ParentID: "top"},
+		{ID: "top:grandchild", ParentID: "top:child"},
+		{ID: "plain"},
+		{ID: "foreign", ParentID: "other-domain:item"},
+	}
+
+	resolvePlacements(defs)
+
+	want := []menu.Placement{menu.PlacementTopbar, menu.PlacementTopbar, menu.PlacementTopbar, menu.PlacementSidebar, menu.PlacementSidebar}
+	for i, d := range defs {
+		if d.Placement != want[i] {
+			t.Errorf("%s: expected placement %q, got %q", d.ID, want[i], d.Placement)
+		}
+	}
+}
+
+// menuRepo records the last menu registration and rejects versions below stored.
+type menuRepo struct {
+	domain.Repository
+	stored  int
+	version int
+	defs    []domain.MenuDefinition
+}
+
+func (r *menuRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return nil, nil
+}
+
+func (r *menuRepo) GetExistingPermissionIDs(_ context.Context, ids []string) ([]string, error) {
+	return ids, nil
+}
+
+func (r *menuRepo) ReplaceMenuDefinitions(_ context.Context, _ string, version int, defs []domain.MenuDefinition) error {
+	if version < r.stored {
+		return domain.ErrStaleMenuVersion
+	}
+	r.stored, r.version, r.defs = version, version, defs
+	return nil
+}
+
+func TestRegisterModuleMenusReplacesByVersion(t *testing.T) {
+	repo := &menuRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+	ctx := context.Background()
+
+	defs := []domain.MenuDefinition{{ID: "cms:pages", Domain: "other", Label: "Pages"}}
+	if _, err := svc.RegisterModuleMenus(ctx, "cms", 2, defs); err != nil {
+		t.Fatalf("register menus: %v", err)
+	}
+	if repo.version != 2 || repo.defs[0].Domain != "cms" {
+		t.Fatalf("expected version 2 stamped with domain cms, got %d %#v", repo.version, repo.defs)
+	}
+
+	if _, err := svc.RegisterModuleMenus(ctx, "cms", 1, defs); !errors.Is(err, domain.ErrStaleMenuVersion) {
+		t.Fatalf("expected stale version to be rejected, got %v", err)
+	}
+	if repo.version != 2 {
+		t.Fatalf("stale registration must not be applied, stored version %d", repo.version)
+	}
+}
+
+func TestRegisterModuleMenusRejectsBlockingProblems(t *testing.T) {
+	repo := &menuRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	defs := []domain.MenuDefinition{
+		{ID: "a", Label: "A", ParentID: "b"},
+		{ID: "b", Label: "B", ParentID: "a"},
+	}
+	problems, err := svc.RegisterModuleMenus(context.Background(), "cms", 1, defs)
+	var invalid *domain.MenuValidationError
+	if !errors.As(err, &invalid) || !errors.Is(err, domain.ErrInvalidMenu) {
+		t.Fatalf("expected a menu validation error, got %v", err)
+	}
+	if len(problems) != 2 || len(invalid.Problems) != 2 {
+		t.Fatalf("expected both items reported in a cycle, got %#v", problems)
+	}
+	if repo.defs != nil {
+		t.Fatal("rejected menus must not be applied")
+	}
+}
+
+func TestValidateMenuDefinitions(t *testing.T) {
+	others := []domain.MenuDefinition{
+		{ID: "auth:system", Domain: "auth"},
+	}
+	defs := []domain.MenuDefinition{
+		{ID: "cms:root", Domain: "cms", ParentID: "auth:system", Permissions: []string{"cms.page.read"}},
+		{ID: "cms:pages", Domain: "cms", ParentID: "cms:root", Permissions: []string{"cms.page.missing"}},
+		{ID: "cms:pages", Domain: "cms", ParentID: "cms:root"},
+		{ID: "cms:lost", Domain: "cms", ParentID: "cms:nowhere"},
+		{ID: "cms:self", Domain: "cms", ParentID: "cms:self"},
+		{ID: "auth:system", Domain: "cms"},
+		{ID: "cms:media", Domain: "cms", Labels: map[string]string{"not a locale": "Media"}},
+		{ID: "cms:footer", Domain: "cms", Placement: "footer"},
+		{ID: "cms:new", Domain: "cms", ParentID: "cms:root", Placement: menu.PlacementQuickActions},
+		{ID: "cms:beta", Domain: "cms", RequiredFlag: "Beta Editor"},
+	}
+	known := map[string]bool{"cms.page.read": true}
+
+	got := make(map[string]bool)
+	for _, p := range validateMenuDefinitions(defs, others, known) {
+		got[p.MenuID+" "+string(p.Kind)] = p.Blocking
+	}
+	want := map[string]bool{
+		"cms:pages unknown_permission": false,
+		"cms:pages duplicate_id":       true,
+		"cms:lost orphan":              false,
+		"cms:self cycle":               true,
+		"auth:system duplicate_id":     true,
+		"cms:media invalid_locale":     true,
+		"cms:footer invalid_placement": true,
+		"cms:new invalid_placement":    true,
+		"cms:beta invalid_flag":        true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected problems:\n got  %v\n want %v", got, want)
 	}
 }
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+const passkeyCeremonyTTL = 5 * time.Minute
+
+// webAuthnUser adapts a user and their passkeys to the webauthn.User interface.
+type webAuthnUser struct {
+	user     *domain.User
+	passkeys []domain.Passkey
+}
+
+func (u webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.FullName
+}
+
+func (u webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, len(u.passkeys))
+	for i, p := range u.passkeys {
+		transports := make([]protocol.AuthenticatorTransport, len(p.Transports))
+		for j, t := range p.Transports {
+			transports[j] = protocol.AuthenticatorTransport(t)
+		}
+		creds[i] = webauthn.Credential{
+			ID:              p.CredentialID,
+			PublicKey:       p.PublicKey,
+			AttestationType: p.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				UserPresent:    true,
+				BackupEligible: p.BackupEligible,
+				BackupState:    p.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    p.AAGUID,
+				SignCount: p.SignCount,
+			},
+		}
+	}
+	return creds
+}
+
+func (u webAuthnUser) passkeyByCredentialID(credentialID []byte) (domain.Passkey, bool) {
+	for _, p := range u.passkeys {
+		if string(p.CredentialID) == string(credentialID) {
+			return p, true
+		}
+	}
+	return domain.Passkey{}, false
+}
+
+func (a authService) BeginPasskeyRegistration(ctx context.Context, userID uuid.UUID) (domain.PasskeyChallenge, error) {
+	if a.webAuthn == nil {
+		return domain.PasskeyChallenge{}, domain.ErrPasskeysDisabled
+	}
+
+	waUser, err := a.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return domain.PasskeyChallenge{}, err
+	}
+
+	creation, session, err := a.webAuthn.BeginRegistration(waUser,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(webauthn.Credentials(waUser.WebAuthnCredentials()).CredentialDescriptors()),
+	)
+	if err != nil {
+		return domain.PasskeyChallenge{}, err
+	}
+
+	return a.startCeremony(ctx, &userID, domain.PasskeyCeremonyRegistration, session, creation)
+}
+
+func (a authService) FinishPasskeyRegistration(ctx context.Context, userID, ceremonyID uuid.UUID, name string, credential []byte) (*domain.Passkey, error) {
+	if a.webAuthn == nil {
+		return nil, domain.ErrPasskeysDisabled
+	}
+
+	session, ceremony, err := a.finishCeremony(ctx, ceremonyID, domain.PasskeyCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID == nil || *ceremony.UserID != userID {
+		return nil, httputil.ErrUnauthorized
+	}
+
+	waUser, err := a.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(credential)
+	if err != nil {
+		return nil, httputil.ErrBadRequest
+	}
+	cred, err := a.webAuthn.CreateCredential(waUser, session, parsed)
+	if err != nil {
+		return nil, httputil.ErrUnauthorized
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	transports := make([]string, len(cred.Transport))
+	for i, t := range cred.Transport {
+		transports[i] = string(t)
+	}
+
+	passkey := &domain.Passkey{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    cred.ID,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  cred.Flags.BackupEligible,
+		BackupState:     cred.Flags.BackupState,
+	}
+	if err := a.repo.CreatePasskey(ctx, passkey); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthPasskeyRegisteredData{
+		Trail:     events.NewTrail(ctx, userTarget(userID), nil, map[string]string{"passkey_id": passkey.ID.String(), "name": name}),
+		UserID:    userID,
+		PasskeyID: passkey.ID,
+		Name:      name,
+	}
+	a.publish(events.AuthPasskeyRegistered, event)
+	return passkey, nil
+}
+
+func (a authService) GetPasskeys(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	return a.repo.GetPasskeysByUserID(ctx, userID)
+}
+
+func (a authService) DeletePasskey(ctx context.Context, userID, passkeyID uuid.UUID) error {
+	if err := a.repo.DeletePasskey(ctx, userID, passkeyID); err != nil {
+		return err
+	}
+
+	event := events.AuthPasskeyDeletedData{
+		Trail:     events.NewTrail(ctx, userTarget(userID), map[string]string{"passkey_id": passkeyID.String()}, nil),
+		UserID:    userID,
+		PasskeyID: passkeyID,
+	}
+	a.publish(events.AuthPasskeyDeleted, event)
+	return nil
+}
+
+// BeginPasskeyLogin starts a discoverable (usernameless) login: the authenticator picks the account.
+func (a authService) BeginPasskeyLogin(ctx context.Context) (domain.PasskeyChallenge, error) {
+	if a.webAuthn == nil {
+		return domain.PasskeyChallenge{}, domain.ErrPasskeysDisabled
+	}
+
+	assertion, session, err := a.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return domain.PasskeyChallenge{}, err
+	}
+
+	return a.startCeremony(ctx, nil, domain.PasskeyCeremonyLogin, session, assertion)
+}
+
+func (a authService) FinishPasskeyLogin(ctx context.Context, ceremonyID uuid.UUID, credential []byte) (domain.AuthTokens, error) {
+	if a.webAuthn == nil {
+		return domain.AuthTokens{}, domain.ErrPasskeysDisabled
+	}
+
+	session, _, err := a.finishCeremony(ctx, ceremonyID, domain.PasskeyCeremonyLogin)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credential)
+	if err != nil {
+		return domain.AuthTokens{}, httputil.ErrBadRequest
+	}
+
+	var waUser webAuthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, httputil.ErrUnauthorized
+		}
+		waUser, err = a.loadWebAuthnUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return waUser, nil
+	}
+
+	cred, err := a.webAuthn.ValidateDiscoverableLogin(handler, session, parsed)
+	if err != nil {
+		var userID *uuid.UUID
+		if waUser.user != nil {
+			userID = &waUser.user.ID
+		}
+		a.publishLoginFailed(ctx, userID, "", events.LoginMethodPasskey, "invalid_assertion")
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
+	if err := a.recordPasskeyUse(ctx, waUser, cred); err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	return a.createSession(ctx, waUser.user.ID, events.LoginMethodPasskey)
+}
+
+func (a authService) FinishPasskeySecondFactor(ctx context.Context, ceremonyID uuid.UUID, credential []byte) (domain.AuthTokens, error) {
+	if a.webAuthn == nil {
+		return domain.AuthTokens{}, domain.ErrPasskeysDisabled
+	}
+
+	session, ceremony, err := a.finishCeremony(ctx, ceremonyID, domain.PasskeyCeremonySecondFactor, domain.PasskeyCeremonyMagicLinkFactor)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	if ceremony.UserID == nil {
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
+
+	waUser, err := a.loadWebAuthnUser(ctx, *ceremony.UserID)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(credential)
+	if err != nil {
+		return domain.AuthTokens{}, httputil.ErrBadRequest
+	}
+	cred, err := a.webAuthn.ValidateLogin(waUser, session, parsed)
+	if err != nil {
+		a.publishLoginFailed(ctx, &waUser.user.ID, waUser.user.Email, events.LoginMethodPasskey, "invalid_assertion")
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
+	if err := a.recordPasskeyUse(ctx, waUser, cred); err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	// Both factors were verified; the session is attributed to the first one.
+	method := events.LoginMethodPassword
+	if ceremony.Purpose == domain.PasskeyCeremonyMagicLinkFactor {
+		method = events.LoginMethodMagicLink
+	}
+	return a.createSession(ctx, waUser.user.ID, method)
+}
+
+// beginSecondFactor is called by the password and magic-link logins once their factor is
+// verified, with purpose recording which one it was. It returns nil when the user has no
+// passkeys, and a SecondFactorRequiredError carrying the assertion challenge otherwise.
+func (a authService) beginSecondFactor(ctx context.Context, u *domain.User, purpose domain.PasskeyCeremonyPurpose) error {
+	if a.webAuthn == nil {
+		return nil
+	}
+
+	passkeys, err := a.repo.GetPasskeysByUserID(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if len(passkeys) == 0 {
+		return nil
+	}
+
+	waUser := webAuthnUser{user: u, passkeys: passkeys}
+	assertion, session, err := a.webAuthn.BeginLogin(waUser)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := a.startCeremony(ctx, &u.ID, purpose, session, assertion)
+	if err != nil {
+		return err
+	}
+	return &domain.SecondFactorRequiredError{Challenge: challenge}
+}
+
+func (a authService) loadWebAuthnUser(ctx context.Context, userID uuid.UUID) (webAuthnUser, error) {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return webAuthnUser{}, err
+	}
+	passkeys, err := a.repo.GetPasskeysByUserID(ctx, userID)
+	if err != nil {
+		return webAuthnUser{}, err
+	}
+	return webAuthnUser{user: u, passkeys: passkeys}, nil
+}
+
+func (a authService) startCeremony(ctx context.Context, userID *uuid.UUID, purpose domain.PasskeyCeremonyPurpose, session *webauthn.SessionData, options any) (domain.PasskeyChallenge, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return domain.PasskeyChallenge{}, err
+	}
+
+	ceremony := &domain.PasskeyCeremony{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Purpose:     purpose,
+		SessionData: data,
+		ExpiresAt:   time.Now().Add(passkeyCeremonyTTL),
+	}
+	if err := a.repo.CreatePasskeyCeremony(ctx, ceremony); err != nil {
+		return domain.PasskeyChallenge{}, err
+	}
+
+	return domain.PasskeyChallenge{CeremonyID: ceremony.ID, Options: options}, nil
+}
+
+func (a authService) finishCeremony(ctx context.Context, ceremonyID uuid.UUID, purposes ...domain.PasskeyCeremonyPurpose) (webauthn.SessionData, *domain.PasskeyCeremony, error) {
+	ceremony, err := a.repo.ConsumePasskeyCeremony(ctx, ceremonyID, purposes...)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return webauthn.SessionData{}, nil, httputil.ErrUnauthorized
+		}
+		return webauthn.SessionData{}, nil, err
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(ceremony.SessionData, &session); err != nil {
+		return webauthn.SessionData{}, nil, err
+	}
+	return session, ceremony, nil
+}
+
+// recordPasskeyUse persists the new sign count. A counter that fails to increase signals a cloned
+// authenticator, in which case the login is refused.
+func (a authService) recordPasskeyUse(ctx context.Context, u webAuthnUser, cred *webauthn.Credential) error {
+	if cred.Authenticator.CloneWarning {
+		a.publishLoginFailed(ctx, &u.user.ID, u.user.Email, events.LoginMethodPasskey, "cloned_authenticator")
+		return httputil.ErrUnauthorized
+	}
+	passkey, ok := u.passkeyByCredentialID(cred.ID)
+	if !ok {
+		return httputil.ErrUnauthorized
+	}
+	return a.repo.UpdatePasskeyUsage(ctx, passkey.ID, cred.Authenticator.SignCount, cred.Flags.BackupState)
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "https://localhost"
+)
+
+// passkeyRepo is an in-memory stand-in for the parts of domain.Repository the passkey flows use.
+type passkeyRepo struct {
+	domain.Repository
+	users      map[uuid.UUID]*domain.User
+	passkeys   []domain.Passkey
+	ceremonies map[uuid.UUID]domain.PasskeyCeremony
+	sessions   []domain.Session
+}
+
+func newPasskeyRepo(users ...*domain.User) *passkeyRepo {
+	r := &passkeyRepo{
+		users:      make(map[uuid.UUID]*domain.User),
+		ceremonies: make(map[uuid.UUID]domain.PasskeyCeremony),
+	}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *passkeyRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	u, ok := r.users[id]
+	if !ok {
+		return nil, httputil.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *passkeyRepo) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, httputil.ErrNotFound
+}
+
+func (r *passkeyRepo) CreatePasskey(_ context.Context, p *domain.Passkey) error {
+	r.passkeys = append(r.passkeys, *p)
+	return nil
+}
+
+func (r *passkeyRepo) GetPasskeysByUserID(_ context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	var out []domain.Passkey
+	for _, p := range r.passkeys {
+		if p.UserID == userID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (r *passkeyRepo) UpdatePasskeyUsage(_ context.Context, id uuid.UUID, signCount uint32, backupState bool) error {
+	for i := range r.passkeys {
+		if r.passkeys[i].ID == id {
+			r.passkeys[i].SignCount = signCount
+			r.passkeys[i].BackupState = backupState
+		}
+	}
+	return nil
+}
+
+func (r *passkeyRepo) CreatePasskeyCeremony(_ context.Context, c *domain.PasskeyCeremony) error {
+	r.ceremonies[c.ID] = *c
+	return nil
+}
+
+func (r *passkeyRepo) ConsumePasskeyCeremony(_ context.Context, id uuid.UUID, purposes ...domain.PasskeyCeremonyPurpose) (*domain.PasskeyCeremony, error) {
+	c, ok := r.ceremonies[id]
+	if !ok || !slices.Contains(purposes, c.Purpose) || time.Now().After(c.ExpiresAt) {
+		return nil, httputil.ErrNotFound
+	}
+	delete(r.ceremonies, id)
+	return &c, nil
+}
+
+func (r *passkeyRepo) CreateSession(_ context.Context, s *domain.Session) error {
+	r.sessions = append(r.sessions, *s)
+	return nil
+}
+
+// softwareAuthenticator emulates a platform authenticator holding a single ES256 passkey.
+type softwareAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	_, _ = rand.Read(credentialID)
+	return &softwareAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (a *softwareAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+func clientData(t *testing.T, ceremonyType string, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"type":      ceremonyType,
+		"challenge": challenge.String(),
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// register answers navigator.credentials.create() with a "none" attestation.
+func (a *softwareAuthenticator) register(t *testing.T, options any) []byte {
+	t.Helper()
+	creation, ok := options.(*protocol.CredentialCreation)
+	if !ok {
+		t.Fatalf("unexpected registration options type %T", options)
+	}
+
+	pub, err := a.key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	point := pub.Bytes() // 0x04 || X || Y
+	coseKey, err := cbor.Marshal(map[int]any{1: 2, 3: -7, -1: 1, -2: point[1:33], -3: point[33:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(0x45, attested), // UP | UV | AT
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    b64(clientData(t, "webauthn.create", creation.Response.Challenge)),
+		"attestationObject": b64(attestationObject),
+	})
+}
+
+// assert answers navigator.credentials.get() for the given user handle.
+func (a *softwareAuthenticator) assert(t *testing.T, options any, userHandle []byte) []byte {
+	t.Helper()
+	assertion, ok := options.(*protocol.CredentialAssertion)
+	if !ok {
+		t.Fatalf("unexpected login options type %T", options)
+	}
+
+	a.signCount++
+	authData := a.authData(0x05, nil) // UP | UV
+	cd := clientData(t, "webauthn.get", assertion.Response.Challenge)
+	cdHash := sha256.Sum256(cd)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), cdHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credentialJSON(t, map[string]string{
+		"clientDataJSON":    b64(cd),
+		"authenticatorData": b64(authData),
+		"signature":         b64(sig),
+		"userHandle":        b64(userHandle),
+	})
+}
+
+func (a *softwareAuthenticator) credentialJSON(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newPasskeyTestService(t *testing.T) (domain.Service, *passkeyRepo, *domain.User) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &domain.User{ID: uuid.New(), Email: "admin@example.com", FullName: "Admin", PasswordHash: string(hash)}
+	repo := newPasskeyRepo(user)
+	svc := NewAuthService(repo, nil, nil, nil, Config{
+		JWTSecret:         "test-secret",
+		WebAuthnRPID:      testRPID,
+		WebAuthnRPName:    "Test",
+		WebAuthnRPOrigins: []string{testOrigin},
+	})
+	return svc, repo, user
+}
+
+func registerPasskey(t *testing.T, svc domain.Service, user *domain.User, authenticator *softwareAuthenticator) *domain.Passkey {
+	t.Helper()
+	ctx := context.Background()
+
+	challenge, err := svc.BeginPasskeyRegistration(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+	passkey, err := svc.FinishPasskeyRegistration(ctx, user.ID, challenge.CeremonyID, "Laptop", authenticator.register(t, challenge.Options))
+	if err != nil {
+		t.Fatalf("finish registration: %v", err)
+	}
+	return passkey
+}
+
+func TestPasskeyRegistrationAndPasswordlessLogin(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, user := newPasskeyTestService(t)
+	authenticator := newSoftwareAuthenticator(t)
+
+	passkey := registerPasskey(t, svc, user, authenticator)
+	if passkey.Name != "Laptop" || string(passkey.CredentialID) != string(authenticator.credentialID) {
+		t.Fatalf("unexpected passkey: %#v", passkey)
+	}
+
+	challenge, err := svc.BeginPasskeyLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	credential := authenticator.assert(t, challenge.Options, user.ID[:])
+
+	tokens, err := svc.FinishPasskeyLogin(ctx, challenge.CeremonyID, credential)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	if tokens.AccessToken == "" || len(repo.sessions) != 1 || repo.sessions[0].UserID != user.ID {
+		t.Fatalf("expected a session for %s, got %#v", user.ID, repo.sessions)
+	}
+	if repo.passkeys[0].SignCount != 1 {
+		t.Fatalf("expected sign count to be persisted, got %d", repo.passkeys[0].SignCount)
+	}
+
+	// The ceremony is single-use, so replaying the same assertion must fail.
+	if _, err := svc.FinishPasskeyLogin(ctx, challenge.CeremonyID, credential); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected replay to be rejected, got %v", err)
+	}
+}
+
+func TestPasskeyRejectsClonedAuthenticator(t *testing.T) {
+	ctx := context.Background()
+	svc, _, user := newPasskeyTestService(t)
+	authenticator := newSoftwareAuthenticator(t)
+	registerPasskey(t, svc, user, authenticator)
+
+	for i := 0; i < 2; i++ {
+		challenge, err := svc.BeginPasskeyLogin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			// A clone replays an older counter value.
+			authenticator.signCount = 0
+		}
+		_, err = svc.FinishPasskeyLogin(ctx, challenge.CeremonyID, authenticator.assert(t, challenge.Options, user.ID[:]))
+		if i == 0 && err != nil {
+			t.Fatalf("first login: %v", err)
+		}
+		if i == 1 && !errors.Is(err, httputil.ErrUnauthorized) {
+			t.Fatalf("expected cloned authenticator to be rejected, got %v", err)
+		}
+	}
+}
+
+func TestPasskeyAsSecondFactor(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, user := newPasskeyTestService(t)
+	authenticator := newSoftwareAuthenticator(t)
+	registerPasskey(t, svc, user, authenticator)
+
+	_, err := svc.Login(ctx, user.Email, "password123")
+	var secondFactor *domain.SecondFactorRequiredError
+	if !errors.As(err, &secondFactor) {
+		t.Fatalf("expected second factor to be required, got %v", err)
+	}
+	if len(repo.sessions) != 0 {
+		t.Fatal("no session must be opened before the second factor")
+	}
+
+	credential := authenticator.assert(t, secondFactor.Challenge.Options, user.ID[:])
+	tokens, err := svc.FinishPasskeySecondFactor(ctx, secondFactor.Challenge.CeremonyID, credential)
+	if err != nil {
+		t.Fatalf("finish second factor: %v", err)
+	}
+	if tokens.AccessToken == "" || len(repo.sessions) != 1 {
+		t.Fatal("expected a session after the second factor")
+	}
+}
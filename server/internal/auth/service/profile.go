@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	// Embedded so timezone validation does not depend on the host's zoneinfo files.
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/language"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+// avatarExtensions lists the accepted avatar content types and the extension they are stored with.
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func (a authService) UpdateProfile(ctx context.Context, userID uuid.UUID, update domain.ProfileUpdate) (*domain.User, error) {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := a.profileSnapshot(u)
+
+	var fields []string
+	if update.FullName != nil {
+		name := strings.TrimSpace(*update.FullName)
+		if name == "" {
+			return nil, httputil.ErrBadRequest
+		}
+		if name != u.FullName {
+			u.FullName = name
+			fields = append(fields, "full_name")
+		}
+	}
+	if update.Locale != nil {
+		tag, err := language.Parse(strings.TrimSpace(*update.Locale))
+		if err != nil {
+			return nil, domain.ErrInvalidLocale
+		}
+		if locale := tag.String(); locale != u.Locale {
+			u.Locale = locale
+			fields = append(fields, "locale")
+		}
+	}
+	if update.Timezone != nil {
+		tz := strings.TrimSpace(*update.Timezone)
+		// An empty name and "Local" resolve to the server's zone, which is never what a user means.
+		if _, err := time.LoadLocation(tz); err != nil || tz == "" || tz == "Local" {
+			return nil, domain.ErrInvalidTimezone
+		}
+		if tz != u.Timezone {
+			u.Timezone = tz
+			fields = append(fields, "timezone")
+		}
+	}
+
+	if len(fields) == 0 {
+		return a.withAvatarURL(u), nil
+	}
+	if err := a.repo.UpdateUserProfile(ctx, u); err != nil {
+		return nil, err
+	}
+	a.publishUserUpdated(ctx, u, before, fields)
+	return a.withAvatarURL(u), nil
+}
+
+func (a authService) UpdateAvatar(ctx context.Context, userID uuid.UUID, contentType string, r io.Reader) (*domain.User, error) {
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return nil, domain.ErrUnsupportedAvatar
+	}
+
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := a.profileSnapshot(u)
+	previousKey := u.AvatarKey
+
+	// A fresh key per upload keeps cached copies of the old avatar from being served as the new one.
+	key := fmt.Sprintf("avatars/%s/%s%s", userID, uuid.New(), ext)
+	if err := a.media.Put(ctx, key, contentType, r); err != nil {
+		return nil, err
+	}
+
+	u.AvatarKey = key
+	if err := a.repo.UpdateUserProfile(ctx, u); err != nil {
+		a.deleteMedia(ctx, key)
+		return nil, err
+	}
+	if previousKey != "" {
+		a.deleteMedia(ctx, previousKey)
+	}
+
+	a.publishUserUpdated(ctx, u, before, []string{"avatar_url"})
+	return a.withAvatarURL(u), nil
+}
+
+func (a authService) DeleteAvatar(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.AvatarKey == "" {
+		return a.withAvatarURL(u), nil
+	}
+	before := a.profileSnapshot(u)
+	previousKey := u.AvatarKey
+
+	u.AvatarKey = ""
+	if err := a.repo.UpdateUserProfile(ctx, u); err != nil {
+		return nil, err
+	}
+	a.deleteMedia(ctx, previousKey)
+
+	a.publishUserUpdated(ctx, u, before, []string{"avatar_url"})
+	return a.withAvatarURL(u), nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The account keeps its current
+// address until the link is used.
+func (a authService) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+	addr, err := mail.ParseAddress(newEmail)
+	if err != nil || addr.Address != newEmail {
+		return domain.ErrInvalidEmail
+	}
+
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(u.Email, newEmail) {
+		return httputil.ErrBadRequest
+	}
+	if _, err := a.repo.GetUserByEmail(ctx, newEmail); err == nil {
+		return domain.ErrEmailTaken
+	} else if !errors.Is(err, httputil.ErrNotFound) {
+		return err
+	}
+
+	token, err := generateSecret()
+	if err != nil {
+		return err
+	}
+	change := &domain.EmailChange{
+		ID:        uuid.New(),
+		UserID:    userID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+	if err := a.repo.CreateEmailChange(ctx, change); err != nil {
+		return err
+	}
+
+	confirmURL := fmt.Sprintf("%s/account/confirm-email?token=%s", a.appBaseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nConfirm that you want to use this address for your account by opening the link below. It expires in %d hours.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+		u.FullName, int(emailChangeTTL.Hours()), confirmURL)
+	if err := a.mailer.Send(ctx, newEmail, "Confirm your new email address", body); err != nil {
+		return err
+	}
+
+	notice := fmt.Sprintf("Hi %s,\n\nA request was made to change the email address of your account to %s. Nothing changes until the new address is confirmed.\n\nIf this was not you, change your password.\n",
+		u.FullName, newEmail)
+	if err := a.mailer.Send(ctx, u.Email, "Email change requested", notice); err != nil {
+		slog.Warn("failed to notify previous email address", "user_id", userID, "error", err)
+	}
+	return nil
+}
+
+func (a authService) ConfirmEmailChange(ctx context.Context, token string) (*domain.User, error) {
+	if token == "" {
+		return nil, httputil.ErrUnauthorized
+	}
+
+	change, err := a.repo.ConfirmEmailChange(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return nil, httputil.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	u, err := a.repo.GetUserByID(ctx, change.UserID)
+	if err != nil {
+		return nil, err
+	}
+	before := a.profileSnapshot(u)
+	before["email"] = change.PreviousEmail
+
+	a.publishUserUpdated(ctx, u, before, []string{"email"})
+	return a.withAvatarURL(u), nil
+}
+
+// DeleteAccount permanently removes the caller's account after re-checking their password.
+func (a authService) DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return httputil.ErrUnauthorized
+	}
+
+	if err := a.repo.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	if u.AvatarKey != "" {
+		a.deleteMedia(ctx, u.AvatarKey)
+	}
+
+	event := events.AuthUserDeletedData{
+		Trail:  events.NewTrail(ctx, userTarget(userID), a.profileSnapshot(u), nil),
+		UserID: userID,
+		Email:  u.Email,
+	}
+	a.publish(events.AuthUserDeleted, event)
+	return nil
+}
+
+func (a authService) publishUserUpdated(ctx context.Context, u *domain.User, before map[string]string, fields []string) {
+	event := events.AuthUserUpdatedData{
+		Trail:  events.NewTrail(ctx, userTarget(u.ID), before, a.profileSnapshot(u)),
+		UserID: u.ID,
+		Fields: fields,
+	}
+	a.publish(events.AuthUserUpdated, event)
+}
+
+// profileSnapshot is the audit representation of a user's editable profile.
+func (a authService) profileSnapshot(u *domain.User) map[string]string {
+	return map[string]string{
+		"email":      u.Email,
+		"full_name":  u.FullName,
+		"locale":     u.Locale,
+		"timezone":   u.Timezone,
+		"avatar_url": a.avatarURL(u.AvatarKey),
+	}
+}
+
+func (a authService) withAvatarURL(u *domain.User) *domain.User {
+	u.AvatarURL = a.avatarURL(u.AvatarKey)
+	return u
+}
+
+func (a authService) avatarURL(key string) string {
+	if key == "" || a.media == nil {
+		return ""
+	}
+	return a.media.URL(key)
+}
+
+// deleteMedia removes a stored file. Failures only leave an orphaned file behind, so they are logged.
+func (a authService) deleteMedia(ctx context.Context, key string) {
+	if err := a.media.Delete(ctx, key); err != nil {
+		slog.Warn("failed to delete media", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// profileRepo is an in-memory stand-in for the user parts of domain.Repository.
+type profileRepo struct {
+	domain.Repository
+	user    domain.User
+	deleted bool
+}
+
+func (r *profileRepo) GetUserByID(context.Context, uuid.UUID) (*domain.User, error) {
+	u := r.user
+	return &u, nil
+}
+
+func (r *profileRepo) UpdateUserProfile(_ context.Context, u *domain.User) error {
+	r.user = *u
+	return nil
+}
+
+func (r *profileRepo) DeleteUser(context.Context, uuid.UUID) error {
+	r.deleted = true
+	return nil
+}
+
+func TestUpdateProfileNormalizesAndValidates(t *testing.T) {
+	ctx := context.Background()
+	repo := &profileRepo{user: domain.User{ID: uuid.New(), FullName: "Ada", Locale: "en", Timezone: "UTC"}}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	locale, tz := "pt-br", "Europe/Lisbon"
+	u, err := svc.UpdateProfile(ctx, repo.user.ID, domain.ProfileUpdate{Locale: &locale, Timezone: &tz})
+	if err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	if u.Locale != "pt-BR" || repo.user.Timezone != "Europe/Lisbon" {
+		t.Fatalf("unexpected profile: %#v", repo.user)
+	}
+
+	for _, bad := range []string{"", "Local", "Mars/Olympus"} {
+		if _, err := svc.UpdateProfile(ctx, repo.user.ID, domain.ProfileUpdate{Timezone: &bad}); !errors.Is(err, domain.ErrInvalidTimezone) {
+			t.Fatalf("timezone %q: expected ErrInvalidTimezone, got %v", bad, err)
+		}
+	}
+
+	blank := "  "
+	if _, err := svc.UpdateProfile(ctx, repo.user.ID, domain.ProfileUpdate{FullName: &blank}); err == nil {
+		t.Fatal("expected a blank full name to be rejected")
+	}
+}
+
+func TestDeleteAccountRequiresPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &profileRepo{user: domain.User{ID: uuid.New(), PasswordHash: string(hash)}}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	if err := svc.DeleteAccount(context.Background(), repo.user.ID, "wrong"); err == nil || repo.deleted {
+		t.Fatal("expected a wrong password to be rejected")
+	}
+	if err := svc.DeleteAccount(context.Background(), repo.user.ID, "correct horse"); err != nil || !repo.deleted {
+		t.Fatalf("expected the account to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// allowlistRepo records the role allowlist and the address permissions are evaluated for.
+type allowlistRepo struct {
+	domain.Repository
+	cidrs    []netip.Prefix
+	clientIP netip.Addr
+}
+
+func (r *allowlistRepo) GetRoles(context.Context) ([]domain.Role, error) {
+	return []domain.Role{{ID: 1, Name: "Admin", AllowedCIDRs: r.cidrs}}, nil
+}
+
+func (r *allowlistRepo) UpdateRoleAllowedCIDRs(_ context.Context, _ int, cidrs []netip.Prefix) error {
+	r.cidrs = cidrs
+	return nil
+}
+
+func (r *allowlistRepo) GetUserPermissions(_ context.Context, _ uuid.UUID, clientIP netip.Addr) ([]string, error) {
+	r.clientIP = clientIP
+	return nil, nil
+}
+
+func (r *allowlistRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return nil, nil
+}
+
+func (r *allowlistRepo) GetUserByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return &domain.User{ID: id, Locale: "en"}, nil
+}
+
+func (r *allowlistRepo) GetMenuOverrides(context.Context) ([]domain.MenuOverride, error) {
+	return nil, nil
+}
+
+func (r *allowlistRepo) GetMenuPreferences(context.Context, uuid.UUID) (*domain.MenuPreferences, error) {
+	return &domain.MenuPreferences{}, nil
+}
+
+func TestSetRoleAllowedCIDRsNormalizes(t *testing.T) {
+	repo := &allowlistRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	role, err := svc.SetRoleAllowedCIDRs(context.Background(), 1, []string{"10.1.2.3/8", " 192.0.2.10 ", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("set allowed cidrs: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.10/32", "2001:db8::/32"}
+	for i, p := range role.AllowedCIDRs {
+		if p.String() != want[i] {
+			t.Fatalf("expected %v, got %v", want, role.AllowedCIDRs)
+		}
+	}
+
+	if _, err := svc.SetRoleAllowedCIDRs(context.Background(), 1, []string{"office"}); !errors.Is(err, domain.ErrInvalidCIDR) {
+		t.Fatalf("expected ErrInvalidCIDR, got %v", err)
+	}
+}
+
+func TestGetUserPermissionsUsesRequestIP(t *testing.T) {
+	repo := &allowlistRepo{}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	ip := netip.MustParseAddr("203.0.113.7")
+	if _, err := svc.GetMyMenu(domain.WithClientIP(context.Background(), ip), uuid.New()); err != nil {
+		t.Fatal(err)
+	}
+	if repo.clientIP != ip {
+		t.Fatalf("expected permissions to be evaluated for %s, got %s", ip, repo.clientIP)
+	}
+}
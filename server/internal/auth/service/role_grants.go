@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// maxElevationMinutes caps how long an approved elevation request may last (12 hours).
+const maxElevationMinutes = 12 * 60
+
+// AssignRole grants roleID to the user. validFrom defaults to now and a nil validUntil makes
+// the grant permanent.
+func (a authService) AssignRole(ctx context.Context, userID uuid.UUID, roleID int, validFrom, validUntil *time.Time) error {
+	grant := &domain.RoleGrant{
+		UserID:     userID,
+		RoleID:     roleID,
+		ValidFrom:  time.Now(),
+		ValidUntil: validUntil,
+		GrantedBy:  actorUserID(ctx),
+	}
+	if validFrom != nil {
+		grant.ValidFrom = *validFrom
+	}
+	if validUntil != nil && !validUntil.After(grant.ValidFrom) {
+		return domain.ErrInvalidGrantWindow
+	}
+
+	if err := a.repo.AssignRoleToUser(ctx, grant); err != nil {
+		return err
+	}
+	a.publishRoleAssigned(ctx, grant)
+	return nil
+}
+
+func (a authService) RevokeRole(ctx context.Context, userID uuid.UUID, roleID int) error {
+	if err := a.repo.RemoveRoleFromUser(ctx, userID, roleID); err != nil {
+		return err
+	}
+	a.publishRoleRevoked(ctx, userID, roleID, events.RoleRevokedManually)
+	return nil
+}
+
+func (a authService) GetRoleGrants(ctx context.Context, userID uuid.UUID) ([]domain.RoleGrant, error) {
+	return a.repo.GetRoleGrantsByUserID(ctx, userID)
+}
+
+func (a authService) ExpireRoleGrants(ctx context.Context) (int, error) {
+	expired, err := a.repo.DeleteExpiredRoleGrants(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, g := range expired {
+		a.publishRoleRevoked(ctx, g.UserID, g.RoleID, events.RoleRevokedExpired)
+	}
+	return len(expired), nil
+}
+
+// RunRoleGrantSweeper expires time-bound role grants every interval until ctx is cancelled.
+// A non-positive interval disables it.
+func RunRoleGrantSweeper(ctx context.Context, svc domain.Service, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.ExpireRoleGrants(ctx)
+			if err != nil {
+				slog.Error("role grant sweep failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				slog.Info("expired role grants", "count", n)
+			}
+		}
+	}
+}
+
+func (a authService) RequestElevation(ctx context.Context, userID uuid.UUID, roleID int, reason string, durationMinutes int) (*domain.ElevationRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || durationMinutes <= 0 {
+		return nil, httputil.ErrBadRequest
+	}
+	if durationMinutes > maxElevationMinutes {
+		return nil, domain.ErrElevationTooLong
+	}
+	if err := a.ensureRoleExists(ctx, roleID); err != nil {
+		return nil, err
+	}
+
+	req := &domain.ElevationRequest{
+		ID:              uuid.New(),
+		UserID:          userID,
+		RoleID:          roleID,
+		Reason:          reason,
+		DurationMinutes: durationMinutes,
+		Status:          domain.ElevationPending,
+	}
+	if err := a.repo.CreateElevationRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthElevationRequestedData{
+		Trail:           events.NewTrail(ctx, elevationTarget(req.ID), nil, req),
+		RequestID:       req.ID,
+		UserID:          userID,
+		RoleID:          roleID,
+		Reason:          reason,
+		DurationMinutes: durationMinutes,
+	}
+	a.publish(events.AuthElevationRequested, event)
+	return req, nil
+}
+
+func (a authService) GetMyElevationRequests(ctx context.Context, userID uuid.UUID) ([]domain.ElevationRequest, error) {
+	return a.repo.GetElevationRequests(ctx, &userID, "")
+}
+
+func (a authService) GetElevationRequests(ctx context.Context, status domain.ElevationStatus) ([]domain.ElevationRequest, error) {
+	return a.repo.GetElevationRequests(ctx, nil, status)
+}
+
+func (a authService) ApproveElevation(ctx context.Context, requestID, approverID uuid.UUID, note string) (*domain.ElevationRequest, error) {
+	req, err := a.loadPendingElevation(ctx, requestID, approverID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	until := now.Add(time.Duration(req.DurationMinutes) * time.Minute)
+	grant := &domain.RoleGrant{
+		UserID:     req.UserID,
+		RoleID:     req.RoleID,
+		ValidFrom:  now,
+		ValidUntil: &until,
+		GrantedBy:  &approverID,
+	}
+
+	// Approval must never shorten a grant the user already holds.
+	existing, err := a.repo.GetRoleGrantsByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range existing {
+		if g.RoleID == req.RoleID && g.Active(now) && (g.ValidUntil == nil || g.ValidUntil.After(until)) {
+			grant = nil
+			break
+		}
+	}
+
+	before := *req
+	req.Status = domain.ElevationApproved
+	req.DecidedBy = &approverID
+	req.DecisionNote = strings.TrimSpace(note)
+	req.DecidedAt = &now
+	if err := a.repo.DecideElevationRequest(ctx, req, grant); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthElevationDecidedData{
+		Trail:      events.NewTrail(ctx, elevationTarget(req.ID), before, req),
+		RequestID:  req.ID,
+		UserID:     req.UserID,
+		RoleID:     req.RoleID,
+		DecidedBy:  approverID,
+		Note:       req.DecisionNote,
+		ValidUntil: &until,
+	}
+	a.publish(events.AuthElevationApproved, event)
+	if grant != nil {
+		a.publishRoleAssigned(ctx, grant)
+	}
+	return req, nil
+}
+
+func (a authService) DenyElevation(ctx context.Context, requestID, approverID uuid.UUID, note string) (*domain.ElevationRequest, error) {
+	req, err := a.loadPendingElevation(ctx, requestID, approverID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	before := *req
+	req.Status = domain.ElevationDenied
+	req.DecidedBy = &approverID
+	req.DecisionNote = strings.TrimSpace(note)
+	req.DecidedAt = &now
+	if err := a.repo.DecideElevationRequest(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	event := events.AuthElevationDecidedData{
+		Trail:     events.NewTrail(ctx, elevationTarget(req.ID), before, req),
+		RequestID: req.ID,
+		UserID:    req.UserID,
+		RoleID:    req.RoleID,
+		DecidedBy: approverID,
+		Note:      req.DecisionNote,
+	}
+	a.publish(events.AuthElevationDenied, event)
+	return req, nil
+}
+
+func (a authService) loadPendingElevation(ctx context.Context, requestID, approverID uuid.UUID) (*domain.ElevationRequest, error) {
+	req, err := a.repo.GetElevationRequestByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID == approverID {
+		return nil, domain.ErrSelfApproval
+	}
+	if req.Status != domain.ElevationPending {
+		return nil, domain.ErrElevationDecided
+	}
+	return req, nil
+}
+
+func (a authService) ensureRoleExists(ctx context.Context, roleID int) error {
+	_, err := a.findRole(ctx, roleID)
+	return err
+}
+
+func (a authService) findRole(ctx context.Context, roleID int) (*domain.Role, error) {
+	roles, err := a.repo.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roles {
+		if r.ID == roleID {
+			return &r, nil
+		}
+	}
+	return nil, httputil.ErrNotFound
+}
+
+func (a authService) publishRoleAssigned(ctx context.Context, grant *domain.RoleGrant) {
+	event := events.AuthRoleAssignedData{
+		Trail:      events.NewTrail(ctx, userTarget(grant.UserID), nil, grant),
+		UserID:     grant.UserID,
+		RoleID:     grant.RoleID,
+		ValidFrom:  grant.ValidFrom,
+		ValidUntil: grant.ValidUntil,
+	}
+	a.publish(events.AuthRoleAssigned, event)
+}
+
+func (a authService) publishRoleRevoked(ctx context.Context, userID uuid.UUID, roleID int, reason string) {
+	event := events.AuthRoleRevokedData{
+		Trail:  events.NewTrail(ctx, userTarget(userID), map[string]int{"role_id": roleID}, nil),
+		UserID: userID,
+		RoleID: roleID,
+		Reason: reason,
+	}
+	a.publish(events.AuthRoleRevoked, event)
+}
+
+// actorUserID returns the acting user recorded in ctx, if any.
+func actorUserID(ctx context.Context) *uuid.UUID {
+	id, err := uuid.Parse(events.ActorFromContext(ctx).UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
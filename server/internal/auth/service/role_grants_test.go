@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// grantRepo is an in-memory stand-in for the role grant and elevation parts of domain.Repository.
+type grantRepo struct {
+	domain.Repository
+	grants   map[int]domain.RoleGrant
+	requests map[uuid.UUID]domain.ElevationRequest
+}
+
+func (r *grantRepo) GetRoles(context.Context) ([]domain.Role, error) {
+	return []domain.Role{{ID: 1, Name: "Admin"}}, nil
+}
+
+func (r *grantRepo) GetRoleGrantsByUserID(context.Context, uuid.UUID) ([]domain.RoleGrant, error) {
+	var out []domain.RoleGrant
+	for _, g := range r.grants {
+		out = append(out, g)
+	}
+	return out, nil
+}
+
+func (r *grantRepo) CreateElevationRequest(_ context.Context, req *domain.ElevationRequest) error {
+	r.requests[req.ID] = *req
+	return nil
+}
+
+func (r *grantRepo) GetElevationRequestByID(_ context.Context, id uuid.UUID) (*domain.ElevationRequest, error) {
+	req := r.requests[id]
+	return &req, nil
+}
+
+func (r *grantRepo) DecideElevationRequest(_ context.Context, req *domain.ElevationRequest, grant *domain.RoleGrant) error {
+	r.requests[req.ID] = *req
+	if grant != nil {
+		r.grants[grant.RoleID] = *grant
+	}
+	return nil
+}
+
+func newGrantTestService() (domain.Service, *grantRepo) {
+	repo := &grantRepo{grants: map[int]domain.RoleGrant{}, requests: map[uuid.UUID]domain.ElevationRequest{}}
+	return NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"}), repo
+}
+
+func TestApproveElevationCreatesTimeBoundGrant(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newGrantTestService()
+	requester, approver := uuid.New(), uuid.New()
+
+	req, err := svc.RequestElevation(ctx, requester, 1, "on-call incident", 120)
+	if err != nil {
+		t.Fatalf("request elevation: %v", err)
+	}
+
+	if _, err := svc.ApproveElevation(ctx, req.ID, requester, ""); !errors.Is(err, domain.ErrSelfApproval) {
+		t.Fatalf("expected self-approval to be rejected, got %v", err)
+	}
+
+	approved, err := svc.ApproveElevation(ctx, req.ID, approver, "ok")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.Status != domain.ElevationApproved || *approved.DecidedBy != approver {
+		t.Fatalf("unexpected decision: %#v", approved)
+	}
+
+	grant, ok := repo.grants[1]
+	if !ok || grant.ValidUntil == nil {
+		t.Fatalf("expected a time-bound grant, got %#v", repo.grants)
+	}
+	if d := grant.ValidUntil.Sub(grant.ValidFrom); d != 2*time.Hour {
+		t.Fatalf("expected a 2h grant, got %s", d)
+	}
+	if grant.Active(time.Now().Add(3 * time.Hour)) {
+		t.Fatal("grant must not be active after valid_until")
+	}
+
+	if _, err := svc.ApproveElevation(ctx, req.ID, approver, ""); !errors.Is(err, domain.ErrElevationDecided) {
+		t.Fatalf("expected second decision to be rejected, got %v", err)
+	}
+}
+
+func TestApproveElevationKeepsPermanentGrant(t *testing.T) {
+	ctx := context.Background()
+	svc, repo := newGrantTestService()
+	requester := uuid.New()
+	repo.grants[1] = domain.RoleGrant{UserID: requester, RoleID: 1, ValidFrom: time.Now().Add(-time.Hour)}
+
+	req, err := svc.RequestElevation(ctx, requester, 1, "already admin", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ApproveElevation(ctx, req.ID, uuid.New(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if repo.grants[1].ValidUntil != nil {
+		t.Fatal("approving an elevation must not shorten a permanent grant")
+	}
+}
+
+func TestRequestElevationValidatesDuration(t *testing.T) {
+	svc, _ := newGrantTestService()
+	if _, err := svc.RequestElevation(context.Background(), uuid.New(), 1, "too long", maxElevationMinutes+1); !errors.Is(err, domain.ErrElevationTooLong) {
+		t.Fatalf("expected ErrElevationTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sort"
+
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// GetRouteManifest derives route guards from the registered menu items that have a path. Items
+// hidden from the menu are listed too: hiding an entry does not make its page public.
+func (a authService) GetRouteManifest(ctx context.Context) (*domain.RouteManifest, error) {
+	defs, err := a.repo.GetMenuDefinitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	routes := routeGuards(defs)
+	return &domain.RouteManifest{Routes: routes, Version: versionHash(routes)}, nil
+}
+
+func routeGuards(defs []domain.MenuDefinition) []domain.RouteGuard {
+	byID := make(map[string]domain.MenuDefinition, len(defs))
+	for _, d := range defs {
+		byID[d.ID] = d
+	}
+
+	routes := make([]domain.RouteGuard, 0, len(defs))
+	for _, d := range defs {
+		if d.Path == "" {
+			continue
+		}
+		// Walk up to the root; visited guards against cycles stored before validation existed.
+		var chain [][]string
+		visited := make(map[string]bool)
+		for cur, ok := d, true; ok && !visited[cur.ID]; cur, ok = byID[cur.ParentID] {
+			visited[cur.ID] = true
+			if len(cur.Permissions) == 0 {
+				continue
+			}
+			group := slices.Clone(cur.Permissions)
+			sort.Strings(group)
+			if !slices.ContainsFunc(chain, func(g []string) bool { return slices.Equal(g, group) }) {
+				chain = append(chain, group)
+			}
+		}
+		slices.Reverse(chain)
+		if chain == nil {
+			chain = [][]string{}
+		}
+		routes = append(routes, domain.RouteGuard{MenuID: d.ID, Domain: d.Domain, Path: d.Path, Requires: chain})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].MenuID < routes[j].MenuID
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes
+}
+
+// versionHash fingerprints v's JSON encoding, so equal content always yields the same version.
+func versionHash(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// manifestRepo serves a fixed permission set and menu.
+type manifestRepo struct {
+	domain.Repository
+	perms []string
+}
+
+func (r *manifestRepo) GetUserPermissions(context.Context, uuid.UUID, netip.Addr) ([]string, error) {
+	return r.perms, nil
+}
+
+func (r *manifestRepo) GetMenuDefinitions(context.Context) ([]domain.MenuDefinition, error) {
+	return []domain.MenuDefinition{
+		{ID: "cms", Domain: "cms", Label: "CMS", Visible: true, Permissions: []string{"cms.page.read"}},
+		{ID: "cms:pages", Domain: "cms", ParentID: "cms", Label: "Pages", Path: "/cms/pages", Visible: true, Permissions: []string{"cms.page.read"}},
+		{ID: "cms:settings", Domain: "cms", ParentID: "cms", Label: "Settings", Path: "/cms/settings", Visible: false, Permissions: []string{"cms.settings.write", "cms.admin"}},
+		{ID: "home", Domain: "auth", Label: "Home", Path: "/", Visible: true},
+	}, nil
+}
+
+func TestGetRouteManifest(t *testing.T) {
+	svc := NewAuthService(&manifestRepo{}, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	manifest, err := svc.GetRouteManifest(context.Background())
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	want := []domain.RouteGuard{
+		{MenuID: "home", Domain: "auth", Path: "/", Requires: [][]string{}},
+		{MenuID: "cms:pages", Domain: "cms", Path: "/cms/pages", Requires: [][]string{{"cms.page.read"}}},
+		{MenuID: "cms:settings", Domain: "cms", Path: "/cms/settings", Requires: [][]string{{"cms.page.read"}, {"cms.admin", "cms.settings.write"}}},
+	}
+	if !reflect.DeepEqual(manifest.Routes, want) {
+		t.Fatalf("unexpected routes:\n got %#v\nwant %#v", manifest.Routes, want)
+	}
+	if manifest.Version == "" {
+		t.Fatal("expected a version")
+	}
+}
+
+func TestGetMyPermissionsVersion(t *testing.T) {
+	repo := &manifestRepo{perms: []string{"b", "a", "b"}}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	first, err := svc.GetMyPermissions(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("permissions: %v", err)
+	}
+	if !reflect.DeepEqual(first.Permissions, []string{"a", "b"}) {
+		t.Fatalf("expected sorted, deduplicated permissions, got %v", first.Permissions)
+	}
+
+	repo.perms = []string{"a", "b"}
+	same, _ := svc.GetMyPermissions(context.Background(), uuid.New())
+	if same.Version != first.Version {
+		t.Fatal("expected the version to depend only on the set")
+	}
+
+	repo.perms = []string{"a"}
+	changed, _ := svc.GetMyPermissions(context.Background(), uuid.New())
+	if changed.Version == first.Version {
+		t.Fatal("expected the version to change with the set")
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TOTP parameters (RFC 6238). These are the defaults every authenticator app understands.
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is how many periods either side of now a code is accepted for, to absorb clock drift.
+	totpSkew = 1
+)
+
+// After reauthMaxFailures wrong passwords or codes in a row, that factor cannot be used to
+// re-authenticate for reauthLockout.
+const (
+	reauthMaxFailures = 5
+	reauthLockout     = 15 * time.Minute
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (a authService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	if newPassword == "" {
+		return httputil.ErrBadRequest
+	}
+
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(currentPassword)); err != nil {
+		return httputil.ErrUnauthorized
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.UpdatePassword(ctx, userID, string(hash)); err != nil {
+		return err
+	}
+
+	event := events.AuthUserPasswordChangedData{
+		Trail:  events.NewTrail(ctx, userTarget(userID), nil, nil),
+		UserID: userID,
+	}
+	a.publish(events.AuthUserPasswordChanged, event)
+	return nil
+}
+
+func (a authService) Reauthenticate(ctx context.Context, userID, sessionID uuid.UUID, password, totpCode string) (domain.AuthTokens, error) {
+	if (password == "") == (totpCode == "") {
+		return domain.AuthTokens{}, fmt.Errorf("%w: provide either a password or a TOTP code", httputil.ErrBadRequest)
+	}
+
+	session, err := a.repo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return domain.AuthTokens{}, httputil.ErrUnauthorized
+		}
+		return domain.AuthTokens{}, err
+	}
+	if session.UserID != userID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return domain.AuthTokens{}, httputil.ErrUnauthorized
+	}
+
+	method := events.LoginMethodPassword
+	if totpCode != "" {
+		method = events.LoginMethodTOTP
+	}
+	lockedUntil, err := a.repo.GetReauthLockedUntil(ctx, userID, method)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+	if lockedUntil != nil && time.Now().Before(*lockedUntil) {
+		return domain.AuthTokens{}, domain.ErrFactorLocked
+	}
+
+	if totpCode != "" {
+		err = a.verifyTOTP(ctx, userID, totpCode)
+	} else {
+		err = a.verifyPassword(ctx, userID, password)
+	}
+	if err != nil {
+		if errors.Is(err, httputil.ErrUnauthorized) {
+			a.publishLoginFailed(ctx, &userID, "", method, "reauthentication_failed")
+			if lockErr := a.repo.RecordReauthFailure(ctx, userID, method, reauthMaxFailures, reauthLockout); lockErr != nil {
+				return domain.AuthTokens{}, lockErr
+			}
+		}
+		return domain.AuthTokens{}, err
+	}
+	if err := a.repo.ResetReauthFailures(ctx, userID, method); err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	session.AuthTime = time.Now()
+	if err := a.repo.UpdateSessionAuthTime(ctx, sessionID, session.AuthTime); err != nil {
+		return domain.AuthTokens{}, err
+	}
+	tokens, err := a.rotateSessionTokens(ctx, session)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	event := events.AuthUserReauthenticatedData{
+		Trail:     events.NewTrail(ctx, userTarget(userID), nil, map[string]string{"method": method}),
+		UserID:    userID,
+		SessionID: sessionID,
+		Method:    method,
+	}
+	a.publish(events.AuthUserReauthenticated, event)
+	return tokens, nil
+}
+
+func (a authService) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*domain.TOTPEnrollment, error) {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := totpEncoding.EncodeToString(raw)
+
+	if err := a.repo.SaveTOTPFactor(ctx, &domain.TOTPFactor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+
+	label := url.PathEscape(a.issuer + ":" + u.Email)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", a.issuer)
+	params.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+	params.Set("digits", fmt.Sprint(totpDigits))
+	return &domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    "otpauth://totp/" + label + "?" + params.Encode(),
+	}, nil
+}
+
+func (a authService) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) error {
+	factor, err := a.repo.GetTOTPFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return domain.ErrTOTPNotEnabled
+		}
+		return err
+	}
+	if factor.ConfirmedAt != nil {
+		return domain.ErrTOTPAlreadyEnabled
+	}
+
+	step, ok := matchTOTP(factor.Secret, code, time.Now())
+	if !ok {
+		return httputil.ErrUnauthorized
+	}
+	if err := a.repo.ConfirmTOTPFactor(ctx, userID, step); err != nil {
+		return err
+	}
+	a.publishTOTPChanged(ctx, userID, true)
+	return nil
+}
+
+func (a authService) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	if err := a.repo.DeleteTOTPFactor(ctx, userID); err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return domain.ErrTOTPNotEnabled
+		}
+		return err
+	}
+	a.publishTOTPChanged(ctx, userID, false)
+	return nil
+}
+
+func (a authService) verifyPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	u, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return httputil.ErrUnauthorized
+	}
+	return nil
+}
+
+// verifyTOTP checks code against the user's confirmed authenticator app. Each code is accepted once.
+func (a authService) verifyTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	factor, err := a.repo.GetTOTPFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return domain.ErrTOTPNotEnabled
+		}
+		return err
+	}
+	if factor.ConfirmedAt == nil {
+		return domain.ErrTOTPNotEnabled
+	}
+
+	step, ok := matchTOTP(factor.Secret, code, time.Now())
+	if !ok {
+		return httputil.ErrUnauthorized
+	}
+	fresh, err := a.repo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return httputil.ErrUnauthorized
+	}
+	return nil
+}
+
+func (a authService) publishTOTPChanged(ctx context.Context, userID uuid.UUID, enabled bool) {
+	event := events.AuthUserUpdatedData{
+		Trail:  events.NewTrail(ctx, userTarget(userID), map[string]bool{"totp_enabled": !enabled}, map[string]bool{"totp_enabled": enabled}),
+		UserID: userID,
+		Fields: []string{"totp_enabled"},
+	}
+	a.publish(events.AuthUserUpdated, event)
+}
+
+// matchTOTP returns the time step code is valid for, if any.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := now.Unix() / int64(totpPeriod.Seconds())
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the HOTP value (RFC 4226) for a time step.
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// Test vector from RFC 6238 appendix B (SHA-1, T=59s), truncated to 6 digits.
+	key := []byte("12345678901234567890")
+	if got := totpCode(key, 59/30); got != "287082" {
+		t.Fatalf("expected 287082, got %s", got)
+	}
+
+	secret := totpEncoding.EncodeToString(key)
+	now := time.Unix(59, 0)
+	if step, ok := matchTOTP(secret, "287082", now.Add(totpPeriod)); !ok || step != 1 {
+		t.Fatalf("expected the previous period to be accepted, got step %d ok %v", step, ok)
+	}
+	if _, ok := matchTOTP(secret, "287082", now.Add(3*totpPeriod)); ok {
+		t.Fatal("expected a code outside the skew window to be rejected")
+	}
+}
+
+// stepUpRepo is an in-memory stand-in for the session, TOTP and lockout parts of domain.Repository.
+type stepUpRepo struct {
+	domain.Repository
+	user        domain.User
+	session     domain.Session
+	factor      *domain.TOTPFactor
+	failures    map[string]int
+	lockedUntil map[string]time.Time
+}
+
+func (r *stepUpRepo) GetUserByID(context.Context, uuid.UUID) (*domain.User, error) {
+	u := r.user
+	return &u, nil
+}
+
+func (r *stepUpRepo) GetSessionByID(context.Context, uuid.UUID) (*domain.Session, error) {
+	s := r.session
+	return &s, nil
+}
+
+func (r *stepUpRepo) UpdateSessionAuthTime(_ context.Context, _ uuid.UUID, authTime time.Time) error {
+	r.session.AuthTime = authTime
+	return nil
+}
+
+func (r *stepUpRepo) UpdateSessionRefresh(_ context.Context, _ uuid.UUID, hash string, expiresAt time.Time) error {
+	r.session.RefreshTokenHash, r.session.ExpiresAt = hash, expiresAt
+	return nil
+}
+
+func (r *stepUpRepo) GetTOTPFactor(context.Context, uuid.UUID) (*domain.TOTPFactor, error) {
+	if r.factor == nil {
+		return nil, httputil.ErrNotFound
+	}
+	f := *r.factor
+	return &f, nil
+}
+
+func (r *stepUpRepo) UseTOTPStep(_ context.Context, _ uuid.UUID, step int64) (bool, error) {
+	if step <= r.factor.LastUsedStep {
+		return false, nil
+	}
+	r.factor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *stepUpRepo) GetReauthLockedUntil(_ context.Context, _ uuid.UUID, method string) (*time.Time, error) {
+	until, ok := r.lockedUntil[method]
+	if !ok {
+		return nil, nil
+	}
+	return &until, nil
+}
+
+func (r *stepUpRepo) RecordReauthFailure(_ context.Context, _ uuid.UUID, method string, maxAttempts int, lockFor time.Duration) error {
+	if r.failures == nil {
+		r.failures, r.lockedUntil = make(map[string]int), make(map[string]time.Time)
+	}
+	r.failures[method]++
+	if r.failures[method] >= maxAttempts {
+		r.failures[method] = 0
+		r.lockedUntil[method] = time.Now().Add(lockFor)
+	}
+	return nil
+}
+
+func (r *stepUpRepo) ResetReauthFailures(_ context.Context, _ uuid.UUID, method string) error {
+	delete(r.failures, method)
+	delete(r.lockedUntil, method)
+	return nil
+}
+
+func TestReauthenticateRefreshesAuthTime(t *testing.T) {
+	ctx := context.Background()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, sessionID := uuid.New(), uuid.New()
+	stale := time.Now().Add(-time.Hour)
+	repo := &stepUpRepo{
+		user:    domain.User{ID: userID, PasswordHash: string(hash)},
+		session: domain.Session{ID: sessionID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour), AuthTime: stale},
+	}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "wrong", ""); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected a wrong password to be rejected, got %v", err)
+	}
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "secret", "123456"); !errors.Is(err, httputil.ErrBadRequest) {
+		t.Fatalf("expected password and code together to be rejected, got %v", err)
+	}
+
+	tokens, err := svc.Reauthenticate(ctx, userID, sessionID, "secret", "")
+	if err != nil {
+		t.Fatalf("reauthenticate: %v", err)
+	}
+	claims := &domain.UserClaims{}
+	if _, err := jwt.ParseWithClaims(tokens.AccessToken, claims, func(*jwt.Token) (any, error) { return []byte("test-secret"), nil }); err != nil {
+		t.Fatal(err)
+	}
+	if !claims.AuthenticatedWithin(time.Minute, time.Now()) || !repo.session.AuthTime.After(stale) {
+		t.Fatalf("expected a fresh auth_time, got %v", claims.AuthTime)
+	}
+}
+
+func TestReauthenticateWithTOTPRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	userID, sessionID := uuid.New(), uuid.New()
+	key := []byte("12345678901234567890")
+	confirmed := time.Now()
+	repo := &stepUpRepo{
+		user:    domain.User{ID: userID},
+		session: domain.Session{ID: sessionID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)},
+		factor:  &domain.TOTPFactor{UserID: userID, Secret: totpEncoding.EncodeToString(key), ConfirmedAt: &confirmed},
+	}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	code := totpCode(key, time.Now().Unix()/int64(totpPeriod.Seconds()))
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "", code); err != nil {
+		t.Fatalf("reauthenticate with totp: %v", err)
+	}
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "", code); !errors.Is(err, httputil.ErrUnauthorized) {
+		t.Fatalf("expected a reused code to be rejected, got %v", err)
+	}
+}
+
+func TestReauthenticateLocksFactorAfterFailures(t *testing.T) {
+	ctx := context.Background()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, sessionID := uuid.New(), uuid.New()
+	repo := &stepUpRepo{
+		user:    domain.User{ID: userID, PasswordHash: string(hash)},
+		session: domain.Session{ID: sessionID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	for i := 0; i < reauthMaxFailures; i++ {
+		if _, err := svc.Reauthenticate(ctx, userID, sessionID, "wrong", ""); !errors.Is(err, httputil.ErrUnauthorized) {
+			t.Fatalf("attempt %d: expected a wrong password to be rejected, got %v", i+1, err)
+		}
+	}
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "secret", ""); !errors.Is(err, domain.ErrFactorLocked) {
+		t.Fatalf("expected the password to be locked, got %v", err)
+	}
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "", "123456"); errors.Is(err, domain.ErrFactorLocked) {
+		t.Fatal("expected other factors to stay usable")
+	}
+
+	repo.lockedUntil[events.LoginMethodPassword] = time.Now().Add(-time.Second)
+	if _, err := svc.Reauthenticate(ctx, userID, sessionID, "secret", ""); err != nil {
+		t.Fatalf("expected the password to work once the lock expired, got %v", err)
+	}
+	if _, locked := repo.lockedUntil[events.LoginMethodPassword]; locked || repo.failures[events.LoginMethodPassword] != 0 {
+		t.Fatal("expected a successful attempt to clear the failures")
+	}
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/csvutil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	invitationTTL = 7 * 24 * time.Hour
+	// maxImportRows bounds a single import so one job cannot run for hours.
+	maxImportRows = 5000
+	// importProgressEvery is how many rows are processed between progress writes.
+	importProgressEvery = 25
+	// A running import also saves its progress once importHeartbeat has passed since the last
+	// write, so a job that has not been updated for importStaleAfter has lost its runner.
+	importHeartbeat   = time.Minute
+	importStaleAfter  = 10 * time.Minute
+	importSweepPeriod = 5 * time.Minute
+	// importRoleSeparator separates role names in the roles column.
+	importRoleSeparator = ";"
+)
+
+// errInvitationNotSent reports a user that was created but could not be mailed the invitation.
+var errInvitationNotSent = errors.New("user created but the invitation could not be sent")
+
+// plannedImportRow is a parsed row together with its validation outcome and resolved roles.
+type plannedImportRow struct {
+	row     domain.UserImportRow
+	roleIDs []int
+	result  domain.UserImportRowResult
+}
+
+func (a authService) ValidateUserImport(ctx context.Context, r io.Reader) (*domain.UserImportReport, error) {
+	plan, err := a.planUserImport(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.UserImportReport{Total: len(plan), Rows: make([]domain.UserImportRowResult, 0, len(plan))}
+	for _, p := range plan {
+		if p.result.Status == domain.ImportRowValid {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, p.result)
+	}
+	return report, nil
+}
+
+func (a authService) StartUserImport(ctx context.Context, r io.Reader) (*domain.UserImportJob, error) {
+	plan, err := a.planUserImport(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &domain.UserImportJob{
+		ID:        uuid.New(),
+		Status:    domain.UserImportPending,
+		CreatedBy: actorUserID(ctx),
+		Total:     len(plan),
+		Rows:      make([]domain.UserImportRowResult, 0, len(plan)),
+	}
+	for _, p := range plan {
+		if p.result.Status != domain.ImportRowValid {
+			job.Failed++
+		}
+		job.Rows = append(job.Rows, p.result)
+	}
+	if err := a.repo.CreateUserImportJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// The job outlives the request but keeps its actor so the events it publishes are attributed
+	// to the admin who started it.
+	run := *job
+	run.Rows = slices.Clone(job.Rows)
+	go a.runUserImport(context.WithoutCancel(ctx), &run, plan)
+
+	return job, nil
+}
+
+func (a authService) GetUserImportJob(ctx context.Context, jobID uuid.UUID) (*domain.UserImportJob, error) {
+	return a.repo.GetUserImportJob(ctx, jobID)
+}
+
+// FailStaleUserImports fails the jobs whose runner stopped saving progress. Imports run in the
+// instance that started them, so a restart leaves its jobs unfinished; a heartbeat rather than the
+// job status tells them apart from jobs other instances are still running.
+func (a authService) FailStaleUserImports(ctx context.Context) (int, error) {
+	return a.repo.FailStaleUserImportJobs(ctx, time.Now().Add(-importStaleAfter))
+}
+
+// RunUserImportSweeper fails stale imports at startup and then periodically until ctx is cancelled.
+func RunUserImportSweeper(ctx context.Context, svc domain.Service) {
+	ticker := time.NewTicker(importSweepPeriod)
+	defer ticker.Stop()
+
+	for {
+		n, err := svc.FailStaleUserImports(ctx)
+		if err != nil {
+			slog.Error("user import sweep failed", "error", err)
+		} else if n > 0 {
+			slog.Warn("failed stale user imports", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runUserImport creates the valid rows of plan one by one. Rows are independent: a failing row is
+// recorded and the import moves on.
+func (a authService) runUserImport(ctx context.Context, job *domain.UserImportJob, plan []plannedImportRow) {
+	job.Status = domain.UserImportRunning
+	a.saveUserImport(ctx, job)
+	lastSave := time.Now()
+
+	for i, p := range plan {
+		if p.result.Status != domain.ImportRowValid {
+			continue
+		}
+
+		res := &job.Rows[i]
+		userID, err := a.inviteUser(ctx, p.row, p.roleIDs, job.ID)
+		if userID != uuid.Nil {
+			res.UserID = &userID
+		}
+		switch {
+		case errors.Is(err, errInvitationNotSent):
+			// The user exists, so the row is not failed; an administrator has to get them a
+			// password another way.
+			slog.Error("user import invitation not sent", "job_id", job.ID, "error", err)
+			res.Status = domain.ImportRowInvitationNotSent
+			res.Errors = []string{"invitation email could not be sent"}
+			job.Succeeded++
+		case err != nil:
+			res.Status = domain.ImportRowFailed
+			res.Errors = []string{importErrorMessage(err)}
+			job.Failed++
+		default:
+			res.Status = domain.ImportRowCreated
+			job.Succeeded++
+		}
+
+		if (i+1)%importProgressEvery == 0 || time.Since(lastSave) >= importHeartbeat {
+			a.saveUserImport(ctx, job)
+			lastSave = time.Now()
+		}
+	}
+
+	now := time.Now()
+	job.Status = domain.UserImportCompleted
+	job.FinishedAt = &now
+	a.saveUserImport(ctx, job)
+	slog.Info("user import finished", "job_id", job.ID, "succeeded", job.Succeeded, "failed", job.Failed)
+
+	event := events.AuthUserImportCompletedData{
+		Trail:     events.NewTrail(ctx, importTarget(job.ID), nil, map[string]int{"succeeded": job.Succeeded, "failed": job.Failed}),
+		JobID:     job.ID,
+		Total:     job.Total,
+		Succeeded: job.Succeeded,
+		Failed:    job.Failed,
+	}
+	a.publish(events.AuthUserImportCompleted, event)
+}
+
+func (a authService) saveUserImport(ctx context.Context, job *domain.UserImportJob) {
+	if err := a.repo.UpdateUserImportJob(ctx, job); err != nil {
+		slog.Error("failed to save user import progress", "job_id", job.ID, "error", err)
+	}
+}
+
+// importErrorMessage keeps client errors such as a taken address and hides internal ones, which
+// are logged instead.
+func importErrorMessage(err error) string {
+	if errors.Is(err, httputil.ErrBadRequest) || errors.Is(err, httputil.ErrConflict) {
+		return err.Error()
+	}
+	slog.Error("user import row failed", "error", err)
+	return "internal error"
+}
+
+// inviteUser creates a user without a password, grants the roles and mails an invitation to
+// choose a password. The returned ID is set whenever the user was created, even if the
+// invitation email could not be sent.
+func (a authService) inviteUser(ctx context.Context, row domain.UserImportRow, roleIDs []int, jobID uuid.UUID) (uuid.UUID, error) {
+	token, err := generateSecret()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now()
+	user := &domain.User{ID: uuid.New(), Email: row.Email, FullName: row.FullName}
+	grants := make([]domain.RoleGrant, 0, len(roleIDs))
+	for _, id := range roleIDs {
+		grants = append(grants, domain.RoleGrant{UserID: user.ID, RoleID: id, ValidFrom: now, GrantedBy: actorUserID(ctx)})
+	}
+	invitation := &domain.UserInvitation{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(invitationTTL),
+	}
+	if err := a.repo.CreateInvitedUser(ctx, user, grants, invitation); err != nil {
+		return uuid.Nil, err
+	}
+
+	registered := events.AuthUserRegisteredData{
+		Trail:    events.NewTrail(ctx, userTarget(user.ID), nil, map[string]string{"email": user.Email, "full_name": user.FullName}),
+		UserID:   user.ID,
+		Email:    user.Email,
+		FullName: user.FullName,
+	}
+	a.publish(events.AuthUserRegistered, registered)
+	for i := range grants {
+		a.publishRoleAssigned(ctx, &grants[i])
+	}
+
+	acceptURL := fmt.Sprintf("%s/auth/accept-invitation?token=%s", a.appBaseURL, url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nAn account has been created for you. Use the link below to choose your password. It expires in %d days.\n\n%s\n",
+		user.FullName, int(invitationTTL.Hours()/24), acceptURL)
+	if err := a.mailer.Send(ctx, user.Email, "You have been invited", body); err != nil {
+		return user.ID, fmt.Errorf("%w: %w", errInvitationNotSent, err)
+	}
+
+	invited := events.AuthUserInvitedData{
+		Trail:       events.NewTrail(ctx, userTarget(user.ID), nil, map[string]string{"email": user.Email}),
+		UserID:      user.ID,
+		Email:       user.Email,
+		ImportJobID: &jobID,
+	}
+	a.publish(events.AuthUserInvited, invited)
+	return user.ID, nil
+}
+
+func (a authService) AcceptInvitation(ctx context.Context, token, password string) error {
+	if token == "" {
+		return httputil.ErrUnauthorized
+	}
+	if password == "" {
+		return httputil.ErrBadRequest
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	inv, err := a.repo.AcceptInvitation(ctx, hashToken(token), string(hash))
+	if err != nil {
+		if errors.Is(err, httputil.ErrNotFound) {
+			return httputil.ErrUnauthorized
+		}
+		return err
+	}
+
+	event := events.AuthUserInvitationAcceptedData{
+		Trail:  events.NewTrail(ctx, userTarget(inv.UserID), nil, nil),
+		UserID: inv.UserID,
+	}
+	a.publish(events.AuthUserInvitationAccepted, event)
+	return nil
+}
+
+// planUserImport parses the CSV and validates every row: a well-formed address that is not yet
+// registered nor repeated in the file, a full name, and roles that exist.
+func (a authService) planUserImport(ctx context.Context, r io.Reader) ([]plannedImportRow, error) {
+	rows, err := parseUserImport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := a.repo.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := make(map[string]int, len(roles))
+	for _, role := range roles {
+		roleIDs[strings.ToLower(role.Name)] = role.ID
+	}
+
+	// Addresses are compared in lower case, both within the file and against existing accounts,
+	// which are looked up in one query.
+	var emails []string
+	for _, row := range rows {
+		if addr, err := mail.ParseAddress(row.Email); err == nil && addr.Address == row.Email {
+			emails = append(emails, strings.ToLower(row.Email))
+		}
+	}
+	registered := make(map[string]bool)
+	if len(emails) > 0 {
+		found, err := a.repo.GetRegisteredEmails(ctx, emails)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range found {
+			registered[email] = true
+		}
+	}
+
+	seen := make(map[string]int, len(rows))
+	plan := make([]plannedImportRow, 0, len(rows))
+	for _, row := range rows {
+		var problems []string
+
+		email := strings.ToLower(row.Email)
+		addr, err := mail.ParseAddress(row.Email)
+		switch {
+		case err != nil || addr.Address != row.Email:
+			problems = append(problems, "invalid email address")
+		case seen[email] != 0:
+			problems = append(problems, fmt.Sprintf("duplicate of line %d", seen[email]))
+		default:
+			seen[email] = row.Line
+			if registered[email] {
+				problems = append(problems, "email address is already in use")
+			}
+		}
+
+		if row.FullName == "" {
+			problems = append(problems, "full_name is required")
+		}
+
+		var ids []int
+		for _, name := range row.Roles {
+			id, ok := roleIDs[strings.ToLower(name)]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("unknown role %q", name))
+				continue
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+
+		result := domain.UserImportRowResult{Line: row.Line, Email: row.Email, Status: domain.ImportRowValid}
+		if len(problems) > 0 {
+			result.Status = domain.ImportRowInvalid
+			result.Errors = problems
+		}
+		plan = append(plan, plannedImportRow{row: row, roleIDs: ids, result: result})
+	}
+	return plan, nil
+}
+
+// parseUserImport reads an import CSV. The header row names the columns: email and full_name are
+// required, roles is optional (names separated by ";") and other columns are ignored, so an
+// export can be imported again. Cells escaped by the export are read back as written.
+func parseUserImport(r io.Reader) ([]domain.UserImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header row", domain.ErrInvalidImportFile)
+	}
+	if err != nil {
+		return nil, importReadError(err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet applications often prepend a UTF-8 byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	emailCol, hasEmail := columns["email"]
+	nameCol, hasName := columns["full_name"]
+	if !hasEmail || !hasName {
+		return nil, fmt.Errorf("%w: header must contain email and full_name", domain.ErrInvalidImportFile)
+	}
+	rolesCol, hasRoles := columns["roles"]
+
+	field := func(record []string, i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(csvutil.Unescape(record[i]))
+		}
+		return ""
+	}
+
+	var rows []domain.UserImportRow
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, importReadError(err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", domain.ErrInvalidImportFile, maxImportRows)
+		}
+
+		line, _ := cr.FieldPos(0)
+		row := domain.UserImportRow{Line: line, Email: field(record, emailCol), FullName: field(record, nameCol)}
+		if hasRoles {
+			for _, name := range strings.Split(field(record, rolesCol), importRoleSeparator) {
+				if name = strings.TrimSpace(name); name != "" {
+					row.Roles = append(row.Roles, name)
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rows", domain.ErrInvalidImportFile)
+	}
+	return rows, nil
+}
+
+// importReadError reports malformed CSV as a client error and passes other read failures, such as
+// an oversized upload, through unchanged.
+func importReadError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", domain.ErrInvalidImportFile, err)
+	}
+	return err
+}
+
+// ExportUsers writes every active account as CSV, in the format accepted by the import.
+func (a authService) ExportUsers(ctx context.Context, w io.Writer) error {
+	users, err := a.repo.GetUsersForExport(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Names and emails are user input; escape them so a spreadsheet does not evaluate them.
+	cw := csvutil.NewWriter(w)
+	if err := cw.Write([]string{"id", "email", "full_name", "roles", "created_at", "activated_at"}); err != nil {
+		return err
+	}
+	for _, u := range users {
+		activatedAt := ""
+		if u.ActivatedAt != nil {
+			activatedAt = u.ActivatedAt.UTC().Format(time.RFC3339)
+		}
+		record := []string{
+			u.ID.String(),
+			u.Email,
+			u.FullName,
+			strings.Join(u.Roles, importRoleSeparator),
+			u.CreatedAt.UTC().Format(time.RFC3339),
+			activatedAt,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
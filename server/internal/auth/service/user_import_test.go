@@ -0,0 +1,268 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/auth/domain"
+)
+
+// importRepo is an in-memory stand-in for the user and import job parts of domain.Repository.
+type importRepo struct {
+	domain.Repository
+	mu       sync.Mutex
+	existing map[string]bool
+	lookups  int
+	exported []domain.UserExportRow
+	created  map[string][]domain.RoleGrant
+	job      domain.UserImportJob
+	done     chan struct{}
+
+	staleBefore time.Time
+}
+
+func (r *importRepo) GetRoles(context.Context) ([]domain.Role, error) {
+	return []domain.Role{{ID: 1, Name: "Admin"}, {ID: 2, Name: "Editor"}}, nil
+}
+
+func (r *importRepo) GetRegisteredEmails(_ context.Context, emails []string) ([]string, error) {
+	r.lookups++
+	var registered []string
+	for existing := range r.existing {
+		if slices.Contains(emails, strings.ToLower(existing)) {
+			registered = append(registered, strings.ToLower(existing))
+		}
+	}
+	return registered, nil
+}
+
+func (r *importRepo) GetUsersForExport(context.Context) ([]domain.UserExportRow, error) {
+	return r.exported, nil
+}
+
+func (r *importRepo) CreateInvitedUser(_ context.Context, user *domain.User, grants []domain.RoleGrant, _ *domain.UserInvitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.created[user.Email] = grants
+	return nil
+}
+
+func (r *importRepo) CreateUserImportJob(_ context.Context, job *domain.UserImportJob) error {
+	r.job = *job
+	return nil
+}
+
+func (r *importRepo) UpdateUserImportJob(_ context.Context, job *domain.UserImportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.job = *job
+	if job.Status == domain.UserImportCompleted {
+		close(r.done)
+	}
+	return nil
+}
+
+func (r *importRepo) FailStaleUserImportJobs(_ context.Context, staleBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.staleBefore = staleBefore
+	return 1, nil
+}
+
+type recordingMailer struct {
+	mu sync.Mutex
+	to []string
+}
+
+func (m *recordingMailer) Send(_ context.Context, to, _, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.to = append(m.to, to)
+	return nil
+}
+
+const importCSV = "\ufeffEmail,Full_Name,Roles,ignored\n" +
+	"ana@example.com,Ana,Admin; editor,x\n" +
+	"\n" +
+	"taken@example.com,Taken,,x\n" +
+	"ANA@example.com,Ana Again,,x\n" +
+	"not-an-email,,Owner,x\n"
+
+func newImportRepo() *importRepo {
+	return &importRepo{
+		existing: map[string]bool{"taken@example.com": true},
+		created:  map[string][]domain.RoleGrant{},
+		done:     make(chan struct{}),
+	}
+}
+
+func TestValidateUserImportReportsEveryProblem(t *testing.T) {
+	svc := NewAuthService(newImportRepo(), nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	report, err := svc.ValidateUserImport(context.Background(), strings.NewReader(importCSV))
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if report.Total != 4 || report.Valid != 1 || report.Invalid != 3 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+
+	want := map[int]string{
+		4: "email address is already in use",
+		5: "duplicate of line 2",
+		6: "invalid email address",
+	}
+	for _, row := range report.Rows[1:] {
+		if len(row.Errors) == 0 || row.Errors[0] != want[row.Line] {
+			t.Errorf("line %d: unexpected errors %v", row.Line, row.Errors)
+		}
+	}
+	if last := report.Rows[3].Errors; len(last) != 3 {
+		t.Errorf("expected missing name and unknown role to be reported too, got %v", last)
+	}
+}
+
+func TestValidateUserImportMatchesExistingAccountsRegardlessOfCase(t *testing.T) {
+	repo := newImportRepo()
+	repo.existing = map[string]bool{"Mixed.Case@Example.com": true}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	file := "email,full_name\nmixed.case@example.com,Mixed\nnew@example.com,New\nNew@example.com,Again\n"
+	report, err := svc.ValidateUserImport(context.Background(), strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if errs := report.Rows[0].Errors; len(errs) != 1 || errs[0] != "email address is already in use" {
+		t.Fatalf("expected the address to be taken despite its case, got %v", errs)
+	}
+	if report.Valid != 1 || report.Rows[2].Errors[0] != "duplicate of line 3" {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if repo.lookups != 1 {
+		t.Fatalf("expected one lookup for the whole file, got %d", repo.lookups)
+	}
+}
+
+func TestValidateUserImportRejectsMalformedFiles(t *testing.T) {
+	svc := NewAuthService(newImportRepo(), nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	for name, file := range map[string]string{
+		"empty":          "",
+		"missing column": "email,roles\na@example.com,Admin\n",
+		"no rows":        "email,full_name\n",
+		"bad quoting":    "email,full_name\n\"a@example.com,A\n",
+	} {
+		if _, err := svc.ValidateUserImport(context.Background(), strings.NewReader(file)); !errors.Is(err, domain.ErrInvalidImportFile) {
+			t.Errorf("%s: expected ErrInvalidImportFile, got %v", name, err)
+		}
+	}
+}
+
+func TestExportUsersEscapesFormulasAndImportsBack(t *testing.T) {
+	repo := newImportRepo()
+	repo.exported = []domain.UserExportRow{
+		{ID: uuid.New(), Email: "eve@example.com", FullName: `=HYPERLINK("http://evil.example","x")`, Roles: []string{"Editor"}, CreatedAt: time.Now()},
+	}
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	var buf bytes.Buffer
+	if err := svc.ExportUsers(context.Background(), &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"'=HYPERLINK(""http://evil.example"",""x"")"`) {
+		t.Fatalf("expected the formula to be escaped, got %q", buf.String())
+	}
+
+	rows, err := parseUserImport(&buf)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if rows[0].FullName != repo.exported[0].FullName {
+		t.Fatalf("expected the name to read back as written, got %q", rows[0].FullName)
+	}
+}
+
+func TestStartUserImportInvitesValidRows(t *testing.T) {
+	repo := newImportRepo()
+	mailer := &recordingMailer{}
+	svc := NewAuthService(repo, nil, mailer, nil, Config{JWTSecret: "test-secret"})
+
+	job, err := svc.StartUserImport(context.Background(), strings.NewReader(importCSV))
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if job.Status != domain.UserImportPending || job.Total != 4 || job.Failed != 3 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	<-repo.done
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.job.Succeeded != 1 || repo.job.Failed != 3 || repo.job.FinishedAt == nil {
+		t.Fatalf("unexpected final job: %+v", repo.job)
+	}
+	if row := repo.job.Rows[0]; row.Status != domain.ImportRowCreated || row.UserID == nil {
+		t.Fatalf("unexpected row result: %+v", row)
+	}
+	if grants := repo.created["ana@example.com"]; len(grants) != 2 {
+		t.Fatalf("expected both roles to be granted, got %+v", grants)
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "ana@example.com" {
+		t.Fatalf("expected one invitation, got %v", mailer.to)
+	}
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(context.Context, string, string, string) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestStartUserImportReportsUnsentInvitations(t *testing.T) {
+	repo := newImportRepo()
+	svc := NewAuthService(repo, nil, failingMailer{}, nil, Config{JWTSecret: "test-secret"})
+
+	if _, err := svc.StartUserImport(context.Background(), strings.NewReader(importCSV)); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	<-repo.done
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.job.Succeeded != 1 || repo.job.Failed != 3 {
+		t.Fatalf("expected the created user to count as succeeded, got %+v", repo.job)
+	}
+	row := repo.job.Rows[0]
+	if row.Status != domain.ImportRowInvitationNotSent || row.UserID == nil || len(row.Errors) != 1 {
+		t.Fatalf("unexpected row result: %+v", row)
+	}
+	if _, ok := repo.created["ana@example.com"]; !ok {
+		t.Fatal("expected the user to be created")
+	}
+}
+
+func TestUserImportSweeperFailsStaleJobsAtStartup(t *testing.T) {
+	repo := newImportRepo()
+	svc := NewAuthService(repo, nil, nil, nil, Config{JWTSecret: "test-secret"})
+
+	// A cancelled context stops the sweeper after its first pass.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	RunUserImportSweeper(ctx, svc)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.staleBefore.IsZero() {
+		t.Fatal("expected stale jobs to be failed on startup")
+	}
+	if age := time.Since(repo.staleBefore); age < importStaleAfter || age > importStaleAfter+time.Minute {
+		t.Fatalf("expected jobs idle for %s to be stale, got a cutoff %s ago", importStaleAfter, age)
+	}
+}
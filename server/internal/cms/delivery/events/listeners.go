@@ -1,13 +1,22 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/cms/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
 )
 
+// badgeQueue load-balances menu badge requests across cms instances.
+const badgeQueue = "cms.menu.badge"
+
+// badgeQueryTimeout bounds the count query; the auth module stops waiting long before this.
+const badgeQueryTimeout = time.Second
+
 type eventHandler struct {
 	svc domain.Service
 }
@@ -20,6 +29,10 @@ func RegisterListeners(nc *nats.Conn, svc domain.Service) {
 	if err != nil {
 		return
 	}
+
+	if _, err := nc.QueueSubscribe(events.CmsMenuBadgeDrafts, badgeQueue, h.handleDraftsBadge); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", events.CmsMenuBadgeDrafts, err)
+	}
 }
 
 func (h *eventHandler) handleOrderCompleted(m *nats.Msg) {
@@ -33,3 +46,27 @@ func (h *eventHandler) handleOrderCompleted(m *nats.Msg) {
 
 	log.Printf("Auth Module: User %s has completed an order.", payload.UserID)
 }
+
+// handleDraftsBadge answers the Pages menu badge with the number of drafts awaiting review. The
+// count is the same for every user allowed to see the item.
+func (h *eventHandler) handleDraftsBadge(m *nats.Msg) {
+	ctx, cancel := context.WithTimeout(context.Background(), badgeQueryTimeout)
+	defer cancel()
+
+	var reply events.MenuBadgeReply
+	count, err := h.svc.CountDraftPages(ctx)
+	if err != nil {
+		log.Printf("Failed to count draft pages: %v", err)
+		reply.Error = "failed to count draft pages"
+	}
+	reply.Count = count
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("Failed to marshal reply for %s: %v", m.Subject, err)
+		return
+	}
+	if err := m.Respond(data); err != nil {
+		log.Printf("Failed to reply on %s: %v", m.Subject, err)
+	}
+}
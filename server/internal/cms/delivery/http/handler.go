@@ -16,7 +16,7 @@ type CMSHandler struct {
 	svc domain.Service
 }
 
-func RegisterHTTPHandlers(r chi.Router, svc domain.Service) {
+func RegisterHTTPHandlers(r chi.Router, svc domain.Service, recentAuth func(http.Handler) http.Handler) {
 	h := &CMSHandler{svc: svc}
 
 	r.Route("/pages", func(r chi.Router) {
@@ -24,7 +24,7 @@ func RegisterHTTPHandlers(r chi.Router, svc domain.Service) {
 		r.Post("/", h.CreateDraft)
 		r.Post("/register", h.RegisterPage)
 		r.Get("/{slug}", h.GetBySlug)
-		r.Delete("/{id}", h.Delete)
+		r.With(recentAuth).Delete("/{id}", h.Delete)
 		r.Put("/{id}/metadata", h.UpdateMetadata)
 		r.Put("/{id}/layout", h.UpdateLayout)
 		r.Post("/{id}/publish", h.Publish)
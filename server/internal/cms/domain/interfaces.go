@@ -21,6 +21,7 @@ type Repository interface {
 
 	// SEO & Status
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	CountByStatus(ctx context.Context, status string) (int, error)
 }
 
 type Service interface {
@@ -37,4 +38,7 @@ type Service interface {
 
 	// Public Facing
 	GetPageBySlug(ctx context.Context, Slug string) (*Page, error)
+
+	// Backoffice
+	CountDraftPages(ctx context.Context) (int, error)
 }
@@ -3,28 +3,37 @@ package cms
 import (
 	"github.com/rubenalves-dev/template-fullstack/server/internal/cms/domain"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
 )
 
+// MenuVersion must be bumped whenever MenuDefinition changes, so that an instance still running
+// older code cannot overwrite the newer menu during a rolling deploy.
+const MenuVersion = 3
+
 var MenuDefinition = menu.MenuDefinition{
 	ID:          "cms:root",
 	Label:       "CMS",
+	Labels:      map[string]string{"pt": "CMS", "es": "CMS"},
 	Icon:        "layout-dashboard",
 	Order:       20,
 	Visible:     true,
 	Permissions: []string{domain.PermissionPageRead},
 	Children: []menu.MenuDefinition{
 		{
-			ID:          "cms:pages",
-			Label:       "Pages",
-			Path:        "/cms/pages",
-			Icon:        "file-pen",
-			Order:       10,
-			Visible:     true,
-			Permissions: []string{domain.PermissionPageRead},
+			ID:           "cms:pages",
+			Label:        "Pages",
+			Labels:       map[string]string{"pt": "Páginas", "es": "Páginas"},
+			Path:         "/cms/pages",
+			Icon:         "file-pen",
+			Order:        10,
+			Visible:      true,
+			Permissions:  []string{domain.PermissionPageRead},
+			BadgeSubject: events.CmsMenuBadgeDrafts,
 		},
 		{
 			ID:          "cms:media",
 			Label:       "Media",
+			Labels:      map[string]string{"pt": "Mídia", "es": "Medios"},
 			Path:        "/cms/media",
 			Icon:        "film",
 			Order:       20,
@@ -3,6 +3,8 @@ package cms
 import (
 	"encoding/json"
 	"log"
+	nethttp "net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -16,6 +18,9 @@ import (
 	globalEvents "github.com/rubenalves-dev/template-fullstack/server/pkg/events"
 )
 
+// menuRegistrationTimeout bounds the wait for the auth module to validate and apply the CMS menu.
+const menuRegistrationTimeout = 5 * time.Second
+
 type CmsModule struct {
 	Service domain.Service
 }
@@ -45,19 +50,32 @@ func NewModule(pool *pgxpool.Pool, nc *nats.Conn) *CmsModule {
 
 		menuPayload := globalEvents.SystemMenusRegisteredData{
 			Domain:  "cms",
-			Version: 1,
+			Version: MenuVersion,
 			Menu:    []menuDomain.MenuDefinition{MenuDefinition},
 		}
 		menuData, _ := json.Marshal(menuPayload)
-		err = nc.Publish(globalEvents.SystemMenusRegister, menuData)
+		msg, err := nc.Request(globalEvents.SystemMenusRegister, menuData, menuRegistrationTimeout)
 		if err != nil {
-			log.Printf("[ERROR] Failed to publish menus for CMS module: %v", err)
+			log.Printf("[ERROR] Failed to register menus for CMS module: %v", err)
+			return
+		}
+		var reply globalEvents.SystemMenusRegisteredReply
+		if err := json.Unmarshal(msg.Data, &reply); err != nil {
+			log.Printf("[ERROR] Failed to read menu registration reply for CMS module: %v", err)
+			return
+		}
+		for _, p := range reply.Problems {
+			log.Printf("[WARN] CMS menu item %s: %s", p.MenuID, p.Message)
+		}
+		if !reply.Applied {
+			log.Printf("[ERROR] CMS menus were rejected: %s", reply.Error)
 		}
 	}()
 
 	return &CmsModule{Service: svc}
 }
 
-func (m *CmsModule) RegisterRoutes(r chi.Router) {
-	http.RegisterHTTPHandlers(r, m.Service)
+// RegisterRoutes mounts the pages API. recentAuth guards destructive routes with step-up authentication.
+func (m *CmsModule) RegisterRoutes(r chi.Router, recentAuth func(nethttp.Handler) nethttp.Handler) {
+	http.RegisterHTTPHandlers(r, m.Service, recentAuth)
 }
@@ -61,6 +61,12 @@ func (p pxgRepo) List(ctx context.Context) ([]domain.Page, error) {
 	return pages, nil
 }
 
+func (p pxgRepo) CountByStatus(ctx context.Context, status string) (int, error) {
+	var count int
+	err := p.pool.QueryRow(ctx, `SELECT COUNT(*) FROM pages WHERE status = $1`, status).Scan(&count)
+	return count, err
+}
+
 func (p pxgRepo) Create(ctx context.Context, page *domain.Page) error {
 	query := `INSERT INTO pages (id, title, slug, seo_description, seo_keywords, status, page_type, is_editable) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 	_, err := p.pool.Exec(ctx, query, page.ID, page.Title, page.Slug, page.SEODescription, page.SEOKeywords, page.Status, page.PageType, page.IsEditable)
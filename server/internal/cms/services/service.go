@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"regexp"
 	"strings"
 
@@ -24,6 +25,18 @@ func NewService(repo domain.Repository, nc *nats.Conn) domain.Service {
 	}
 }
 
+// publish emits a CMS event once the change it describes is stored. Failures are logged rather
+// than returned so a saved page is never reported as a failed request.
+func (s service) publish(subject string, payload any) {
+	eventBytes, err := json.Marshal(payload)
+	if err == nil {
+		err = s.nc.Publish(subject, eventBytes)
+	}
+	if err != nil {
+		slog.Error("failed to publish cms event", "subject", subject, "error", err)
+	}
+}
+
 func (s service) CreateDraft(ctx context.Context, title string) error {
 	page := &domain.Page{
 		ID:       uuid.New(),
@@ -39,16 +52,22 @@ func (s service) CreateDraft(ctx context.Context, title string) error {
 	}
 
 	event := events.CmsPageDraftedData{
+		Trail:  events.NewTrail(ctx, pageTarget(page.ID), nil, page),
 		PageID: page.ID,
 		Title:  page.Title,
 		Slug:   page.Slug,
 	}
-	eventBytes, _ := json.Marshal(event)
-	return s.nc.Publish(events.CmsPageDrafted, eventBytes)
+	s.publish(events.CmsPageDrafted, event)
+	return nil
 }
 
 func (s service) PublishPage(ctx context.Context, id uuid.UUID) error {
-	err := s.repo.UpdateStatus(ctx, id, "published")
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateStatus(ctx, id, "published")
 	if err != nil {
 		return err
 	}
@@ -59,12 +78,13 @@ func (s service) PublishPage(ctx context.Context, id uuid.UUID) error {
 	}
 
 	event := events.CmsPagePublishedData{
+		Trail:  events.NewTrail(ctx, pageTarget(page.ID), before, page),
 		PageID: page.ID,
 		Title:  page.Title,
 		Slug:   page.Slug,
 	}
-	eventBytes, _ := json.Marshal(event)
-	return s.nc.Publish(events.CmsPagePublished, eventBytes)
+	s.publish(events.CmsPagePublished, event)
+	return nil
 }
 
 func (s service) DeletePage(ctx context.Context, id uuid.UUID) error {
@@ -78,11 +98,12 @@ func (s service) DeletePage(ctx context.Context, id uuid.UUID) error {
 	}
 
 	event := events.CmsPageDeletedData{
+		Trail:  events.NewTrail(ctx, pageTarget(page.ID), page, nil),
 		PageID: page.ID,
 		Title:  page.Title,
 	}
-	eventBytes, _ := json.Marshal(event)
-	return s.nc.Publish(events.CmsPageDeleted, eventBytes)
+	s.publish(events.CmsPageDeleted, event)
+	return nil
 }
 
 func (s service) ArchivePage(ctx context.Context, id uuid.UUID) error {
@@ -95,13 +116,17 @@ func (s service) ArchivePage(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
+	archived := *page
+	archived.Status = "archived"
+
 	event := events.CmsPageArchivedData{
+		Trail:  events.NewTrail(ctx, pageTarget(page.ID), page, archived),
 		PageID: page.ID,
 		Title:  page.Title,
 		Slug:   page.Slug,
 	}
-	eventBytes, _ := json.Marshal(event)
-	return s.nc.Publish(events.CmsPageArchived, eventBytes)
+	s.publish(events.CmsPageArchived, event)
+	return nil
 }
 
 func (s service) RegisterStaticPage(ctx context.Context, req domain.RegisterStaticPageRequest) error {
@@ -129,17 +154,13 @@ func (s service) RegisterStaticPage(ctx context.Context, req domain.RegisterStat
 	}
 
 	event := events.CmsPagePublishedData{
+		Trail:  events.NewTrail(ctx, pageTarget(registeredPage.ID), nil, registeredPage),
 		PageID: registeredPage.ID,
 		Title:  registeredPage.Title,
 		Slug:   registeredPage.Slug,
 	}
-	eventBytes, err := json.Marshal(event)
-	if err != nil {
-		return err
-	}
-
-	return s.nc.Publish(events.CmsPagePublished, eventBytes)
-
+	s.publish(events.CmsPagePublished, event)
+	return nil
 }
 
 func (s service) UpdatePageMetadata(ctx context.Context, id uuid.UUID, req domain.PageUpdateRequest) error {
@@ -215,10 +236,11 @@ func (s service) UpdatePageLayout(ctx context.Context, id uuid.UUID, layout []do
 	}
 
 	event := events.CmsPageLayoutUpdatedData{
+		Trail:  events.NewTrail(ctx, pageTarget(id), nil, domainRows),
 		PageID: id,
 	}
-	eventBytes, _ := json.Marshal(event)
-	return s.nc.Publish(events.CmsPageLayoutUpdated, eventBytes)
+	s.publish(events.CmsPageLayoutUpdated, event)
+	return nil
 }
 
 func (s service) GetPageBySlug(ctx context.Context, Slug string) (*domain.Page, error) {
@@ -240,6 +262,15 @@ func (s service) ListPages(ctx context.Context) ([]domain.Page, error) {
 	return s.repo.List(ctx)
 }
 
+// CountDraftPages returns the number of pages still in draft, shown as the badge of the Pages menu item.
+func (s service) CountDraftPages(ctx context.Context) (int, error) {
+	return s.repo.CountByStatus(ctx, "draft")
+}
+
+func pageTarget(id uuid.UUID) events.Target {
+	return events.Target{Type: "cms.page", ID: id.String()}
+}
+
 func slugify(text string) string {
 	var re = regexp.MustCompile("[^a-z0-9]+")
 	return strings.Trim(re.ReplaceAllString(strings.ToLower(text), "-"), "-")
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// listQueue load-balances snapshot requests across instances.
+const listQueue = "flags.list"
+
+const listTimeout = 2 * time.Second
+
+type eventHandler struct {
+	svc domain.Service
+}
+
+func RegisterListeners(nc *nats.Conn, svc domain.Service) {
+	h := &eventHandler{svc: svc}
+
+	if _, err := nc.QueueSubscribe(events.FlagsList, listQueue, h.handleList); err != nil {
+		log.Printf("Failed to subscribe to %s: %v", events.FlagsList, err)
+	}
+}
+
+// handleList answers the instances building their flag replica with every flag.
+func (h *eventHandler) handleList(m *nats.Msg) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	var reply events.FlagsListReply
+	flags, err := h.svc.ListFlags(ctx)
+	if err != nil {
+		log.Printf("Failed to list feature flags: %v", err)
+		reply.Error = "failed to list feature flags"
+	}
+	reply.Flags = flags
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		log.Printf("Failed to marshal reply for %s: %v", m.Subject, err)
+		return
+	}
+	if err := m.Respond(data); err != nil {
+		log.Printf("Failed to reply on %s: %v", m.Subject, err)
+	}
+}
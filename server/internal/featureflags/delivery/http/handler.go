@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+type FlagsHandler struct {
+	svc domain.Service
+}
+
+type flagRequest struct {
+	Description string      `json:"description"`
+	Enabled     bool        `json:"enabled"`
+	Roles       []string    `json:"roles"`
+	Users       []uuid.UUID `json:"users"`
+	Percentage  int         `json:"percentage"`
+}
+
+func RegisterHTTPHandlers(r chi.Router, svc domain.Service, authorize func(permission string) func(http.Handler) http.Handler) {
+	h := &FlagsHandler{svc: svc}
+
+	r.Route("/backoffice/flags", func(r chi.Router) {
+		r.With(authorize(domain.PermissionFlagRead)).Get("/", h.ListFlags)
+		r.With(authorize(domain.PermissionFlagRead)).Get("/{key}", h.GetFlag)
+		r.With(authorize(domain.PermissionFlagWrite)).Put("/{key}", h.SaveFlag)
+		r.With(authorize(domain.PermissionFlagWrite)).Delete("/{key}", h.DeleteFlag)
+	})
+}
+
+func (h *FlagsHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.svc.ListFlags(r.Context())
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, flags)
+}
+
+func (h *FlagsHandler) GetFlag(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.svc.GetFlag(r.Context(), chi.URLParam(r, "key"))
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, flag)
+}
+
+// SaveFlag creates the flag or replaces it entirely; omitted targeting is cleared.
+func (h *FlagsHandler) SaveFlag(w http.ResponseWriter, r *http.Request) {
+	var req flagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to parse request body")
+		return
+	}
+
+	flag, err := h.svc.SaveFlag(r.Context(), domain.Flag{
+		Key:         chi.URLParam(r, "key"),
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Roles:       req.Roles,
+		Users:       req.Users,
+		Percentage:  req.Percentage,
+	})
+	if err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	jsonutil.RenderJSON(w, http.StatusOK, flag)
+}
+
+func (h *FlagsHandler) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	if err := h.svc.DeleteFlag(r.Context(), chi.URLParam(r, "key")); err != nil {
+		status, code := httputil.MapError(err)
+		jsonutil.RenderError(w, status, code, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
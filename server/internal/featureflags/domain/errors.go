@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+var (
+	ErrInvalidFlagKey    = fmt.Errorf("%w: flag keys use lowercase letters, digits, dots, dashes and underscores, at most 100 characters", httputil.ErrBadRequest)
+	ErrInvalidPercentage = fmt.Errorf("%w: percentage must be between 0 and 100", httputil.ErrBadRequest)
+)
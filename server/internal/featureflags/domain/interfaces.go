@@ -0,0 +1,19 @@
+package domain
+
+import "context"
+
+type Repository interface {
+	List(ctx context.Context) ([]Flag, error)
+	GetByKey(ctx context.Context, key string) (*Flag, error)
+	// Upsert creates or replaces the flag and fills in its timestamps.
+	Upsert(ctx context.Context, flag *Flag) error
+	Delete(ctx context.Context, key string) error
+}
+
+type Service interface {
+	ListFlags(ctx context.Context) ([]Flag, error)
+	GetFlag(ctx context.Context, key string) (*Flag, error)
+	// SaveFlag creates or replaces the flag under flag.Key and broadcasts it to every instance.
+	SaveFlag(ctx context.Context, flag Flag) (*Flag, error)
+	DeleteFlag(ctx context.Context, key string) error
+}
@@ -0,0 +1,10 @@
+package domain
+
+const (
+	PermissionFlagRead  = "flags.flag.read"
+	PermissionFlagWrite = "flags.flag.write"
+)
+
+func GetAvailablePermissions() []string {
+	return []string{PermissionFlagRead, PermissionFlagWrite}
+}
@@ -0,0 +1,5 @@
+package domain
+
+import "github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+
+type Flag = flags.Flag
@@ -0,0 +1,55 @@
+package featureflags
+
+import (
+	"encoding/json"
+	"log"
+	nethttp "net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/delivery/events"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/delivery/http"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/repositories"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/service"
+	globalEvents "github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/features"
+)
+
+type FeatureFlagsModule struct {
+	Service domain.Service
+	client  *features.Client
+}
+
+// NewModule stores and administers the flags; client is this instance's replica, used to gate routes.
+func NewModule(pool *pgxpool.Pool, nc *nats.Conn, client *features.Client) *FeatureFlagsModule {
+	repo := repositories.NewPgxRepository(pool)
+	svc := service.NewFlagsService(repo, nc)
+
+	events.RegisterListeners(nc, svc)
+
+	go func() {
+		payload := globalEvents.SystemPermissionsRegisteredData{
+			Module:      "flags",
+			Permissions: domain.GetAvailablePermissions(),
+		}
+		data, _ := json.Marshal(payload)
+		if err := nc.Publish(globalEvents.SystemPermissionsRegister, data); err != nil {
+			log.Printf("[ERROR] Failed to publish permissions for flags module: %v", err)
+		}
+	}()
+
+	return &FeatureFlagsModule{Service: svc, client: client}
+}
+
+// RegisterRoutes mounts the flag admin API. authorize guards each route with the given permission.
+func (m *FeatureFlagsModule) RegisterRoutes(r chi.Router, authorize func(permission string) func(nethttp.Handler) nethttp.Handler) {
+	http.RegisterHTTPHandlers(r, m.Service, authorize)
+}
+
+// RequireFlag hides routes behind the flag key, answering 404 Not Found while it is off for the
+// requesting user. Mount it after the authentication middleware.
+func (m *FeatureFlagsModule) RequireFlag(key string) func(nethttp.Handler) nethttp.Handler {
+	return m.client.Require(key)
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+const flagColumns = `key, description, enabled, roles, users, percentage, updated_by, created_at, updated_at`
+
+type pgxRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPgxRepository(pool *pgxpool.Pool) domain.Repository {
+	return &pgxRepo{pool: pool}
+}
+
+func (r *pgxRepo) List(ctx context.Context) ([]domain.Flag, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+flagColumns+` FROM feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("flags repo list: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []domain.Flag{}
+	for rows.Next() {
+		f, err := scanFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("flags repo list: %w", err)
+		}
+		flags = append(flags, *f)
+	}
+	return flags, rows.Err()
+}
+
+func (r *pgxRepo) GetByKey(ctx context.Context, key string) (*domain.Flag, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+flagColumns+` FROM feature_flags WHERE key = $1`, key)
+	f, err := scanFlag(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, httputil.ErrNotFound
+		}
+		return nil, fmt.Errorf("flags repo get: %w", err)
+	}
+	return f, nil
+}
+
+func (r *pgxRepo) Upsert(ctx context.Context, flag *domain.Flag) error {
+	query := `
+		INSERT INTO feature_flags (key, description, enabled, roles, users, percentage, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description,
+			enabled = EXCLUDED.enabled,
+			roles = EXCLUDED.roles,
+			users = EXCLUDED.users,
+			percentage = EXCLUDED.percentage,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = now()
+		RETURNING created_at, updated_at
+	`
+	err := r.pool.QueryRow(ctx, query,
+		flag.Key, flag.Description, flag.Enabled, flag.Roles, flag.Users, flag.Percentage, flag.UpdatedBy,
+	).Scan(&flag.CreatedAt, &flag.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("flags repo upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *pgxRepo) Delete(ctx context.Context, key string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("flags repo delete: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return httputil.ErrNotFound
+	}
+	return nil
+}
+
+func scanFlag(row pgx.Row) (*domain.Flag, error) {
+	var f domain.Flag
+	err := row.Scan(&f.Key, &f.Description, &f.Enabled, &f.Roles, &f.Users, &f.Percentage, &f.UpdatedBy, &f.CreatedAt, &f.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+type flagsService struct {
+	repo domain.Repository
+	nc   *nats.Conn
+}
+
+func NewFlagsService(repository domain.Repository, nc *nats.Conn) domain.Service {
+	return &flagsService{repo: repository, nc: nc}
+}
+
+func (s flagsService) ListFlags(ctx context.Context) ([]domain.Flag, error) {
+	return s.repo.List(ctx)
+}
+
+func (s flagsService) GetFlag(ctx context.Context, key string) (*domain.Flag, error) {
+	return s.repo.GetByKey(ctx, key)
+}
+
+func (s flagsService) SaveFlag(ctx context.Context, flag domain.Flag) (*domain.Flag, error) {
+	if !flags.ValidKey(flag.Key) {
+		return nil, domain.ErrInvalidFlagKey
+	}
+	if flag.Percentage < 0 || flag.Percentage > 100 {
+		return nil, domain.ErrInvalidPercentage
+	}
+	flag.Description = strings.TrimSpace(flag.Description)
+	flag.Roles = normalizeRoles(flag.Roles)
+	flag.Users = normalizeUsers(flag.Users)
+	flag.UpdatedBy = actorUserID(ctx)
+
+	before, err := s.repo.GetByKey(ctx, flag.Key)
+	if err != nil && !errors.Is(err, httputil.ErrNotFound) {
+		return nil, err
+	}
+	if err := s.repo.Upsert(ctx, &flag); err != nil {
+		return nil, err
+	}
+
+	event := events.FlagsFlagUpdatedData{
+		Trail: events.NewTrail(ctx, flagTarget(flag.Key), before, flag),
+		Flag:  flag,
+	}
+	if err := s.publish(events.FlagsFlagUpdated, event); err != nil {
+		// The stored flag still reaches every instance on its next resync.
+		slog.Warn("failed to broadcast feature flag", "key", flag.Key, "error", err)
+	}
+	return &flag, nil
+}
+
+func (s flagsService) DeleteFlag(ctx context.Context, key string) error {
+	before, err := s.repo.GetByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	event := events.FlagsFlagDeletedData{
+		Trail: events.NewTrail(ctx, flagTarget(key), before, nil),
+		Key:   key,
+	}
+	if err := s.publish(events.FlagsFlagDeleted, event); err != nil {
+		slog.Warn("failed to broadcast feature flag deletion", "key", key, "error", err)
+	}
+	return nil
+}
+
+func (s flagsService) publish(subject string, payload any) error {
+	if s.nc == nil {
+		return nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return s.nc.Publish(subject, data)
+}
+
+func flagTarget(key string) events.Target {
+	return events.Target{Type: "flags.flag", ID: key}
+}
+
+// actorUserID returns the acting user recorded in ctx, if any.
+func actorUserID(ctx context.Context) *uuid.UUID {
+	id, err := uuid.Parse(events.ActorFromContext(ctx).UserID)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func normalizeRoles(roles []string) []string {
+	out := []string{}
+	for _, r := range roles {
+		if r = strings.TrimSpace(r); r != "" && !slices.Contains(out, r) {
+			out = append(out, r)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func normalizeUsers(users []uuid.UUID) []uuid.UUID {
+	out := []uuid.UUID{}
+	for _, u := range users {
+		if u != uuid.Nil && !slices.Contains(out, u) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/featureflags/domain"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+)
+
+// memoryRepo keeps flags in a map.
+type memoryRepo struct {
+	domain.Repository
+	flags map[string]domain.Flag
+}
+
+func (r *memoryRepo) GetByKey(_ context.Context, key string) (*domain.Flag, error) {
+	f, ok := r.flags[key]
+	if !ok {
+		return nil, httputil.ErrNotFound
+	}
+	return &f, nil
+}
+
+func (r *memoryRepo) Upsert(_ context.Context, f *domain.Flag) error {
+	r.flags[f.Key] = *f
+	return nil
+}
+
+func TestSaveFlagNormalizesTargeting(t *testing.T) {
+	repo := &memoryRepo{flags: map[string]domain.Flag{}}
+	svc := NewFlagsService(repo, nil)
+	admin, user := uuid.New(), uuid.New()
+	ctx := events.WithActor(context.Background(), events.Actor{UserID: admin.String()})
+
+	saved, err := svc.SaveFlag(ctx, domain.Flag{
+		Key:         "cms.new-editor",
+		Description: "  New page editor ",
+		Roles:       []string{"editor", " admin", "editor", ""},
+		Users:       []uuid.UUID{user, uuid.Nil, user},
+		Percentage:  25,
+	})
+	if err != nil {
+		t.Fatalf("SaveFlag: %v", err)
+	}
+
+	if saved.Description != "New page editor" {
+		t.Fatalf("unexpected description %q", saved.Description)
+	}
+	if !reflect.DeepEqual(saved.Roles, []string{"admin", "editor"}) {
+		t.Fatalf("unexpected roles %v", saved.Roles)
+	}
+	if !reflect.DeepEqual(saved.Users, []uuid.UUID{user}) {
+		t.Fatalf("unexpected users %v", saved.Users)
+	}
+	if saved.UpdatedBy == nil || *saved.UpdatedBy != admin {
+		t.Fatalf("expected the flag to be attributed to %s, got %v", admin, saved.UpdatedBy)
+	}
+	if _, ok := repo.flags["cms.new-editor"]; !ok {
+		t.Fatal("expected the flag to be stored")
+	}
+}
+
+func TestSaveFlagRejectsInvalidFlags(t *testing.T) {
+	svc := NewFlagsService(&memoryRepo{flags: map[string]domain.Flag{}}, nil)
+
+	for _, f := range []domain.Flag{
+		{Key: "New Editor"},
+		{Key: "editor", Percentage: 101},
+		{Key: "editor", Percentage: -1},
+	} {
+		if _, err := svc.SaveFlag(context.Background(), f); !errors.Is(err, httputil.ErrBadRequest) {
+			t.Errorf("SaveFlag(%+v) = %v, want a bad request", f, err)
+		}
+	}
+}
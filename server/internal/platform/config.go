@@ -3,6 +3,8 @@ package platform
 import (
 	"fmt"
 	"log"
+	"net/netip"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
@@ -14,6 +16,66 @@ type Config struct {
 	DBConnString string `env:"DB_CONN_STRING,required"`
 	JWTSecret    string `env:"JWT_SECRET,required"`
 	NatsURL      string `env:"NATS_URL,required"`
+	AppBaseURL   string `env:"APP_BASE_URL" envDefault:"http://localhost:4200"`
+
+	// TrustedProxies lists the networks of reverse proxies whose X-Forwarded-For hops are believed.
+	TrustedProxies []netip.Prefix `env:"TRUSTED_PROXIES" envSeparator:"," envDefault:"127.0.0.1/32,::1/128"`
+
+	// RoleGrantSweepInterval is how often expired time-bound role grants are removed. Zero disables the sweeper.
+	RoleGrantSweepInterval time.Duration `env:"ROLE_GRANT_SWEEP_INTERVAL" envDefault:"1m"`
+
+	// PermissionCacheTTL is how long answers to auth.permissions.* requests are cached. Zero disables caching.
+	PermissionCacheTTL time.Duration `env:"PERMISSION_CACHE_TTL" envDefault:"30s"`
+
+	// ReauthWindow is how long after logging in or re-authenticating sensitive operations stay allowed.
+	ReauthWindow time.Duration `env:"REAUTH_WINDOW" envDefault:"5m"`
+
+	// DPoPProofMaxAge is how far a DPoP proof's iat may be from the server clock.
+	DPoPProofMaxAge time.Duration `env:"DPOP_PROOF_MAX_AGE" envDefault:"1m"`
+
+	// PoWDifficulty is the base number of leading zero bits asked of clients on bot-protected
+	// endpoints. Zero disables proof-of-work. Difficulty rises with load and repeated failures from
+	// one IP up to PoWMaxDifficulty.
+	PoWDifficulty    int           `env:"POW_DIFFICULTY" envDefault:"16"`
+	PoWMaxDifficulty int           `env:"POW_MAX_DIFFICULTY" envDefault:"22"`
+	PoWChallengeTTL  time.Duration `env:"POW_CHALLENGE_TTL" envDefault:"2m"`
+	// PoWLoadThreshold is the number of protected requests per minute after which difficulty rises.
+	PoWLoadThreshold int `env:"POW_LOAD_THRESHOLD" envDefault:"300"`
+
+	// EncryptionKeys are the master keys for secrets stored in the database, as "id:base64-key"
+	// entries of 32-byte keys. The first is the primary; the others are kept for decryption while
+	// values are re-encrypted. EncryptionKeysFile, one entry per line, takes precedence.
+	EncryptionKeys     []string `env:"ENCRYPTION_KEYS" envSeparator:","`
+	EncryptionKeysFile string   `env:"ENCRYPTION_KEYS_FILE"`
+
+	// ReencryptionInterval is how often values sealed under an older master key are re-encrypted.
+	ReencryptionInterval time.Duration `env:"REENCRYPTION_INTERVAL" envDefault:"1h"`
+
+	// SupportedLocales are the locales the backoffice is translated into. The first is the default,
+	// the language module-declared menu labels are written in.
+	SupportedLocales []string `env:"SUPPORTED_LOCALES" envSeparator:"," envDefault:"en,pt,es"`
+
+	// MenuBadgeTimeout bounds how long building a menu waits for modules to answer badge requests;
+	// late badges are left out. MenuBadgeCacheTTL is how long answered badges are reused.
+	MenuBadgeTimeout  time.Duration `env:"MENU_BADGE_TIMEOUT" envDefault:"250ms"`
+	MenuBadgeCacheTTL time.Duration `env:"MENU_BADGE_CACHE_TTL" envDefault:"30s"`
+	// MenuCacheTTL is how long built menu trees are reused. Menu and role changes purge them
+	// sooner; 0 disables the cache.
+	MenuCacheTTL time.Duration `env:"MENU_CACHE_TTL" envDefault:"5m"`
+
+	WebAuthnRPID      string   `env:"WEBAUTHN_RP_ID" envDefault:"localhost"`
+	WebAuthnRPName    string   `env:"WEBAUTHN_RP_NAME" envDefault:"Template Fullstack"`
+	WebAuthnRPOrigins []string `env:"WEBAUTHN_RP_ORIGINS" envSeparator:"," envDefault:"http://localhost:4200"`
+
+	// MediaDir is where uploaded files are stored; MediaBaseURL is the public prefix they are served from.
+	MediaDir     string `env:"MEDIA_DIR" envDefault:"./media"`
+	MediaBaseURL string `env:"MEDIA_BASE_URL" envDefault:"/media"`
+
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	SMTPFrom     string `env:"SMTP_FROM" envDefault:"no-reply@localhost"`
 }
 
 func Load() (*Config, error) {
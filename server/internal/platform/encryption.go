@@ -0,0 +1,286 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sealedPrefix marks values produced by Encryptor. The format is
+//
+//	enc:v1:<key id>:<wrapped data key>:<ciphertext>
+//
+// where both binary parts are base64url (no padding) of nonce||AES-256-GCM output.
+const sealedPrefix = "enc:v1:"
+
+// devKeyID names the key derived from JWT_SECRET when no master key is configured in development.
+const devKeyID = "dev"
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{1,32}$`)
+
+// ErrDecrypt is returned for values that are not sealed, were tampered with, belong to another
+// record or were sealed under an unknown master key.
+var ErrDecrypt = errors.New("encryption: cannot decrypt value")
+
+// Encryptor seals secrets with envelope encryption: every value is encrypted with its own random
+// data key, and the data key is encrypted with a master key. Master keys have IDs so they can be
+// rotated: new values use the primary key, the others only decrypt until RunReencryption has
+// moved everything to the primary key.
+type Encryptor struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// Reencrypter is implemented by stores holding sealed values. Reencrypt re-seals a batch of values
+// not yet under the primary key and reports how many it changed; zero means it is done.
+type Reencrypter interface {
+	Reencrypt(ctx context.Context) (int, error)
+}
+
+// NewEncryptor loads the master keys from ENCRYPTION_KEYS_FILE or ENCRYPTION_KEYS. Both hold
+// "id:base64-key" entries of 32-byte keys, the first being the primary. In development, a key
+// derived from JWT_SECRET is used when none is configured.
+func NewEncryptor(cfg *Config) (*Encryptor, error) {
+	var entries []string
+	for _, entry := range cfg.EncryptionKeys {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	if cfg.EncryptionKeysFile != "" {
+		fromFile, err := readKeyFile(cfg.EncryptionKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = fromFile
+	}
+
+	if len(entries) == 0 {
+		if cfg.Env != "development" {
+			return nil, errors.New("encryption: ENCRYPTION_KEYS or ENCRYPTION_KEYS_FILE is required")
+		}
+		slog.Warn("no encryption keys configured, deriving a development key from JWT_SECRET")
+		key := sha256.Sum256([]byte("encryption:" + cfg.JWTSecret))
+		return NewEncryptorWithKeys(devKeyID, map[string][]byte{devKeyID: key[:]})
+	}
+
+	var primary string
+	keys := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("encryption: key entries must look like id:base64-key")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: key %q is not valid base64", id)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("encryption: duplicate key id %q", id)
+		}
+		if primary == "" {
+			primary = id
+		}
+		keys[id] = key
+	}
+	return NewEncryptorWithKeys(primary, keys)
+}
+
+// NewEncryptorWithKeys builds an Encryptor from raw 32-byte master keys.
+func NewEncryptorWithKeys(primary string, keys map[string][]byte) (*Encryptor, error) {
+	e := &Encryptor{primary: primary, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if !keyIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("encryption: key id %q must be 1-32 letters, digits or dashes", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption: key %q must be 32 bytes", id)
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		e.keys[id] = aead
+	}
+	if _, ok := e.keys[primary]; !ok {
+		return nil, fmt.Errorf("encryption: primary key %q is not configured", primary)
+	}
+	return e, nil
+}
+
+// Encrypt seals plaintext under a fresh data key. aad binds the value to its record (for example
+// the table, column and row ID); the same aad must be passed to Decrypt.
+func (e *Encryptor) Encrypt(plaintext, aad []byte) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	// The key ID is authenticated along with the data key so it cannot be swapped.
+	wrapped, err := seal(e.keys[e.primary], dataKey, []byte(e.primary))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, plaintext, aad)
+	if err != nil {
+		return "", err
+	}
+
+	return e.CurrentPrefix() + encode(wrapped) + ":" + encode(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt, under any configured master key.
+func (e *Encryptor) Decrypt(sealed string, aad []byte) ([]byte, error) {
+	rest, ok := strings.CutPrefix(sealed, sealedPrefix)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return nil, ErrDecrypt
+	}
+	master, ok := e.keys[parts[0]]
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	wrapped, err1 := decode(parts[1])
+	ciphertext, err2 := decode(parts[2])
+	if err1 != nil || err2 != nil {
+		return nil, ErrDecrypt
+	}
+
+	dataKey, err := open(master, wrapped, []byte(parts[0]))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := open(data, ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// CurrentPrefix is the prefix of every value sealed under the primary key. Stores use it to find
+// values that still need re-encryption.
+func (e *Encryptor) CurrentPrefix() string {
+	return sealedPrefix + e.primary + ":"
+}
+
+// IsSealed reports whether v was produced by an Encryptor, as opposed to legacy plaintext.
+func IsSealed(v string) bool {
+	return strings.HasPrefix(v, sealedPrefix)
+}
+
+// RunReencryption moves the values of every store to the primary key, once at startup and then
+// every interval until ctx is cancelled. A non-positive interval only runs it at startup.
+func RunReencryption(ctx context.Context, interval time.Duration, stores ...Reencrypter) {
+	reencryptAll(ctx, stores)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reencryptAll(ctx, stores)
+		}
+	}
+}
+
+func reencryptAll(ctx context.Context, stores []Reencrypter) {
+	for _, store := range stores {
+		total := 0
+		for ctx.Err() == nil {
+			n, err := store.Reencrypt(ctx)
+			if err != nil {
+				slog.Error("re-encryption failed", "store", fmt.Sprintf("%T", store), "error", err)
+				break
+			}
+			if n == 0 {
+				break
+			}
+			total += n
+		}
+		if total > 0 {
+			slog.Info("re-encrypted values", "store", fmt.Sprintf("%T", store), "count", total)
+		}
+	}
+}
+
+func readKeyFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+	return entries, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce||ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,104 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestEncryptorRoundTripAndBinding(t *testing.T) {
+	enc, err := NewEncryptorWithKeys("k1", map[string][]byte{"k1": testKey(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := enc.Encrypt([]byte("JBSWY3DPEHPK3PXP"), []byte("row-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || !strings.HasPrefix(sealed, enc.CurrentPrefix()) || strings.Contains(sealed, "JBSWY3DP") {
+		t.Fatalf("unexpected sealed value %q", sealed)
+	}
+	other, _ := enc.Encrypt([]byte("JBSWY3DPEHPK3PXP"), []byte("row-1"))
+	if other == sealed {
+		t.Fatal("expected a fresh data key and nonce per value")
+	}
+
+	plaintext, err := enc.Decrypt(sealed, []byte("row-1"))
+	if err != nil || string(plaintext) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("decrypt: %q, %v", plaintext, err)
+	}
+
+	// Flip a character well inside the ciphertext so the change is never limited to padding bits.
+	i := len(sealed) - 8
+	flipped := byte('A')
+	if sealed[i] == 'A' {
+		flipped = 'B'
+	}
+	tampered := sealed[:i] + string(flipped) + sealed[i+1:]
+	for name, tc := range map[string]struct {
+		value string
+		aad   string
+	}{
+		"other record": {sealed, "row-2"},
+		"tampered":     {tampered, "row-1"},
+		"plaintext":    {"JBSWY3DPEHPK3PXP", "row-1"},
+		"unknown key":  {strings.Replace(sealed, "enc:v1:k1:", "enc:v1:k9:", 1), "row-1"},
+	} {
+		if _, err := enc.Decrypt(tc.value, []byte(tc.aad)); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: expected ErrDecrypt, got %v", name, err)
+		}
+	}
+}
+
+func TestEncryptorRotation(t *testing.T) {
+	old, _ := NewEncryptorWithKeys("k1", map[string][]byte{"k1": testKey(1)})
+	sealed, err := old.Encrypt([]byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "keys")
+	content := "# newest first\nk2:" + base64.StdEncoding.EncodeToString(testKey(2)) + "\n" +
+		"k1:" + base64.StdEncoding.EncodeToString(testKey(1)) + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := NewEncryptor(&Config{Env: "production", EncryptionKeysFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.HasPrefix(sealed, rotated.CurrentPrefix()) {
+		t.Fatal("values sealed under the old key must be picked up for re-encryption")
+	}
+	if plaintext, err := rotated.Decrypt(sealed, nil); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("old values must stay readable: %q, %v", plaintext, err)
+	}
+	resealed, _ := rotated.Encrypt([]byte("secret"), nil)
+	if !strings.HasPrefix(resealed, "enc:v1:k2:") {
+		t.Fatalf("expected new values under the primary key, got %q", resealed)
+	}
+}
+
+func TestNewEncryptorRequiresKeysOutsideDevelopment(t *testing.T) {
+	if _, err := NewEncryptor(&Config{Env: "production", EncryptionKeys: []string{""}}); err == nil {
+		t.Fatal("expected missing keys to be rejected")
+	}
+	if _, err := NewEncryptor(&Config{Env: "development", JWTSecret: "dev"}); err != nil {
+		t.Fatalf("expected a development key, got %v", err)
+	}
+	short := "k1:" + base64.StdEncoding.EncodeToString([]byte("short"))
+	if _, err := NewEncryptor(&Config{Env: "production", EncryptionKeys: []string{short}}); err == nil {
+		t.Fatal("expected a short key to be rejected")
+	}
+}
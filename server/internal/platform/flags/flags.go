@@ -0,0 +1,70 @@
+// Package flags holds the feature flag model shared by the flags module, which stores and
+// administers flags, and the modules that evaluate them.
+package flags
+
+import (
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Flag gates a feature. Enabled turns it on for everyone; otherwise it is on only for the listed
+// users, for holders of any of the listed roles and for Percentage percent of signed-in users.
+// A flag without any of these is off, as is a flag that does not exist.
+type Flag struct {
+	Key         string      `json:"key"`
+	Description string      `json:"description"`
+	Enabled     bool        `json:"enabled"`
+	Roles       []string    `json:"roles"`
+	Users       []uuid.UUID `json:"users"`
+	Percentage  int         `json:"percentage"`
+	UpdatedBy   *uuid.UUID  `json:"updated_by,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Subject is who a flag is evaluated for. Anonymous requests have a nil UserID and no roles, so
+// only globally enabled flags apply to them.
+type Subject struct {
+	UserID uuid.UUID
+	Roles  []string
+}
+
+var keyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,99}$`)
+
+// ValidKey reports whether key is a usable flag key: lowercase letters, digits, dots, dashes and
+// underscores, at most 100 characters.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// EnabledFor reports whether the flag is on for s.
+func (f Flag) EnabledFor(s Subject) bool {
+	if f.Enabled {
+		return true
+	}
+	if s.UserID == uuid.Nil {
+		return false
+	}
+	if slices.Contains(f.Users, s.UserID) {
+		return true
+	}
+	for _, role := range s.Roles {
+		if slices.Contains(f.Roles, role) {
+			return true
+		}
+	}
+	return f.Percentage > 0 && Bucket(f.Key, s.UserID) < f.Percentage
+}
+
+// Bucket places a user in one of 100 rollout buckets. It depends on the flag key too, so the same
+// users are not always the first to receive every feature; raising a percentage only adds users.
+func Bucket(key string, userID uuid.UUID) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write(userID[:])
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,76 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEnabledFor(t *testing.T) {
+	user := uuid.New()
+	cases := []struct {
+		name    string
+		flag    Flag
+		subject Subject
+		want    bool
+	}{
+		{"global", Flag{Key: "f", Enabled: true}, Subject{}, true},
+		{"off", Flag{Key: "f"}, Subject{UserID: user}, false},
+		{"listed user", Flag{Key: "f", Users: []uuid.UUID{user}}, Subject{UserID: user}, true},
+		{"other user", Flag{Key: "f", Users: []uuid.UUID{uuid.New()}}, Subject{UserID: user}, false},
+		{"role", Flag{Key: "f", Roles: []string{"editor"}}, Subject{UserID: user, Roles: []string{"viewer", "editor"}}, true},
+		{"missing role", Flag{Key: "f", Roles: []string{"admin"}}, Subject{UserID: user, Roles: []string{"editor"}}, false},
+		{"full rollout", Flag{Key: "f", Percentage: 100}, Subject{UserID: user}, true},
+		{"anonymous rollout", Flag{Key: "f", Percentage: 100}, Subject{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.flag.EnabledFor(tc.subject); got != tc.want {
+				t.Fatalf("EnabledFor = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPercentageRolloutIsStableAndGrows(t *testing.T) {
+	users := make([]uuid.UUID, 1000)
+	for i := range users {
+		users[i] = uuid.New()
+	}
+
+	count := func(percentage int) map[uuid.UUID]bool {
+		on := make(map[uuid.UUID]bool)
+		f := Flag{Key: "new-editor", Percentage: percentage}
+		for _, u := range users {
+			if f.EnabledFor(Subject{UserID: u}) {
+				on[u] = true
+			}
+		}
+		return on
+	}
+
+	ten, fifty := count(10), count(50)
+	if len(ten) < 50 || len(ten) > 150 {
+		t.Fatalf("expected about 10%% of users, got %d of %d", len(ten), len(users))
+	}
+	for u := range ten {
+		if !fifty[u] {
+			t.Fatalf("user %s lost the feature when the rollout grew", u)
+		}
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"cms.new-editor": true,
+		"shop_v2":        true,
+		"":               false,
+		"-shop":          false,
+		"Shop":           false,
+		"shop editor":    false,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
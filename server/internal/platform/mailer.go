@@ -0,0 +1,66 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+)
+
+// Mailer delivers plain-text emails.
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailer returns an SMTP mailer when SMTP is configured, and a mailer that only logs otherwise.
+func NewMailer(cfg *Config) Mailer {
+	if cfg.SMTPHost == "" {
+		return logMailer{}
+	}
+	return smtpMailer{
+		addr: fmt.Sprintf("%s:%d", cfg.SMTPHost, cfg.SMTPPort),
+		host: cfg.SMTPHost,
+		user: cfg.SMTPUsername,
+		pass: cfg.SMTPPassword,
+		from: cfg.SMTPFrom,
+	}
+}
+
+type smtpMailer struct {
+	addr string
+	host string
+	user string
+	pass string
+	from string
+}
+
+func (m smtpMailer) Send(_ context.Context, to, subject, body string) error {
+	var auth smtp.Auth
+	if m.user != "" {
+		auth = smtp.PlainAuth("", m.user, m.pass, m.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
+
+// logMailer is used in development when no SMTP server is configured.
+type logMailer struct{}
+
+func (logMailer) Send(_ context.Context, to, subject, body string) error {
+	slog.Info("email not sent, SMTP is not configured", "to", to, "subject", subject, "body", body)
+	return nil
+}
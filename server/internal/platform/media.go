@@ -0,0 +1,99 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MediaStorage stores user-uploaded files under opaque keys such as "avatars/<user>/<id>.png".
+type MediaStorage interface {
+	Put(ctx context.Context, key, contentType string, r io.Reader) error
+	Delete(ctx context.Context, key string) error
+	// URL returns the public address a stored key is served from.
+	URL(key string) string
+}
+
+// NewMediaStorage returns a storage backed by the local MEDIA_DIR directory.
+func NewMediaStorage(cfg *Config) MediaStorage {
+	return localMediaStorage{
+		dir:     cfg.MediaDir,
+		baseURL: strings.TrimRight(cfg.MediaBaseURL, "/"),
+	}
+}
+
+type localMediaStorage struct {
+	dir     string
+	baseURL string
+}
+
+func (s localMediaStorage) Put(_ context.Context, key, _ string, r io.Reader) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("media put: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial upload.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("media put: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("media put: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("media put: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("media put: %w", err)
+	}
+	return nil
+}
+
+func (s localMediaStorage) Delete(_ context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("media delete: %w", err)
+	}
+	return nil
+}
+
+func (s localMediaStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path resolves key inside the media directory, rejecting keys that would escape it.
+func (s localMediaStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("media: invalid key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+// MediaHandler serves files from MEDIA_DIR. Directory listings are disabled.
+func MediaHandler(cfg *Config) http.Handler {
+	fs := http.FileServer(http.Dir(cfg.MediaDir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		fs.ServeHTTP(w, r)
+	})
+}
@@ -1,22 +1,102 @@
 package menu
 
+// MenuDefinition is a backoffice menu item declared by a module. Label is the text in the default
+// locale; Labels holds translations keyed by BCP 47 tag, e.g. "pt" or "es-MX". BadgeSubject, when
+// set, is a NATS subject the declaring module answers with the item's badge (see
+// events.MenuBadgeRequest). Placement picks the menu the item belongs to; items without one
+// inherit their parent's, and top-level items default to the sidebar. RequiredFlag names a
+// feature flag that must be on for the user, hiding the item and its children otherwise.
 type MenuDefinition struct {
-	ID          string           `json:"id"`
-	Domain      string           `json:"domain"`
-	Label       string           `json:"label"`
-	Path        string           `json:"path,omitempty"`
-	Icon        string           `json:"icon,omitempty"`
-	Order       int              `json:"order,omitempty"`
-	ParentID    string           `json:"parent_id,omitempty"`
-	Permissions []string         `json:"permissions,omitempty"`
-	Visible     bool             `json:"visible"`
-	Children    []MenuDefinition `json:"children,omitempty"`
+	ID           string            `json:"id"`
+	Domain       string            `json:"domain"`
+	Label        string            `json:"label"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Path         string            `json:"path,omitempty"`
+	Icon         string            `json:"icon,omitempty"`
+	Order        int               `json:"order,omitempty"`
+	ParentID     string            `json:"parent_id,omitempty"`
+	Permissions  []string          `json:"permissions,omitempty"`
+	Visible      bool              `json:"visible"`
+	BadgeSubject string            `json:"badge_subject,omitempty"`
+	Placement    Placement         `json:"placement,omitempty"`
+	RequiredFlag string            `json:"required_flag,omitempty"`
+	Children     []MenuDefinition  `json:"children,omitempty"`
 }
 
+// Placement is one of the backoffice menus an item can be contributed to.
+type Placement string
+
+const (
+	PlacementSidebar      Placement = "sidebar"
+	PlacementTopbar       Placement = "topbar"
+	PlacementUserMenu     Placement = "user_menu"
+	PlacementQuickActions Placement = "quick_actions"
+)
+
+// Placements lists every placement, the default first.
+var Placements = []Placement{PlacementSidebar, PlacementTopbar, PlacementUserMenu, PlacementQuickActions}
+
+// OrDefault returns p, or the sidebar when p is empty.
+func (p Placement) OrDefault() Placement {
+	if p == "" {
+		return PlacementSidebar
+	}
+	return p
+}
+
+func (p Placement) Valid() bool {
+	for _, known := range Placements {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Sections built per user from their menu preferences and returned next to the placements. They
+// hold flat lists of items from the placements and cannot be registered to.
+const (
+	SectionFavorites Placement = "favorites"
+	SectionRecent    Placement = "recent"
+)
+
+// MenuTrees holds a user's menu for every placement and section; those without items are empty.
+type MenuTrees map[Placement][]MenuNode
+
 type MenuNode struct {
 	Id       string     `json:"id"`
 	Label    string     `json:"label"`
 	Path     string     `json:"path,omitempty"`
 	Icon     string     `json:"icon,omitempty"`
+	Badge    *Badge     `json:"badge,omitempty"`
 	Children []MenuNode `json:"children,omitempty"`
 }
+
+// Badge is a counter shown next to a menu item, e.g. items awaiting review.
+type Badge struct {
+	Count int `json:"count"`
+}
+
+// ProblemKind classifies a defect found in registered menu definitions.
+type ProblemKind string
+
+const (
+	ProblemDuplicateID       ProblemKind = "duplicate_id"
+	ProblemOrphan            ProblemKind = "orphan"
+	ProblemCycle             ProblemKind = "cycle"
+	ProblemUnknownPermission ProblemKind = "unknown_permission"
+	ProblemInvalidLocale     ProblemKind = "invalid_locale"
+	ProblemInvalidBadge      ProblemKind = "invalid_badge_subject"
+	ProblemInvalidPlacement  ProblemKind = "invalid_placement"
+	ProblemInvalidFlag       ProblemKind = "invalid_flag"
+)
+
+// Problem is a defect in one menu item. Problems marked Blocking cause the registration to be
+// rejected; the others are reported but the menu is still applied.
+type Problem struct {
+	Domain   string      `json:"domain"`
+	MenuID   string      `json:"menu_id"`
+	Kind     ProblemKind `json:"kind"`
+	Blocking bool        `json:"blocking"`
+	Message  string      `json:"message"`
+}
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_roles
+    ADD COLUMN valid_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    ADD COLUMN valid_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN granted_by UUID,
+    ADD CONSTRAINT chk_user_roles_window CHECK (valid_until IS NULL OR valid_until > valid_from);
+
+CREATE INDEX idx_user_roles_valid_until ON user_roles(valid_until) WHERE valid_until IS NOT NULL;
+
+CREATE TABLE role_elevation_requests (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    duration_minutes INTEGER NOT NULL CHECK (duration_minutes > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decision_note TEXT NOT NULL DEFAULT '',
+    decided_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_role_elevation_requests_status ON role_elevation_requests(status, created_at);
+CREATE INDEX idx_role_elevation_requests_user ON role_elevation_requests(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE role_elevation_requests;
+
+DROP INDEX IF EXISTS idx_user_roles_valid_until;
+ALTER TABLE user_roles
+    DROP CONSTRAINT chk_user_roles_window,
+    DROP COLUMN granted_by,
+    DROP COLUMN valid_until,
+    DROP COLUMN valid_from;
+-- +goose StatementEnd
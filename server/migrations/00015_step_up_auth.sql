@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing sessions count as authenticated when they were opened.
+ALTER TABLE auth_sessions ADD COLUMN auth_time TIMESTAMP WITH TIME ZONE;
+UPDATE auth_sessions SET auth_time = created_at;
+ALTER TABLE auth_sessions
+    ALTER COLUMN auth_time SET NOT NULL,
+    ALTER COLUMN auth_time SET DEFAULT now();
+
+CREATE TABLE user_totp_factors (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_totp_factors;
+
+ALTER TABLE auth_sessions DROP COLUMN auth_time;
+-- +goose StatementEnd
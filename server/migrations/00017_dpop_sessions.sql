@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE auth_sessions ADD COLUMN dpop_jkt VARCHAR(64) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE auth_sessions DROP COLUMN dpop_jkt;
+-- +goose StatementEnd
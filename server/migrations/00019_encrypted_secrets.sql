@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Sealed values (enc:v1:...) are longer than the plaintext secrets they replace.
+ALTER TABLE user_totp_factors ALTER COLUMN secret TYPE TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Only succeeds once no sealed secret is left; remove the factors first if needed.
+ALTER TABLE user_totp_factors ALTER COLUMN secret TYPE VARCHAR(64);
+-- +goose StatementEnd
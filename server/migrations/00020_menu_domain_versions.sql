@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE menu_domains (
+    domain VARCHAR(50) PRIMARY KEY,
+    version INTEGER NOT NULL,
+    registered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- Domains that registered before versioning start at 0, so their first versioned registration applies.
+INSERT INTO menu_domains (domain, version)
+SELECT DISTINCT domain, 0 FROM menu_definitions;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE menu_domains;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Admin changes to module-declared menu items. Kept apart from menu_definitions so module
+-- re-registrations never overwrite them; NULL columns keep the declared value.
+CREATE TABLE menu_overrides (
+    menu_id VARCHAR(120) PRIMARY KEY REFERENCES menu_definitions(id) ON DELETE CASCADE,
+    hidden BOOLEAN,
+    label VARCHAR(100),
+    icon VARCHAR(100),
+    order_index INTEGER,
+    updated_by UUID,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE menu_overrides;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Translations of label keyed by BCP 47 tag; label stays the default-locale text.
+ALTER TABLE menu_definitions
+    ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE menu_definitions
+    DROP COLUMN labels;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NATS subject answering the item's badge count; empty when the item has no badge.
+ALTER TABLE menu_definitions
+    ADD COLUMN badge_subject VARCHAR(200) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE menu_definitions
+    DROP COLUMN badge_subject;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Which backoffice menu the item belongs to: sidebar, topbar, user_menu or quick_actions.
+ALTER TABLE menu_definitions
+    ADD COLUMN placement VARCHAR(32) NOT NULL DEFAULT 'sidebar';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE menu_definitions
+    DROP COLUMN placement;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key         VARCHAR(100) PRIMARY KEY,
+    description TEXT         NOT NULL DEFAULT '',
+    -- On for everyone; otherwise only for the targeted users, roles and rollout percentage.
+    enabled     BOOLEAN      NOT NULL DEFAULT FALSE,
+    roles       TEXT[]       NOT NULL DEFAULT '{}',
+    users       UUID[]       NOT NULL DEFAULT '{}',
+    percentage  SMALLINT     NOT NULL DEFAULT 0 CHECK (percentage BETWEEN 0 AND 100),
+    updated_by  UUID         REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS feature_flags;
+-- +goose StatementEnd
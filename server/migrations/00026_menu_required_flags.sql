@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Feature flag that must be on for the user to see the item. Not a foreign key: modules may
+-- declare flags before an administrator creates them, and unknown flags are off.
+ALTER TABLE menu_definitions
+    ADD COLUMN required_flag VARCHAR(100) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE menu_definitions
+    DROP COLUMN required_flag;
+-- +goose StatementEnd
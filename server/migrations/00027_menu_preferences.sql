@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Menu items a user pinned, in the order they arranged them.
+CREATE TABLE menu_pins (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    menu_id VARCHAR(120) NOT NULL REFERENCES menu_definitions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (user_id, menu_id)
+);
+
+-- The last menu items a user navigated to; older visits are pruned as new ones are recorded.
+CREATE TABLE menu_recent_items (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    menu_id VARCHAR(120) NOT NULL REFERENCES menu_definitions(id) ON DELETE CASCADE,
+    visited_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, menu_id)
+);
+
+CREATE INDEX idx_menu_recent_items_user_visited ON menu_recent_items(user_id, visited_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE menu_recent_items;
+DROP TABLE menu_pins;
+-- +goose StatementEnd
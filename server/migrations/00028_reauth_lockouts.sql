@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Failed re-authentication attempts per user and factor ('password' or 'totp'). Reaching the
+-- limit locks that factor until locked_until; a successful attempt clears the row.
+CREATE TABLE reauth_lockouts (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (user_id, method)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reauth_lockouts;
+-- +goose StatementEnd
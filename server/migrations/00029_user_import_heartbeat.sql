@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Running imports save their progress at least every minute. An unfinished job that has not been
+-- updated for a while lost its runner, e.g. to a restart, and is marked failed.
+ALTER TABLE user_import_jobs ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now();
+
+CREATE INDEX idx_user_import_jobs_unfinished ON user_import_jobs(updated_at) WHERE finished_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_import_jobs_unfinished;
+ALTER TABLE user_import_jobs DROP COLUMN updated_at;
+-- +goose StatementEnd
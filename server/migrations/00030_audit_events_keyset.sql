@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Listings and exports page by keyset on (occurred_at, id), newest first.
+DROP INDEX idx_audit_events_occurred_at;
+CREATE INDEX idx_audit_events_occurred_at_id ON audit_events(occurred_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_audit_events_occurred_at_id;
+CREATE INDEX idx_audit_events_occurred_at ON audit_events(occurred_at DESC);
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Menu favorites and recent items are finished: turn their flag on for everyone. The flag stays so
+-- they can be switched off again without a deploy; a flag an admin already created is kept as is.
+INSERT INTO feature_flags (key, description, enabled)
+VALUES ('auth.menu.preferences', 'Menu favorites and recent items.', TRUE)
+ON CONFLICT (key) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM feature_flags WHERE key = 'auth.menu.preferences';
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Serves case-insensitive lookups of existing addresses, such as the user import's.
+CREATE INDEX idx_users_email_lower ON users(lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_email_lower;
+-- +goose StatementEnd
@@ -0,0 +1,100 @@
+// Package authz is a typed client for the permission subjects answered by the auth module, for
+// modules and services that must not read the auth tables themselves.
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// DefaultTimeout bounds a request when the caller's context has no deadline.
+const DefaultTimeout = 2 * time.Second
+
+// ErrUnavailable is returned when no auth instance answered in time.
+var ErrUnavailable = errors.New("authz: auth module unavailable")
+
+type Client struct {
+	request func(ctx context.Context, subject string, data []byte) (*nats.Msg, error)
+	timeout time.Duration
+}
+
+func NewClient(nc *nats.Conn) *Client {
+	return &Client{request: nc.RequestWithContext, timeout: DefaultTimeout}
+}
+
+// HasPermission reports whether the user holds permission when acting from ip. Pass the zero
+// Addr when the address is unknown; roles restricted to an IP allowlist then do not count.
+func (c *Client) HasPermission(ctx context.Context, userID uuid.UUID, permission string, ip netip.Addr) (bool, error) {
+	req := events.AuthPermissionCheckRequest{UserID: userID, Permission: permission, IP: formatIP(ip)}
+	var reply events.AuthPermissionCheckReply
+	if err := c.call(ctx, events.AuthPermissionsCheck, req, &reply); err != nil {
+		return false, err
+	}
+	if reply.Error != "" {
+		return false, fmt.Errorf("authz: %s", reply.Error)
+	}
+	return reply.Allowed, nil
+}
+
+// Permissions returns the user's effective permissions when acting from ip.
+func (c *Client) Permissions(ctx context.Context, userID uuid.UUID, ip netip.Addr) ([]string, error) {
+	req := events.AuthPermissionListRequest{UserID: userID, IP: formatIP(ip)}
+	var reply events.AuthPermissionListReply
+	if err := c.call(ctx, events.AuthPermissionsList, req, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, fmt.Errorf("authz: %s", reply.Error)
+	}
+	return reply.Permissions, nil
+}
+
+// Roles returns the names of the user's effective roles when acting from ip.
+func (c *Client) Roles(ctx context.Context, userID uuid.UUID, ip netip.Addr) ([]string, error) {
+	req := events.AuthRoleListRequest{UserID: userID, IP: formatIP(ip)}
+	var reply events.AuthRoleListReply
+	if err := c.call(ctx, events.AuthRolesList, req, &reply); err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, fmt.Errorf("authz: %s", reply.Error)
+	}
+	return reply.Roles, nil
+}
+
+func (c *Client) call(ctx context.Context, subject string, req, reply any) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	msg, err := c.request(ctx, subject, data)
+	if err != nil {
+		if errors.Is(err, nats.ErrNoResponders) || errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		return err
+	}
+	return json.Unmarshal(msg.Data, reply)
+}
+
+func formatIP(ip netip.Addr) string {
+	if !ip.IsValid() {
+		return ""
+	}
+	return ip.String()
+}
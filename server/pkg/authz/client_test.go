@@ -0,0 +1,104 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+// stubClient answers every request with reply and remembers the last subject and payload.
+func stubClient(t *testing.T, reply any, err error) (*Client, *nats.Msg) {
+	t.Helper()
+	sent := &nats.Msg{}
+	c := &Client{timeout: time.Second, request: func(ctx context.Context, subject string, data []byte) (*nats.Msg, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected every request to have a deadline")
+		}
+		sent.Subject, sent.Data = subject, data
+		if err != nil {
+			return nil, err
+		}
+		body, _ := json.Marshal(reply)
+		return &nats.Msg{Data: body}, nil
+	}}
+	return c, sent
+}
+
+func TestHasPermission(t *testing.T) {
+	userID := uuid.New()
+	c, sent := stubClient(t, events.AuthPermissionCheckReply{Allowed: true}, nil)
+
+	allowed, err := c.HasPermission(context.Background(), userID, "cms.page.read", netip.MustParseAddr("203.0.113.7"))
+	if err != nil || !allowed {
+		t.Fatalf("expected the permission to be granted, got %v, %v", allowed, err)
+	}
+	var req events.AuthPermissionCheckRequest
+	if err := json.Unmarshal(sent.Data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Subject != events.AuthPermissionsCheck || req.UserID != userID || req.Permission != "cms.page.read" || req.IP != "203.0.113.7" {
+		t.Fatalf("unexpected request %s %+v", sent.Subject, req)
+	}
+}
+
+func TestPermissionsAndRolesSendAnUnknownAddressAsEmpty(t *testing.T) {
+	c, sent := stubClient(t, events.AuthPermissionListReply{Permissions: []string{"cms.page.read"}}, nil)
+	perms, err := c.Permissions(context.Background(), uuid.New(), netip.Addr{})
+	if err != nil || !slices.Equal(perms, []string{"cms.page.read"}) {
+		t.Fatalf("unexpected permissions %v, %v", perms, err)
+	}
+	var listReq events.AuthPermissionListRequest
+	_ = json.Unmarshal(sent.Data, &listReq)
+	if sent.Subject != events.AuthPermissionsList || listReq.IP != "" {
+		t.Fatalf("unexpected request %s %+v", sent.Subject, listReq)
+	}
+
+	c, sent = stubClient(t, events.AuthRoleListReply{Roles: []string{"Editor"}}, nil)
+	roles, err := c.Roles(context.Background(), uuid.New(), netip.Addr{})
+	if err != nil || !slices.Equal(roles, []string{"Editor"}) {
+		t.Fatalf("unexpected roles %v, %v", roles, err)
+	}
+	if sent.Subject != events.AuthRolesList {
+		t.Fatalf("unexpected subject %s", sent.Subject)
+	}
+}
+
+func TestErrorReplies(t *testing.T) {
+	ctx := context.Background()
+
+	c, _ := stubClient(t, events.AuthPermissionCheckReply{Error: "invalid request"}, nil)
+	if allowed, err := c.HasPermission(ctx, uuid.New(), "x", netip.Addr{}); err == nil || allowed {
+		t.Fatalf("expected the error reply to fail the check, got %v, %v", allowed, err)
+	}
+	c, _ = stubClient(t, events.AuthPermissionListReply{Error: "database unavailable"}, nil)
+	if _, err := c.Permissions(ctx, uuid.New(), netip.Addr{}); err == nil || err.Error() != "authz: database unavailable" {
+		t.Fatalf("expected the error reply to be returned, got %v", err)
+	}
+	c, _ = stubClient(t, events.AuthRoleListReply{Error: "database unavailable"}, nil)
+	if _, err := c.Roles(ctx, uuid.New(), netip.Addr{}); err == nil {
+		t.Fatal("expected the error reply to be returned")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	for _, cause := range []error{nats.ErrNoResponders, context.DeadlineExceeded} {
+		c, _ := stubClient(t, nil, cause)
+		if _, err := c.HasPermission(context.Background(), uuid.New(), "x", netip.Addr{}); !errors.Is(err, ErrUnavailable) {
+			t.Errorf("%v: expected ErrUnavailable, got %v", cause, err)
+		}
+	}
+
+	other := errors.New("connection closed")
+	c, _ := stubClient(t, nil, other)
+	if _, err := c.Roles(context.Background(), uuid.New(), netip.Addr{}); !errors.Is(err, other) || errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected other failures to be passed through, got %v", err)
+	}
+}
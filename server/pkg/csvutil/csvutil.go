@@ -0,0 +1,52 @@
+// Package csvutil writes CSV that is safe to open in a spreadsheet application.
+package csvutil
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// formulaPrefixes are the leading characters that make spreadsheet applications evaluate a cell
+// as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// Writer is a csv.Writer that escapes every cell with Escape.
+type Writer struct {
+	*csv.Writer
+}
+
+// NewWriter returns a Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{Writer: csv.NewWriter(w)}
+}
+
+// Write writes a single record, escaping each cell.
+func (w *Writer) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, cell := range record {
+		escaped[i] = Escape(cell)
+	}
+	return w.Writer.Write(escaped)
+}
+
+// Escape prefixes cell with a single quote if it would otherwise be read as a formula. Cells that
+// already look escaped get a second quote, so Unescape restores them as written.
+func Escape(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) || isEscaped(cell) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// Unescape reverses Escape, so an exported file can be read back.
+func Unescape(cell string) string {
+	if isEscaped(cell) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func isEscaped(cell string) bool {
+	return len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes+"'", rune(cell[1]))
+}
@@ -0,0 +1,47 @@
+package csvutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	cases := map[string]string{
+		"":                  "",
+		"Ada Lovelace":      "Ada Lovelace",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1 555":            "'+1 555",
+		"-2+3":              "'-2+3",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\tcmd":             "'\tcmd",
+		"\rcmd":             "'\rcmd",
+		"'quoted":           "'quoted",
+		"a=b":               "a=b",
+		"'=already":         "''=already",
+		"''":                "'''",
+		"'":                 "'",
+	}
+	for in, want := range cases {
+		if got := Escape(in); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", in, got, want)
+		}
+		if got := Unescape(Escape(in)); got != in {
+			t.Errorf("Unescape(Escape(%q)) = %q", in, got)
+		}
+	}
+}
+
+func TestWriterEscapesEveryCell(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Write([]string{"id", "=1+1", "-x"}); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "id,'=1+1,'-x\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
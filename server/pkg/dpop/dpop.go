@@ -0,0 +1,136 @@
+// Package dpop verifies OAuth 2.0 Demonstrating Proof of Possession (DPoP, RFC 9449) proofs.
+package dpop
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/replay"
+)
+
+// Header is the request header carrying the proof.
+const Header = "DPoP"
+
+// ErrInvalidProof wraps every verification failure.
+var ErrInvalidProof = errors.New("invalid DPoP proof")
+
+// signingMethods are the asymmetric algorithms accepted for proofs. Symmetric algorithms and
+// "none" cannot prove possession of a key and are rejected.
+var signingMethods = []string{"ES256", "ES384", "ES512", "RS256", "PS256", "EdDSA"}
+
+// Proof is a verified DPoP proof.
+type Proof struct {
+	// Thumbprint is the RFC 7638 thumbprint of the key that signed the proof, the value bound
+	// into tokens as cnf.jkt.
+	Thumbprint string
+	JTI        string
+	IssuedAt   time.Time
+}
+
+type proofClaims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verifier checks proofs and remembers their jti so each proof is accepted once. Replay protection
+// is per instance: the cache lives in memory, so behind a load balancer a captured proof can be
+// replayed once against each other instance within its acceptance window.
+type Verifier struct {
+	maxAge time.Duration
+	seen   *replay.Cache
+}
+
+// NewVerifier accepts proofs issued at most maxAge ago (and no more than maxAge in the future, to
+// absorb clock skew).
+func NewVerifier(maxAge time.Duration) *Verifier {
+	return &Verifier{maxAge: maxAge, seen: replay.NewCache()}
+}
+
+// Present reports whether the request carries a proof.
+func Present(r *http.Request) bool {
+	return len(r.Header.Values(Header)) > 0
+}
+
+// Verify checks the request's proof. accessToken is the token presented alongside it, whose hash
+// the proof must carry in "ath"; pass "" on token requests such as login and refresh.
+func (v *Verifier) Verify(r *http.Request, accessToken string) (*Proof, error) {
+	values := r.Header.Values(Header)
+	if len(values) != 1 {
+		return nil, fmt.Errorf("%w: expected exactly one %s header", ErrInvalidProof, Header)
+	}
+
+	var key *jwk
+	claims := &proofClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(signingMethods), jwt.WithoutClaimsValidation())
+	_, err := parser.ParseWithClaims(values[0], claims, func(t *jwt.Token) (any, error) {
+		if typ, _ := t.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, errors.New("typ must be dpop+jwt")
+		}
+		k, pub, err := parseJWK(t.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		key = k
+		return pub, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return nil, fmt.Errorf("%w: jti and iat are required", ErrInvalidProof)
+	}
+	now := time.Now()
+	issuedAt := claims.IssuedAt.Time
+	if now.Sub(issuedAt) > v.maxAge || issuedAt.Sub(now) > v.maxAge {
+		return nil, fmt.Errorf("%w: iat outside the accepted window", ErrInvalidProof)
+	}
+	if claims.HTM != r.Method {
+		return nil, fmt.Errorf("%w: htm does not match the request method", ErrInvalidProof)
+	}
+	if !matchesRequestURL(claims.HTU, r) {
+		return nil, fmt.Errorf("%w: htu does not match the request URL", ErrInvalidProof)
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		if claims.ATH != base64.RawURLEncoding.EncodeToString(sum[:]) {
+			return nil, fmt.Errorf("%w: ath does not match the access token", ErrInvalidProof)
+		}
+	}
+
+	proof := &Proof{Thumbprint: key.Thumbprint(), JTI: claims.ID, IssuedAt: issuedAt}
+	if !v.remember(proof.Thumbprint+"|"+proof.JTI, now) {
+		return nil, fmt.Errorf("%w: proof was already used", ErrInvalidProof)
+	}
+	return proof, nil
+}
+
+// remember records key and reports false if it was already seen within the acceptance window.
+func (v *Verifier) remember(key string, now time.Time) bool {
+	// Entries only need to outlive the window in which their proof could still be accepted.
+	return v.seen.Add(key, now.Add(2*v.maxAge), now)
+}
+
+// matchesRequestURL compares htu with the request's host and path, ignoring query and fragment
+// (RFC 9449 section 4.3). The scheme is not compared because TLS is usually terminated by a proxy
+// in front of the API.
+func matchesRequestURL(htu string, r *http.Request) bool {
+	u, err := url.Parse(htu)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return strings.EqualFold(u.Host, r.Host) && path == r.URL.EscapedPath()
+}
@@ -0,0 +1,119 @@
+package dpop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, map[string]any) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := func(b []byte) string {
+		out := make([]byte, 32)
+		copy(out[32-len(b):], b)
+		return base64.RawURLEncoding.EncodeToString(out)
+	}
+	return key, map[string]any{"kty": "EC", "crv": "P-256", "x": pad(key.X.Bytes()), "y": pad(key.Y.Bytes())}
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, jwk map[string]any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = jwk
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func proofClaimsFor(method, url string) jwt.MapClaims {
+	return jwt.MapClaims{"htm": method, "htu": url, "iat": time.Now().Unix(), "jti": uuid.NewString()}
+}
+
+func TestVerifyAcceptsProofOnce(t *testing.T) {
+	key, jwk := newKey(t)
+	v := NewVerifier(time.Minute)
+
+	r := httptest.NewRequest("POST", "http://api.example.com/auth/login?x=1", nil)
+	r.Header.Set(Header, sign(t, key, jwk, proofClaimsFor("POST", "https://api.example.com/auth/login")))
+
+	proof, err := v.Verify(r, "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if len(proof.Thumbprint) != 43 {
+		t.Fatalf("unexpected thumbprint %q", proof.Thumbprint)
+	}
+	if _, err := v.Verify(r, ""); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("expected replayed proof to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyChecksAccessTokenHash(t *testing.T) {
+	key, jwk := newKey(t)
+	v := NewVerifier(time.Minute)
+	sum := sha256.Sum256([]byte("access-token"))
+
+	claims := proofClaimsFor("GET", "https://api.example.com/me")
+	claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	r := httptest.NewRequest("GET", "http://api.example.com/me", nil)
+	r.Header.Set(Header, sign(t, key, jwk, claims))
+	if _, err := v.Verify(r, "other-token"); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("expected ath mismatch, got %v", err)
+	}
+
+	claims["jti"] = uuid.NewString()
+	r.Header.Set(Header, sign(t, key, jwk, claims))
+	if _, err := v.Verify(r, "access-token"); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestVerifyRejectsMismatchedProofs(t *testing.T) {
+	key, jwk := newKey(t)
+	v := NewVerifier(time.Minute)
+
+	stale := proofClaimsFor("POST", "https://api.example.com/auth/login")
+	stale["iat"] = time.Now().Add(-5 * time.Minute).Unix()
+	private := map[string]any{"kty": "EC", "crv": "P-256", "x": jwk["x"], "y": jwk["y"], "d": "secret"}
+
+	cases := map[string]string{
+		"method":      sign(t, key, jwk, proofClaimsFor("GET", "https://api.example.com/auth/login")),
+		"url":         sign(t, key, jwk, proofClaimsFor("POST", "https://api.example.com/auth/refresh")),
+		"stale":       sign(t, key, jwk, stale),
+		"private key": sign(t, key, private, proofClaimsFor("POST", "https://api.example.com/auth/login")),
+	}
+	for name, proof := range cases {
+		r := httptest.NewRequest("POST", "http://api.example.com/auth/login", nil)
+		r.Header.Set(Header, proof)
+		if _, err := v.Verify(r, ""); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("%s: expected ErrInvalidProof, got %v", name, err)
+		}
+	}
+}
+
+func TestThumbprintMatchesRFC7638Example(t *testing.T) {
+	k := &jwk{
+		Kty: "RSA",
+		E:   "AQAB",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+	}
+	if got := k.Thumbprint(); got != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Fatalf("unexpected thumbprint %s", got)
+	}
+}
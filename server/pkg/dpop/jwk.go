@@ -0,0 +1,109 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// jwk holds the public members of a JSON Web Key (RFC 7517) this package understands.
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	D   string `json:"d,omitempty"`
+}
+
+func parseJWK(raw any) (*jwk, crypto.PublicKey, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	var k jwk
+	if err := json.Unmarshal(data, &k); err != nil {
+		return nil, nil, errors.New("jwk header is not an object")
+	}
+	if k.D != "" {
+		return nil, nil, errors.New("jwk header contains a private key")
+	}
+
+	switch k.Kty {
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeInt(k.X)
+		y, errY := decodeInt(k.Y)
+		if errX != nil || errY != nil {
+			return nil, nil, errors.New("invalid EC coordinates")
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, nil, errors.New("EC point is not on the curve")
+		}
+		return &k, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "RSA":
+		n, errN := decodeInt(k.N)
+		e, errE := decodeInt(k.E)
+		if errN != nil || errE != nil || !e.IsInt64() {
+			return nil, nil, errors.New("invalid RSA key")
+		}
+		if n.BitLen() < 2048 {
+			return nil, nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return &k, &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("invalid Ed25519 key")
+		}
+		return &k, ed25519.PublicKey(x), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// Thumbprint is the RFC 7638 SHA-256 thumbprint of the key, base64url encoded. The required
+// members are serialised in lexicographic order with no whitespace.
+func (k *jwk) Thumbprint() string {
+	var canonical string
+	switch k.Kty {
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Crv, k.X, k.Y)
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"OKP","x":%q}`, k.Crv, k.X)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
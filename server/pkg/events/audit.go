@@ -0,0 +1,54 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+type actorContextKey struct{}
+
+// Actor identifies who triggered an event and from where.
+type Actor struct {
+	UserID    string `json:"user_id,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Target identifies the entity an event acted upon.
+type Target struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Trail carries the attribution shared by every auditable event payload.
+// Payloads embed it so the audit module can decode any of them generically.
+type Trail struct {
+	Actor      Actor     `json:"actor"`
+	Target     Target    `json:"target"`
+	Before     any       `json:"before,omitempty"`
+	After      any       `json:"after,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// WithActor stores the request actor in the context so services can attribute the events they publish.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or an empty Actor for system-initiated work.
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorContextKey{}).(Actor)
+	return actor
+}
+
+// NewTrail builds the attribution for an event about target using the actor found in ctx.
+func NewTrail(ctx context.Context, target Target, before, after any) Trail {
+	return Trail{
+		Actor:      ActorFromContext(ctx),
+		Target:     target,
+		Before:     before,
+		After:      after,
+		OccurredAt: time.Now().UTC(),
+	}
+}
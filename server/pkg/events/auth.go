@@ -1,9 +1,311 @@
 package events
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 const (
 	AuthUserRegistered      = "auth.user.registered"
 	AuthUserUpdated         = "auth.user.updated"
 	AuthUserDeleted         = "auth.user.deleted"
 	AuthUserPasswordChanged = "auth.user.password.changed"
 	AuthUserPasswordReset   = "auth.user.password.reset"
+	AuthUserReauthenticated = "auth.user.reauthenticated"
+
+	AuthUserInvited            = "auth.user.invited"
+	AuthUserInvitationAccepted = "auth.user.invitation.accepted"
+	AuthUserImportCompleted    = "auth.user.import.completed"
+
+	AuthLoginSucceeded = "auth.login.succeeded"
+	AuthLoginFailed    = "auth.login.failed"
+
+	AuthRoleCreated           = "auth.role.created"
+	AuthRoleUpdated           = "auth.role.updated"
+	AuthRoleAssigned          = "auth.role.assigned"
+	AuthRoleRevoked           = "auth.role.revoked"
+	AuthRolePermissionGranted = "auth.role.permission.granted"
+
+	AuthGroupCreated       = "auth.group.created"
+	AuthGroupUpdated       = "auth.group.updated"
+	AuthGroupDeleted       = "auth.group.deleted"
+	AuthGroupMemberAdded   = "auth.group.member.added"
+	AuthGroupMemberRemoved = "auth.group.member.removed"
+	AuthGroupRoleAssigned  = "auth.group.role.assigned"
+	AuthGroupRoleRevoked   = "auth.group.role.revoked"
+
+	AuthElevationRequested = "auth.elevation.requested"
+	AuthElevationApproved  = "auth.elevation.approved"
+	AuthElevationDenied    = "auth.elevation.denied"
+
+	AuthClientCreated = "auth.client.created"
+	AuthClientRevoked = "auth.client.revoked"
+
+	AuthPasskeyRegistered = "auth.passkey.registered"
+	AuthPasskeyDeleted    = "auth.passkey.deleted"
+
+	AuthMenuOverrideUpdated = "auth.menu.override.updated"
+	AuthMenuOverrideReset   = "auth.menu.override.reset"
 )
+
+// Request-reply subjects answered by the auth module. See pkg/authz for a typed client.
+const (
+	AuthPermissionsCheck = "auth.permissions.check"
+	AuthPermissionsList  = "auth.permissions.list"
+	AuthRolesList        = "auth.roles.list"
+)
+
+// Reasons reported in AuthRoleRevokedData.
+const (
+	RoleRevokedManually = "revoked"
+	RoleRevokedExpired  = "expired"
+)
+
+// Login methods reported in AuthLoginSucceededData and AuthLoginFailedData.
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodPasskey   = "passkey"
+	LoginMethodTOTP      = "totp"
+)
+
+// AuthPermissionCheckRequest asks whether a user holds a permission. IP is the address the user
+// is acting from; roles restricted to an IP allowlist are ignored when it is empty.
+type AuthPermissionCheckRequest struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Permission string    `json:"permission"`
+	IP         string    `json:"ip,omitempty"`
+}
+
+type AuthPermissionCheckReply struct {
+	Allowed bool   `json:"allowed"`
+	Error   string `json:"error,omitempty"`
+}
+
+type AuthPermissionListRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	IP     string    `json:"ip,omitempty"`
+}
+
+type AuthPermissionListReply struct {
+	Permissions []string `json:"permissions"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// AuthRoleListRequest asks for the names of a user's effective roles, with the same IP rules as
+// AuthPermissionListRequest.
+type AuthRoleListRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	IP     string    `json:"ip,omitempty"`
+}
+
+type AuthRoleListReply struct {
+	Roles []string `json:"roles"`
+	Error string   `json:"error,omitempty"`
+}
+
+type AuthUserRegisteredData struct {
+	Trail
+	UserID   uuid.UUID `json:"user_id"`
+	Email    string    `json:"email"`
+	FullName string    `json:"full_name"`
+}
+
+type AuthUserUpdatedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+	// Fields lists the JSON names of the attributes that changed.
+	Fields []string `json:"fields"`
+}
+
+type AuthUserDeletedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+}
+
+type AuthUserPasswordChangedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type AuthUserPasswordResetData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type AuthUserReauthenticatedData struct {
+	Trail
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Method    string    `json:"method"`
+}
+
+type AuthUserInvitedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	// ImportJobID is set when the invitation was sent by a bulk import.
+	ImportJobID *uuid.UUID `json:"import_job_id,omitempty"`
+}
+
+type AuthUserInvitationAcceptedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type AuthUserImportCompletedData struct {
+	Trail
+	JobID     uuid.UUID `json:"job_id"`
+	Total     int       `json:"total"`
+	Succeeded int       `json:"succeeded"`
+	Failed    int       `json:"failed"`
+}
+
+type AuthLoginSucceededData struct {
+	Trail
+	UserID    uuid.UUID `json:"user_id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Method    string    `json:"method"`
+}
+
+type AuthLoginFailedData struct {
+	Trail
+	// UserID is only set when the attempt could be tied to an existing account.
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Email  string     `json:"email,omitempty"`
+	Method string     `json:"method"`
+	Reason string     `json:"reason"`
+}
+
+type AuthRoleCreatedData struct {
+	Trail
+	RoleID int    `json:"role_id"`
+	Name   string `json:"name"`
+}
+
+type AuthRoleUpdatedData struct {
+	Trail
+	RoleID int `json:"role_id"`
+	// Fields lists the JSON names of the attributes that changed.
+	Fields []string `json:"fields"`
+}
+
+type AuthRoleAssignedData struct {
+	Trail
+	UserID     uuid.UUID  `json:"user_id"`
+	RoleID     int        `json:"role_id"`
+	ValidFrom  time.Time  `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+type AuthRoleRevokedData struct {
+	Trail
+	UserID uuid.UUID `json:"user_id"`
+	RoleID int       `json:"role_id"`
+	Reason string    `json:"reason"`
+}
+
+type AuthRolePermissionGrantedData struct {
+	Trail
+	RoleID       int    `json:"role_id"`
+	PermissionID string `json:"permission_id"`
+}
+
+type AuthGroupCreatedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	Name    string    `json:"name"`
+}
+
+type AuthGroupUpdatedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	Name    string    `json:"name"`
+}
+
+type AuthGroupDeletedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	Name    string    `json:"name"`
+}
+
+type AuthGroupMemberAddedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type AuthGroupMemberRemovedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	UserID  uuid.UUID `json:"user_id"`
+}
+
+type AuthGroupRoleAssignedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	RoleID  int       `json:"role_id"`
+}
+
+type AuthGroupRoleRevokedData struct {
+	Trail
+	GroupID uuid.UUID `json:"group_id"`
+	RoleID  int       `json:"role_id"`
+}
+
+type AuthElevationRequestedData struct {
+	Trail
+	RequestID       uuid.UUID `json:"request_id"`
+	UserID          uuid.UUID `json:"user_id"`
+	RoleID          int       `json:"role_id"`
+	Reason          string    `json:"reason"`
+	DurationMinutes int       `json:"duration_minutes"`
+}
+
+type AuthElevationDecidedData struct {
+	Trail
+	RequestID  uuid.UUID  `json:"request_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	RoleID     int        `json:"role_id"`
+	DecidedBy  uuid.UUID  `json:"decided_by"`
+	Note       string     `json:"note,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+}
+
+type AuthClientCreatedData struct {
+	Trail
+	ClientID uuid.UUID `json:"client_id"`
+	Name     string    `json:"name"`
+	Scopes   []string  `json:"scopes"`
+}
+
+type AuthClientRevokedData struct {
+	Trail
+	ClientID uuid.UUID `json:"client_id"`
+}
+
+type AuthPasskeyRegisteredData struct {
+	Trail
+	UserID    uuid.UUID `json:"user_id"`
+	PasskeyID uuid.UUID `json:"passkey_id"`
+	Name      string    `json:"name"`
+}
+
+type AuthPasskeyDeletedData struct {
+	Trail
+	UserID    uuid.UUID `json:"user_id"`
+	PasskeyID uuid.UUID `json:"passkey_id"`
+}
+
+type AuthMenuOverrideUpdatedData struct {
+	Trail
+	MenuID string `json:"menu_id"`
+}
+
+// AuthMenuOverrideResetData lists the menu items restored to their declared values.
+type AuthMenuOverrideResetData struct {
+	Trail
+	MenuIDs []string `json:"menu_ids"`
+}
@@ -10,29 +10,40 @@ const (
 	CmsPageLayoutUpdated = "cms.page.layout.updated"
 )
 
+// Request-reply subjects answered by the cms module.
+const (
+	// CmsMenuBadgeDrafts answers a events.MenuBadgeRequest with the number of draft pages.
+	CmsMenuBadgeDrafts = "cms.menu.badge.drafts"
+)
+
 type CmsPagePublishedData struct {
+	Trail
 	PageID uuid.UUID `json:"page_id"`
 	Title  string    `json:"title"`
 	Slug   string    `json:"slug"`
 }
 type CmsPageDraftedData struct {
+	Trail
 	PageID uuid.UUID `json:"page_id"`
 	Title  string    `json:"title"`
 	Slug   string    `json:"slug"`
 }
 
 type CmsPageDeletedData struct {
+	Trail
 	PageID uuid.UUID `json:"page_id"`
 	Title  string    `json:"title"`
 }
 
 type CmsPageArchivedData struct {
+	Trail
 	PageID uuid.UUID `json:"page_id"`
 	Title  string    `json:"title"`
 	Slug   string    `json:"slug"`
 }
 
 type CmsPageLayoutUpdatedData struct {
+	Trail
 	PageID uuid.UUID `json:"page_id"`
 	Title  string    `json:"title"`
 	Slug   string    `json:"slug"`
@@ -0,0 +1,31 @@
+package events
+
+import "github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+
+// Flag changes are broadcast to every instance so they can update their replica (see pkg/features).
+const (
+	FlagsFlagUpdated = "flags.flag.updated"
+	FlagsFlagDeleted = "flags.flag.deleted"
+)
+
+// Request-reply subjects answered by the flags module.
+const (
+	// FlagsList answers with every flag, for instances building their replica.
+	FlagsList = "flags.list"
+)
+
+// FlagsFlagUpdatedData carries the flag as saved, whether it was created or changed.
+type FlagsFlagUpdatedData struct {
+	Trail
+	Flag flags.Flag `json:"flag"`
+}
+
+type FlagsFlagDeletedData struct {
+	Trail
+	Key string `json:"key"`
+}
+
+type FlagsListReply struct {
+	Flags []flags.Flag `json:"flags"`
+	Error string       `json:"error,omitempty"`
+}
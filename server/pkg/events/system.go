@@ -1,6 +1,7 @@
 package events
 
 import (
+	"github.com/google/uuid"
 	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/menu"
 )
 
@@ -19,3 +20,26 @@ type SystemMenusRegisteredData struct {
 	Version int                   `json:"version"`
 	Menu    []menu.MenuDefinition `json:"menu"`
 }
+
+// SystemMenusRegisteredReply answers a menu registration sent as a request. Applied is false when
+// the registration was rejected, with the reason in Error and any blocking Problems listed.
+type SystemMenusRegisteredReply struct {
+	Domain   string         `json:"domain"`
+	Version  int            `json:"version"`
+	Applied  bool           `json:"applied"`
+	Problems []menu.Problem `json:"problems,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// MenuBadgeRequest is sent by the auth module to a menu item's BadgeSubject while building a
+// user's menu. Answer quickly: the menu is returned without the badge when the reply is late.
+type MenuBadgeRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	MenuID string    `json:"menu_id"`
+}
+
+// MenuBadgeReply carries the badge count. A zero count hides the badge.
+type MenuBadgeReply struct {
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+}
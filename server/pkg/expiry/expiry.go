@@ -0,0 +1,78 @@
+// Package expiry provides a map whose entries each expire at their own time.
+package expiry
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Map holds values until their expiry. Expired entries are evicted in expiry order as new ones are
+// set, so no call scans the whole map. The zero Map is empty and ready to use. A Map is not safe
+// for concurrent use; callers guard it with their own lock.
+type Map[K comparable, V any] struct {
+	entries map[K]entry[V]
+	expiry  expiryHeap[K]
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Get returns the value of key if it is set and has not expired at now.
+func (m *Map[K, V]) Get(key K, now time.Time) (V, bool) {
+	e, ok := m.entries[key]
+	if !ok || now.After(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key until expiresAt, replacing any previous value, and evicts the entries
+// expired at now.
+func (m *Map[K, V]) Set(key K, value V, expiresAt, now time.Time) {
+	if m.entries == nil {
+		m.entries = make(map[K]entry[V])
+	}
+	for len(m.expiry) > 0 && now.After(m.expiry[0].expiresAt) {
+		head := heap.Pop(&m.expiry).(heapEntry[K])
+		// A key set again since has a later expiry and another heap entry.
+		if e, ok := m.entries[head.key]; ok && e.expiresAt.Equal(head.expiresAt) {
+			delete(m.entries, head.key)
+		}
+	}
+	m.entries[key] = entry[V]{value: value, expiresAt: expiresAt}
+	heap.Push(&m.expiry, heapEntry[K]{key: key, expiresAt: expiresAt})
+}
+
+// Clear removes every entry.
+func (m *Map[K, V]) Clear() {
+	clear(m.entries)
+	m.expiry = nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted.
+func (m *Map[K, V]) Len() int {
+	return len(m.entries)
+}
+
+type heapEntry[K comparable] struct {
+	key       K
+	expiresAt time.Time
+}
+
+// expiryHeap is a min-heap of keys ordered by expiry.
+type expiryHeap[K comparable] []heapEntry[K]
+
+func (h expiryHeap[K]) Len() int           { return len(h) }
+func (h expiryHeap[K]) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap[K]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap[K]) Push(x any)        { *h = append(*h, x.(heapEntry[K])) }
+
+func (h *expiryHeap[K]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,58 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMapExpiresEntries(t *testing.T) {
+	var m Map[string, int]
+	now := time.Now()
+
+	if _, ok := m.Get("a", now); ok {
+		t.Fatal("expected an empty map to hold nothing")
+	}
+	m.Set("a", 1, now.Add(time.Minute), now)
+	if v, ok := m.Get("a", now.Add(time.Minute)); !ok || v != 1 {
+		t.Fatalf("expected the value to live until its expiry, got %d, %v", v, ok)
+	}
+	if _, ok := m.Get("a", now.Add(2*time.Minute)); ok {
+		t.Fatal("expected the value to expire")
+	}
+}
+
+func TestMapEvictsInExpiryOrder(t *testing.T) {
+	var m Map[string, int]
+	now := time.Now()
+
+	m.Set("late", 1, now.Add(10*time.Minute), now)
+	m.Set("early", 2, now.Add(time.Minute), now)
+	m.Set("middle", 3, now.Add(5*time.Minute), now)
+
+	m.Set("next", 4, now.Add(20*time.Minute), now.Add(6*time.Minute))
+	if got := m.Len(); got != 2 {
+		t.Fatalf("expected the two expired entries to be evicted, got %d", got)
+	}
+	if _, ok := m.Get("late", now.Add(6*time.Minute)); !ok {
+		t.Fatal("expected the unexpired entry to be kept")
+	}
+}
+
+func TestMapKeepsEntriesSetAgain(t *testing.T) {
+	var m Map[string, int]
+	now := time.Now()
+
+	m.Set("a", 1, now.Add(time.Minute), now)
+	m.Set("a", 2, now.Add(10*time.Minute), now)
+
+	// The first expiry of "a" is evicted from the heap but must not remove the newer value.
+	m.Set("b", 3, now.Add(20*time.Minute), now.Add(5*time.Minute))
+	if v, ok := m.Get("a", now.Add(5*time.Minute)); !ok || v != 2 {
+		t.Fatalf("expected the newer value to be kept, got %d, %v", v, ok)
+	}
+
+	m.Clear()
+	if m.Len() != 0 {
+		t.Fatal("expected Clear to remove every entry")
+	}
+}
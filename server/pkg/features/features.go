@@ -0,0 +1,186 @@
+// Package features keeps a replica of the feature flags administered by the flags module, so any
+// module can evaluate flags without a round trip. Every instance loads the full set over NATS and
+// applies the changes the flags module broadcasts.
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/authz"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+const (
+	// ResyncInterval is how often the whole set is reloaded, bounding the effect of a missed
+	// broadcast, e.g. during a reconnect.
+	ResyncInterval = 5 * time.Minute
+
+	syncTimeout    = 2 * time.Second
+	maxSyncBackoff = 30 * time.Second
+)
+
+// RoleResolver returns the names of a user's effective roles when acting from ip.
+type RoleResolver func(ctx context.Context, userID uuid.UUID, ip netip.Addr) ([]string, error)
+
+// Client is a replica of the flag set. Until the first load completes every flag is off.
+type Client struct {
+	nc    *nats.Conn
+	roles RoleResolver
+
+	mu    sync.RWMutex
+	flags map[string]flags.Flag
+}
+
+// NewClient starts replicating the flags over nc. Role targeting in Require resolves roles
+// through the auth module.
+func NewClient(nc *nats.Conn) *Client {
+	c := &Client{nc: nc, roles: authz.NewClient(nc).Roles, flags: make(map[string]flags.Flag)}
+
+	if _, err := nc.Subscribe(events.FlagsFlagUpdated, c.handleUpdated); err != nil {
+		slog.Error("feature flags: failed to subscribe", "subject", events.FlagsFlagUpdated, "error", err)
+	}
+	if _, err := nc.Subscribe(events.FlagsFlagDeleted, c.handleDeleted); err != nil {
+		slog.Error("feature flags: failed to subscribe", "subject", events.FlagsFlagDeleted, "error", err)
+	}
+	go c.run()
+	return c
+}
+
+// Flag returns the flag stored under key.
+func (c *Client) Flag(key string) (flags.Flag, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	f, ok := c.flags[key]
+	return f, ok
+}
+
+// Enabled reports whether the flag key is on for s. Unknown flags are off.
+func (c *Client) Enabled(key string, s flags.Subject) bool {
+	f, ok := c.Flag(key)
+	return ok && f.EnabledFor(s)
+}
+
+// Require answers 404 Not Found, as if the route did not exist, unless the flag key is on for the
+// requesting user. Mount it behind the authentication middleware; anonymous requests only pass
+// globally enabled flags.
+func (c *Client) Require(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.enabledForRequest(r, key) {
+				status, code := httputil.MapError(httputil.ErrNotFound)
+				jsonutil.RenderError(w, status, code, httputil.ErrNotFound.Error())
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *Client) enabledForRequest(r *http.Request, key string) bool {
+	f, ok := c.Flag(key)
+	if !ok {
+		return false
+	}
+
+	actor := events.ActorFromContext(r.Context())
+	userID, _ := uuid.Parse(actor.UserID)
+	subject := flags.Subject{UserID: userID}
+	if f.EnabledFor(subject) {
+		return true
+	}
+	// Roles cost a request to the auth module, so they are only looked up when they matter.
+	if len(f.Roles) == 0 || userID == uuid.Nil || c.roles == nil {
+		return false
+	}
+	ip, _ := netip.ParseAddr(actor.IP)
+	roles, err := c.roles(r.Context(), userID, ip)
+	if err != nil {
+		slog.Warn("feature flags: failed to resolve roles", "flag", key, "user_id", userID, "error", err)
+		return false
+	}
+	subject.Roles = roles
+	return f.EnabledFor(subject)
+}
+
+func (c *Client) handleUpdated(m *nats.Msg) {
+	var payload events.FlagsFlagUpdatedData
+	if err := json.Unmarshal(m.Data, &payload); err != nil {
+		slog.Error("feature flags: invalid update", "error", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A late broadcast must not roll back a newer state loaded in the meantime.
+	if current, ok := c.flags[payload.Flag.Key]; ok && current.UpdatedAt.After(payload.Flag.UpdatedAt) {
+		return
+	}
+	c.flags[payload.Flag.Key] = payload.Flag
+}
+
+func (c *Client) handleDeleted(m *nats.Msg) {
+	var payload events.FlagsFlagDeletedData
+	if err := json.Unmarshal(m.Data, &payload); err != nil {
+		slog.Error("feature flags: invalid deletion", "error", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.flags, payload.Key)
+}
+
+// run loads the flag set, retrying with backoff until the flags module answers, then reloads it
+// every ResyncInterval.
+func (c *Client) run() {
+	backoff := time.Second
+	for {
+		if err := c.sync(); err != nil {
+			slog.Warn("feature flags: failed to load flags", "error", err, "retry_in", backoff)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, maxSyncBackoff)
+			continue
+		}
+		backoff = time.Second
+		time.Sleep(ResyncInterval)
+	}
+}
+
+func (c *Client) sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	msg, err := c.nc.RequestWithContext(ctx, events.FlagsList, nil)
+	if err != nil {
+		return err
+	}
+	var reply events.FlagsListReply
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return err
+	}
+	if reply.Error != "" {
+		return fmt.Errorf("feature flags: %s", reply.Error)
+	}
+	c.replace(reply.Flags)
+	return nil
+}
+
+func (c *Client) replace(list []flags.Flag) {
+	set := make(map[string]flags.Flag, len(list))
+	for _, f := range list {
+		set[f.Key] = f
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flags = set
+}
@@ -0,0 +1,63 @@
+package features
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rubenalves-dev/template-fullstack/server/internal/platform/flags"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/events"
+)
+
+func TestRequire(t *testing.T) {
+	editor := uuid.New()
+	var lookups int
+	c := &Client{
+		roles: func(_ context.Context, userID uuid.UUID, _ netip.Addr) ([]string, error) {
+			lookups++
+			if userID == editor {
+				return []string{"editor"}, nil
+			}
+			return nil, nil
+		},
+		flags: map[string]flags.Flag{
+			"public":  {Key: "public", Enabled: true},
+			"editors": {Key: "editors", Roles: []string{"editor"}},
+			"off":     {Key: "off"},
+		},
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	cases := []struct {
+		name string
+		key  string
+		user uuid.UUID
+		want int
+	}{
+		{"globally enabled", "public", uuid.Nil, http.StatusOK},
+		{"role holder", "editors", editor, http.StatusOK},
+		{"other user", "editors", uuid.New(), http.StatusNotFound},
+		{"anonymous", "editors", uuid.Nil, http.StatusNotFound},
+		{"disabled", "off", editor, http.StatusNotFound},
+		{"unknown", "missing", editor, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.user != uuid.Nil {
+				req = req.WithContext(events.WithActor(req.Context(), events.Actor{UserID: tc.user.String()}))
+			}
+			rec := httptest.NewRecorder()
+			c.Require(tc.key)(ok).ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, rec.Code)
+			}
+		})
+	}
+	if lookups != 2 {
+		t.Fatalf("expected roles to be looked up only for role-targeted flags, got %d lookups", lookups)
+	}
+}
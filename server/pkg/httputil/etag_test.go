@@ -0,0 +1,50 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotModified(t *testing.T) {
+	etag, err := ETag(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("ETag: %v", err)
+	}
+	other, _ := ETag(map[string]int{"a": 2})
+	if etag == other {
+		t.Fatal("expected different bodies to get different tags")
+	}
+
+	cases := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"match", etag, true},
+		{"weak match", "W/" + etag, true},
+		{"list", other + ", " + etag, true},
+		{"wildcard", "*", true},
+		{"stale", other, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			if got := NotModified(rec, req, etag); got != tc.want {
+				t.Fatalf("NotModified = %v, want %v", got, tc.want)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Fatalf("expected ETag %s, got %q", etag, rec.Header().Get("ETag"))
+			}
+			if tc.want && rec.Code != http.StatusNotModified {
+				t.Fatalf("expected 304, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ var (
 	ErrForbidden    = errors.New("forbidden")
 	ErrBadRequest   = errors.New("bad request")
 	ErrConflict     = errors.New("conflict")
+
+	ErrTooManyRequests = errors.New("too many requests")
 )
 
 func MapError(err error) (int, string) {
@@ -25,6 +27,8 @@ func MapError(err error) (int, string) {
 		return http.StatusBadRequest, "BAD_REQUEST"
 	case errors.Is(err, ErrConflict):
 		return http.StatusConflict, "CONFLICT"
+	case errors.Is(err, ErrTooManyRequests):
+		return http.StatusTooManyRequests, "TOO_MANY_REQUESTS"
 	default:
 		return http.StatusInternalServerError, "INTERNAL_SERVER_ERROR"
 	}
@@ -0,0 +1,71 @@
+package httputil
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/expiry"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+)
+
+// RateLimiter is a fixed-window counter keyed by an arbitrary string (usually the client IP).
+// Windows are evicted in expiry order as new ones start.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows expiry.Map[string, *rateWindow]
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window}
+}
+
+// Allow records a hit for key and reports whether it is still within the limit,
+// together with how long the caller should wait when it is not.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows.Get(key, now)
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows.Set(key, w, now.Add(l.window), now)
+	}
+
+	w.count++
+	if w.count > l.limit {
+		return false, l.window - now.Sub(w.start)
+	}
+	return true, 0
+}
+
+// RateLimit allows at most limit requests per window from each client IP.
+// It relies on RealIP having normalised r.RemoteAddr.
+func RateLimit(limit int, window time.Duration) func(next http.Handler) http.Handler {
+	return RateLimitBy(limit, window, func(r *http.Request) string { return r.RemoteAddr })
+}
+
+// RateLimitBy allows at most limit requests per window for each key returned by key, e.g. the
+// authenticated user, so callers cannot escape the limit by changing address.
+func RateLimitBy(limit int, window time.Duration, key func(r *http.Request) string) func(next http.Handler) http.Handler {
+	limiter := NewRateLimiter(limit, window)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ok, retryAfter := limiter.Allow(key(r)); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				jsonutil.RenderError(w, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Too many requests")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
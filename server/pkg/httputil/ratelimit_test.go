@@ -0,0 +1,77 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterAllowsUpToLimitPerKey(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow("10.0.0.1"); !ok {
+			t.Fatalf("hit %d should be allowed", i+1)
+		}
+	}
+	if ok, retryAfter := limiter.Allow("10.0.0.1"); ok || retryAfter <= 0 {
+		t.Fatalf("third hit should be rejected with a retry delay, got ok=%v retryAfter=%v", ok, retryAfter)
+	}
+	if ok, _ := limiter.Allow("10.0.0.2"); !ok {
+		t.Fatal("other keys should have their own window")
+	}
+}
+
+func TestRateLimiterResetsAfterWindow(t *testing.T) {
+	limiter := NewRateLimiter(1, 10*time.Millisecond)
+
+	limiter.Allow("key")
+	if ok, _ := limiter.Allow("key"); ok {
+		t.Fatal("second hit within the window should be rejected")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if ok, _ := limiter.Allow("key"); !ok {
+		t.Fatal("hit after the window should be allowed")
+	}
+}
+
+func TestRateLimiterEvictsExpiredWindows(t *testing.T) {
+	limiter := NewRateLimiter(1, 10*time.Millisecond)
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	time.Sleep(15 * time.Millisecond)
+	limiter.Allow("c")
+
+	if got := limiter.windows.Len(); got != 1 {
+		t.Fatalf("expected the expired windows to be evicted, got %d", got)
+	}
+}
+
+func TestRateLimitByKeysOnTheGivenValue(t *testing.T) {
+	limited := RateLimitBy(1, time.Minute, func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func(user, remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set("X-User", user)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		limited.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call("ana", "10.0.0.1"); code != http.StatusNoContent {
+		t.Fatalf("first hit should be allowed, got %d", code)
+	}
+	if code := call("ana", "10.0.0.2"); code != http.StatusTooManyRequests {
+		t.Fatalf("a new address should not reset the key's window, got %d", code)
+	}
+	if code := call("ben", "10.0.0.2"); code != http.StatusNoContent {
+		t.Fatalf("other keys should have their own window, got %d", code)
+	}
+}
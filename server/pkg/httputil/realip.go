@@ -0,0 +1,69 @@
+package httputil
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP normalises r.RemoteAddr to the client's IP address without a port. When the direct peer
+// is inside one of trustedProxies, X-Forwarded-For is walked from the right, skipping the hops of
+// trusted proxies, and the first address outside them is taken as the client's. Entries to its
+// left were supplied by the client or untrusted hops and are ignored, so proxies may append to the
+// header rather than overwrite it. Other forwarding headers are not honoured.
+func RealIP(trustedProxies []netip.Prefix) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := ParseRemoteIP(r.RemoteAddr); ok {
+				if containsIP(trustedProxies, peer) {
+					peer = forwardedClientIP(r.Header.Values("X-Forwarded-For"), trustedProxies, peer)
+				}
+				r.RemoteAddr = peer.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP returns the rightmost X-Forwarded-For address outside trustedProxies. When
+// every hop is trusted the leftmost one is returned, and peer when the header is missing. An
+// unparsable entry ends the walk at the last trusted hop, since nothing beyond it can be trusted.
+func forwardedClientIP(headers []string, trustedProxies []netip.Prefix, peer netip.Addr) netip.Addr {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return client
+		}
+		client = ip.Unmap()
+		if !containsIP(trustedProxies, client) {
+			return client
+		}
+	}
+	return client
+}
+
+// ParseRemoteIP parses an address in r.RemoteAddr form, with or without a port.
+func ParseRemoteIP(remoteAddr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(remoteAddr); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	if ip, err := netip.ParseAddr(remoteAddr); err == nil {
+		return ip.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestRealIPOnlyTrustsConfiguredProxies(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	var got string
+	h := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.RemoteAddr
+	}))
+
+	cases := []struct {
+		peer, forwarded, want string
+	}{
+		{peer: "10.1.2.3:4000", forwarded: "203.0.113.7", want: "203.0.113.7"},
+		{peer: "198.51.100.9:4000", forwarded: "203.0.113.7", want: "198.51.100.9"},
+		{peer: "10.1.2.3:4000", want: "10.1.2.3"},
+		{peer: "[::ffff:198.51.100.9]:4000", want: "198.51.100.9"},
+		// The client sent its own header; the proxy appended the address it saw.
+		{peer: "10.1.2.3:4000", forwarded: "1.2.3.4, 203.0.113.7", want: "203.0.113.7"},
+		// Hops of inner trusted proxies are skipped.
+		{peer: "10.1.2.3:4000", forwarded: "1.2.3.4, 203.0.113.7, 10.9.9.9", want: "203.0.113.7"},
+		{peer: "10.1.2.3:4000", forwarded: "10.8.8.8, 10.9.9.9", want: "10.8.8.8"},
+		{peer: "10.1.2.3:4000", forwarded: "203.0.113.7, garbage, 10.9.9.9", want: "10.9.9.9"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.peer
+		if tc.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tc.want {
+			t.Errorf("peer %s forwarded %q: expected %s, got %s", tc.peer, tc.forwarded, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Package pow protects public endpoints from bots with self-hosted proof-of-work challenges.
+//
+// A client fetches a challenge, finds a nonce such that SHA-256("<challenge>:<nonce>") starts
+// with the requested number of zero bits, and sends both with the protected request in the
+// X-PoW-Challenge and X-PoW-Nonce headers. Challenges are HMAC-signed, so the server keeps no
+// state for them besides a list of spent ones, and are bound to the client IP.
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/expiry"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/httputil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/jsonutil"
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/replay"
+)
+
+const (
+	ChallengeHeader = "X-PoW-Challenge"
+	NonceHeader     = "X-PoW-Nonce"
+
+	// Algorithm is reported to clients so the scheme can evolve.
+	Algorithm = "sha256-leading-zero-bits"
+
+	// maxNonceLength bounds the work done hashing a submitted solution.
+	maxNonceLength = 64
+)
+
+var (
+	ErrInvalidChallenge = errors.New("invalid or expired challenge")
+	ErrInvalidSolution  = errors.New("challenge solution is not valid")
+	ErrChallengeUsed    = errors.New("challenge was already used")
+)
+
+// Options tune the guard. Difficulty is in leading zero bits: every extra bit doubles the
+// expected work.
+type Options struct {
+	Difficulty    int
+	MaxDifficulty int
+	TTL           time.Duration
+	// LoadThreshold is the number of protected requests per minute, across all clients, above
+	// which difficulty rises one bit per multiple. Zero disables load adjustment.
+	LoadThreshold int
+	// FailureThreshold is the number of failed requests from one IP within FailureWindow above
+	// which difficulty rises one bit per multiple.
+	FailureThreshold int
+	FailureWindow    time.Duration
+}
+
+// Challenge is handed to the client to solve.
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	Algorithm  string    `json:"algorithm"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type claims struct {
+	ID         string `json:"id"`
+	IP         string `json:"ip"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// Guard issues and verifies challenges. Its counters and spent-challenge list live in memory, so
+// every instance behind a load balancer adapts on its own, and replay protection is per instance:
+// a solved challenge can be spent once on each instance until it expires.
+type Guard struct {
+	secret []byte
+	opts   Options
+	spent  *replay.Cache
+
+	mu       sync.Mutex
+	load     window
+	failures expiry.Map[netip.Addr, *window]
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+func New(secret []byte, opts Options) *Guard {
+	if opts.MaxDifficulty < opts.Difficulty {
+		opts.MaxDifficulty = opts.Difficulty
+	}
+	return &Guard{
+		secret: secret,
+		opts:   opts,
+		spent:  replay.NewCache(),
+	}
+}
+
+// Enabled reports whether challenges are required at all. A base difficulty of zero disables them.
+func (g *Guard) Enabled() bool {
+	return g.opts.Difficulty > 0
+}
+
+// Difficulty is the number of leading zero bits currently asked of ip.
+func (g *Guard) Difficulty(ip netip.Addr) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	d := g.opts.Difficulty
+	if g.opts.LoadThreshold > 0 && now.Sub(g.load.start) < time.Minute {
+		d += g.load.count / g.opts.LoadThreshold
+	}
+	if w, ok := g.failures.Get(ip, now); ok && g.opts.FailureThreshold > 0 && now.Sub(w.start) < g.opts.FailureWindow {
+		d += w.count / g.opts.FailureThreshold
+	}
+	return min(d, g.opts.MaxDifficulty)
+}
+
+// Issue creates a challenge for ip at its current difficulty.
+func (g *Guard) Issue(ip netip.Addr) (Challenge, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Challenge{}, err
+	}
+
+	expiresAt := time.Now().Add(g.opts.TTL).Truncate(time.Second)
+	c := claims{
+		ID:         base64.RawURLEncoding.EncodeToString(id),
+		IP:         ip.String(),
+		Difficulty: g.Difficulty(ip),
+		ExpiresAt:  expiresAt.Unix(),
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return Challenge{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return Challenge{
+		Challenge:  encoded + "." + g.sign(encoded),
+		Difficulty: c.Difficulty,
+		Algorithm:  Algorithm,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// Verify checks a solved challenge presented by ip and marks it as spent. The difficulty signed
+// into the challenge applies, so a rise in difficulty does not void challenges already handed out.
+func (g *Guard) Verify(challenge, nonce string, ip netip.Addr) error {
+	encoded, sig, ok := strings.Cut(challenge, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(g.sign(encoded))) {
+		return ErrInvalidChallenge
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidChallenge
+	}
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return ErrInvalidChallenge
+	}
+	now := time.Now()
+	if now.Unix() > c.ExpiresAt || c.IP != ip.String() {
+		return ErrInvalidChallenge
+	}
+
+	if nonce == "" || len(nonce) > maxNonceLength || leadingZeroBits(challenge, nonce) < c.Difficulty {
+		return ErrInvalidSolution
+	}
+
+	if !g.spent.Add(c.ID, time.Unix(c.ExpiresAt, 0), now) {
+		return ErrChallengeUsed
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt from ip towards a higher difficulty.
+func (g *Guard) RecordFailure(ip netip.Addr) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	w, ok := g.failures.Get(ip, now)
+	if !ok || now.Sub(w.start) >= g.opts.FailureWindow {
+		// Windows are evicted in expiry order as new ones start.
+		w = &window{start: now}
+		g.failures.Set(ip, w, now.Add(g.opts.FailureWindow), now)
+	}
+	w.count++
+}
+
+func (g *Guard) recordRequest() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(g.load.start) >= time.Minute {
+		g.load = window{start: now}
+	}
+	g.load.count++
+}
+
+// ChallengeHandler answers with a new challenge for the caller. It relies on RealIP having
+// normalised r.RemoteAddr.
+func (g *Guard) ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	ip, ok := httputil.ParseRemoteIP(r.RemoteAddr)
+	if !ok {
+		jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Unknown client address")
+		return
+	}
+	c, err := g.Issue(ip)
+	if err != nil {
+		jsonutil.RenderError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue challenge")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	jsonutil.RenderJSON(w, http.StatusOK, c)
+}
+
+// Require rejects requests without a valid solution before the handler does any work, answering
+// 428 POW_REQUIRED when the headers are missing and 403 POW_INVALID when the solution is wrong.
+// Invalid solutions and 4xx responses from the handler, such as failed logins, count as failures
+// for the client IP.
+func (g *Guard) Require(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		g.recordRequest()
+
+		ip, ok := httputil.ParseRemoteIP(r.RemoteAddr)
+		if !ok {
+			jsonutil.RenderError(w, http.StatusBadRequest, "INVALID_REQUEST", "Unknown client address")
+			return
+		}
+
+		challenge, nonce := r.Header.Get(ChallengeHeader), r.Header.Get(NonceHeader)
+		if challenge == "" || nonce == "" {
+			jsonutil.RenderError(w, http.StatusPreconditionRequired, "POW_REQUIRED",
+				fmt.Sprintf("Solve a challenge and send it in the %s and %s headers", ChallengeHeader, NonceHeader))
+			return
+		}
+		if err := g.Verify(challenge, nonce, ip); err != nil {
+			g.RecordFailure(ip)
+			jsonutil.RenderError(w, http.StatusForbidden, "POW_INVALID", err.Error())
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status >= 400 && rec.status < 500 {
+			g.RecordFailure(ip)
+		}
+	})
+}
+
+func (g *Guard) sign(encoded string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Solve finds a nonce for challenge by brute force. It is meant for tests and Go clients.
+func Solve(challenge string, difficulty int) string {
+	for n := 0; ; n++ {
+		nonce := fmt.Sprint(n)
+		if leadingZeroBits(challenge, nonce) >= difficulty {
+			return nonce
+		}
+	}
+}
+
+func leadingZeroBits(challenge, nonce string) int {
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	total := 0
+	for _, b := range sum {
+		if b != 0 {
+			return total + bits.LeadingZeros8(b)
+		}
+		total += 8
+	}
+	return total
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,176 @@
+package pow
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+var clientIP = netip.MustParseAddr("203.0.113.7")
+
+func newGuard() *Guard {
+	return New([]byte("secret"), Options{
+		Difficulty:       8,
+		MaxDifficulty:    12,
+		TTL:              time.Minute,
+		LoadThreshold:    3,
+		FailureThreshold: 2,
+		FailureWindow:    time.Minute,
+	})
+}
+
+func TestVerifyAcceptsSolutionOnce(t *testing.T) {
+	g := newGuard()
+	c, err := g.Issue(clientIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := Solve(c.Challenge, c.Difficulty)
+
+	if err := g.Verify(c.Challenge, nonce, clientIP); err != nil {
+		t.Fatalf("expected valid solution, got %v", err)
+	}
+	if err := g.Verify(c.Challenge, nonce, clientIP); !errors.Is(err, ErrChallengeUsed) {
+		t.Fatalf("expected replay to be rejected, got %v", err)
+	}
+}
+
+func TestVerifyRejectsBadInput(t *testing.T) {
+	g := newGuard()
+	c, _ := g.Issue(clientIP)
+	nonce := Solve(c.Challenge, c.Difficulty)
+
+	wrong := "0"
+	for leadingZeroBits(c.Challenge, wrong) >= c.Difficulty {
+		wrong += "0"
+	}
+	if err := g.Verify(c.Challenge, wrong, clientIP); !errors.Is(err, ErrInvalidSolution) {
+		t.Errorf("wrong nonce: got %v", err)
+	}
+	if err := g.Verify(c.Challenge, nonce, netip.MustParseAddr("198.51.100.1")); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("other IP: got %v", err)
+	}
+
+	encoded, _, _ := strings.Cut(c.Challenge, ".")
+	if err := g.Verify(encoded+".forged", nonce, clientIP); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("forged signature: got %v", err)
+	}
+
+	other := New([]byte("other"), g.opts)
+	if err := other.Verify(c.Challenge, nonce, clientIP); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("other secret: got %v", err)
+	}
+}
+
+func TestVerifyRejectsExpiredChallenge(t *testing.T) {
+	g := newGuard()
+	g.opts.TTL = -time.Second
+	c, _ := g.Issue(clientIP)
+	if err := g.Verify(c.Challenge, Solve(c.Challenge, c.Difficulty), clientIP); !errors.Is(err, ErrInvalidChallenge) {
+		t.Fatalf("expected expired challenge to be rejected, got %v", err)
+	}
+}
+
+func TestDifficultyRisesWithFailures(t *testing.T) {
+	g := newGuard()
+	other := netip.MustParseAddr("198.51.100.1")
+
+	for range 4 {
+		g.RecordFailure(clientIP)
+	}
+	if d := g.Difficulty(clientIP); d != 10 {
+		t.Errorf("expected difficulty 10 after 4 failures, got %d", d)
+	}
+	if d := g.Difficulty(other); d != 8 {
+		t.Errorf("expected other IPs to keep base difficulty, got %d", d)
+	}
+
+	for range 20 {
+		g.RecordFailure(clientIP)
+	}
+	if d := g.Difficulty(clientIP); d != 12 {
+		t.Errorf("expected difficulty capped at 12, got %d", d)
+	}
+}
+
+func TestFailureWindowsExpire(t *testing.T) {
+	g := New([]byte("secret"), Options{Difficulty: 8, MaxDifficulty: 12, FailureThreshold: 1, FailureWindow: 10 * time.Millisecond})
+	other := netip.MustParseAddr("198.51.100.1")
+
+	g.RecordFailure(clientIP)
+	time.Sleep(15 * time.Millisecond)
+	if d := g.Difficulty(clientIP); d != 8 {
+		t.Errorf("expected difficulty to fall back after the window, got %d", d)
+	}
+
+	g.RecordFailure(other)
+	if got := g.failures.Len(); got != 1 {
+		t.Errorf("expected the expired window to be evicted, got %d windows", got)
+	}
+}
+
+func TestDifficultyRisesWithLoad(t *testing.T) {
+	g := newGuard()
+	for range 6 {
+		g.recordRequest()
+	}
+	if d := g.Difficulty(clientIP); d != 10 {
+		t.Fatalf("expected difficulty 10 under load, got %d", d)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	g := newGuard()
+	g.opts.LoadThreshold = 0
+	status := http.StatusOK
+	h := g.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	send := func(challenge, nonce string) int {
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		if challenge != "" {
+			req.Header.Set(ChallengeHeader, challenge)
+			req.Header.Set(NonceHeader, nonce)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send("", ""); code != http.StatusPreconditionRequired {
+		t.Errorf("missing solution: expected 428, got %d", code)
+	}
+
+	c, _ := g.Issue(clientIP)
+	nonce := Solve(c.Challenge, c.Difficulty)
+	if code := send(c.Challenge, nonce); code != http.StatusOK {
+		t.Errorf("valid solution: expected 200, got %d", code)
+	}
+	if code := send(c.Challenge, nonce); code != http.StatusForbidden {
+		t.Errorf("replayed solution: expected 403, got %d", code)
+	}
+
+	// The replay counts as one failure; a rejected login counts as another.
+	status = http.StatusUnauthorized
+	c, _ = g.Issue(clientIP)
+	send(c.Challenge, Solve(c.Challenge, c.Difficulty))
+	if d := g.Difficulty(clientIP); d != 9 {
+		t.Errorf("expected failures to raise difficulty to 9, got %d", d)
+	}
+}
+
+func TestRequireDisabled(t *testing.T) {
+	g := New([]byte("secret"), Options{})
+	h := g.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected pass-through when disabled, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,44 @@
+// Package replay remembers one-time values, such as proof and challenge IDs, until they expire.
+//
+// The cache lives in memory, so replay protection is per instance: behind a load balancer, a value
+// spent on one instance is unknown to the others and can be presented to each of them once within
+// its lifetime.
+package replay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rubenalves-dev/template-fullstack/server/pkg/expiry"
+)
+
+// Cache is a set of keys that each expire at their own time. Expired keys are evicted in expiry
+// order as new ones are added, so no call scans the whole set.
+type Cache struct {
+	mu   sync.Mutex
+	keys expiry.Map[string, struct{}]
+}
+
+// NewCache returns an empty cache.
+func NewCache() *Cache {
+	return &Cache{}
+}
+
+// Add records key until expiresAt and reports false if it is already recorded and not expired.
+func (c *Cache) Add(key string, expiresAt, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.keys.Get(key, now); ok {
+		return false
+	}
+	c.keys.Set(key, struct{}{}, expiresAt, now)
+	return true
+}
+
+// Len returns the number of keys recorded, including expired ones not yet evicted.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keys.Len()
+}
@@ -0,0 +1,39 @@
+package replay
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheRejectsKeysUntilTheyExpire(t *testing.T) {
+	c := NewCache()
+	now := time.Now()
+
+	if !c.Add("a", now.Add(time.Minute), now) {
+		t.Fatal("expected a new key to be accepted")
+	}
+	if c.Add("a", now.Add(time.Minute), now.Add(30*time.Second)) {
+		t.Fatal("expected a recorded key to be rejected")
+	}
+	if !c.Add("a", now.Add(3*time.Minute), now.Add(2*time.Minute)) {
+		t.Fatal("expected an expired key to be accepted again")
+	}
+}
+
+func TestCacheEvictsInExpiryOrder(t *testing.T) {
+	c := NewCache()
+	now := time.Now()
+
+	// Keys are added out of expiry order, as challenges issued at different times are spent.
+	c.Add("late", now.Add(10*time.Minute), now)
+	c.Add("early", now.Add(time.Minute), now)
+	c.Add("middle", now.Add(5*time.Minute), now)
+
+	c.Add("next", now.Add(20*time.Minute), now.Add(6*time.Minute))
+	if got := c.Len(); got != 2 {
+		t.Fatalf("expected the two expired keys to be evicted, got %d keys", got)
+	}
+	if c.Add("late", now.Add(20*time.Minute), now.Add(6*time.Minute)) {
+		t.Fatal("expected the unexpired key to be kept")
+	}
+}